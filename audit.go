@@ -0,0 +1,166 @@
+package tourney_tracker
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Entry is a record of a single change made to the tracker, used to find out who changed what.
+type Entry struct {
+	ID int64 `json:"id"`
+
+	// UserID is the User who made the change, or nil if they have since been deleted.
+	UserID *int64 `json:"userID"`
+
+	// Actor is the name of the User who made the change at the time it was made, including the API token they used (if any).
+	Actor string `json:"actor"`
+
+	Action Action `json:"action"`
+
+	// SubjectID is the ID of the object that was changed. The type of the object is given by Action.Subject().
+	SubjectID int64 `json:"subjectID"`
+
+	// Before and After hold the JSON encoding of the changed value before and after the change.
+	// Before is null for objects that were created or restored, and After is null for objects that were deleted.
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Action is the kind of change recorded by an Entry.
+type Action string
+
+const (
+	ActionCreateTournament  Action = "create tournament"
+	ActionDeleteTournament  Action = "delete tournament"
+	ActionRestoreTournament Action = "restore tournament"
+	ActionSetTier           Action = "set tier"
+	ActionSetGame           Action = "set game"
+	ActionSetTeamScoring    Action = "set team scoring"
+	ActionCreatePlayer      Action = "create player"
+	ActionUpdatePlayer      Action = "update player"
+	ActionDeletePlayer      Action = "delete player"
+	ActionRestorePlayer     Action = "restore player"
+	ActionSetPlayers        Action = "set players"
+	ActionCreateSnapshot    Action = "create snapshot"
+	ActionRestoreSnapshot   Action = "restore snapshot"
+	ActionDeleteSnapshot    Action = "delete snapshot"
+	ActionUpdateFormula     Action = "update formula"
+	ActionCreateUser        Action = "create user"
+	ActionUpdateUser        Action = "update user"
+	ActionDeleteUser        Action = "delete user"
+
+	// Purges are made by the server on its own, when objects have been in the trash for too long, or there are too many automatic snapshots.
+	ActionPurgeTournament Action = "purge tournament"
+	ActionPurgePlayer     Action = "purge player"
+	ActionPurgeSnapshot   Action = "purge snapshot"
+)
+
+// Actions holds every Action, in the order they should be listed.
+var Actions = []Action{
+	ActionCreateTournament,
+	ActionDeleteTournament,
+	ActionRestoreTournament,
+	ActionSetTier,
+	ActionSetGame,
+	ActionSetTeamScoring,
+	ActionCreatePlayer,
+	ActionUpdatePlayer,
+	ActionDeletePlayer,
+	ActionRestorePlayer,
+	ActionSetPlayers,
+	ActionCreateSnapshot,
+	ActionRestoreSnapshot,
+	ActionDeleteSnapshot,
+	ActionUpdateFormula,
+	ActionCreateUser,
+	ActionUpdateUser,
+	ActionDeleteUser,
+	ActionPurgeTournament,
+	ActionPurgePlayer,
+	ActionPurgeSnapshot,
+}
+
+// Subject returns the type of object changed by the Action: "tournament", "player", "entrant", "snapshot", "formula", or "user".
+// There is only one formula, so its entries always have a SubjectID of 0.
+func (a Action) Subject() string {
+	switch a {
+	case ActionCreatePlayer, ActionUpdatePlayer, ActionDeletePlayer, ActionRestorePlayer, ActionPurgePlayer:
+		return "player"
+	case ActionSetPlayers:
+		return "entrant"
+	case ActionCreateSnapshot, ActionRestoreSnapshot, ActionDeleteSnapshot, ActionPurgeSnapshot:
+		return "snapshot"
+	case ActionUpdateFormula:
+		return "formula"
+	case ActionCreateUser, ActionUpdateUser, ActionDeleteUser:
+		return "user"
+	default:
+		return "tournament"
+	}
+}
+
+// EntryFilter decides which entries are returned by AuditService.GetEntries. Zero-valued fields match every Entry.
+type EntryFilter struct {
+	// Actor matches entries whose Actor contains the given text, ignoring case.
+	Actor string
+
+	Action Action
+
+	// Subject and SubjectID match entries that changed the given object, eg. every change made to a single Tournament.
+	Subject   string
+	SubjectID int64
+
+	// Limit and Offset select a single page of entries. A Limit of 0 returns every Entry.
+	Limit, Offset int
+}
+
+// AuditService represents a service for reading the changes made to the tracker.
+// Entries are written by the other services, in the same transaction as the change they record, so a change fails if its Entry cannot be written.
+// Each change is made by the actor of the context it is made with. See WithActor.
+type AuditService interface {
+	// GetEntries returns the entries matching the given filter, newest first.
+	GetEntries(ctx context.Context, filter EntryFilter) ([]Entry, error)
+}
+
+// actorKey is the context key of the actor making changes.
+type actorKey struct{}
+
+// actor identifies who made a change. See Entry.UserID and Entry.Actor.
+type actor struct {
+	userID *int64
+	name   string
+}
+
+// WithActor returns a copy of the context that attributes the changes made with it to the given User, or to the named part of the server if userID is nil.
+func WithActor(ctx context.Context, userID *int64, name string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor{userID, name})
+}
+
+// ActorOf returns the User and name that the changes made with the context are attributed to. The User is nil if the changes are made by the server.
+func ActorOf(ctx context.Context) (userID *int64, name string) {
+	a, _ := ctx.Value(actorKey{}).(actor)
+	return a.userID, a.name
+}
+
+// NewEntry returns an Entry recording the given change, made by the actor of the context.
+// The values before and after the change are encoded as JSON, and a nil value is left as null.
+func NewEntry(ctx context.Context, action Action, subjectID int64, before, after any) (entry Entry, err error) {
+	entry = Entry{Action: action, SubjectID: subjectID}
+	entry.UserID, entry.Actor = ActorOf(ctx)
+
+	if before != nil {
+		if entry.Before, err = json.Marshal(before); err != nil {
+			return entry, err
+		}
+	}
+	if after != nil {
+		if entry.After, err = json.Marshal(after); err != nil {
+			return entry, err
+		}
+	}
+
+	return entry, nil
+}
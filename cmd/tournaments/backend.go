@@ -0,0 +1,102 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/ejacobg/tourney-tracker/http"
+	"github.com/ejacobg/tourney-tracker/postgres"
+	"github.com/ejacobg/tourney-tracker/sqlite"
+	"log"
+	"strings"
+
+	_ "github.com/lib/pq"
+)
+
+// migrators holds the schema functions of each backend, keyed by the backend's DSN scheme.
+var migrators = map[string]struct {
+	version func(*sql.DB) (current, latest int, err error)
+	migrate func(*sql.DB) error
+}{
+	"sqlite":   {sqlite.Version, sqlite.Migrate},
+	"postgres": {postgres.Version, postgres.Migrate},
+}
+
+// openBackend connects to the database named by the DSN, checks its schema, and sets the services of the Server to the matching backend.
+// If migrate is true, any pending migrations are applied. Otherwise, an error is returned if the schema is out of date.
+func openBackend(dsn string, srv *http.Server, migrate bool) (*sql.DB, error) {
+	db, scheme, err := openDB(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = checkSchema(db, scheme, migrate); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	switch scheme {
+	case "sqlite":
+		srv.Services = sqlite.NewServices(db)
+	case "postgres":
+		srv.Services = postgres.NewServices(db)
+	}
+
+	return db, nil
+}
+
+// openDB connects to the database named by the DSN, and returns the scheme of its backend: either "sqlite" or "postgres".
+// DSNs starting with "sqlite:" name an SQLite database file (eg. "sqlite:tracker.db" or "sqlite:///var/lib/tracker.db").
+// DSNs starting with "postgres:" or "postgresql:", or using PostgreSQL's key=value format, are passed to PostgreSQL.
+func openDB(dsn string) (*sql.DB, string, error) {
+	scheme, rest, ok := strings.Cut(dsn, ":")
+	if !ok || strings.Contains(scheme, "=") {
+		scheme = "postgres"
+	}
+
+	switch scheme {
+	case "sqlite":
+		db, err := sqlite.Open(strings.TrimPrefix(rest, "//"))
+		return db, scheme, err
+	case "postgres", "postgresql":
+		db, err := openPostgres(dsn)
+		return db, "postgres", err
+	}
+
+	return nil, "", fmt.Errorf("unsupported DSN scheme %q", scheme)
+}
+
+func openPostgres(dsn string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// checkSchema compares the version of the database's schema with the migrations built into the binary.
+// If migrate is true, any pending migrations are applied. Otherwise, an error is returned if the schema is out of date.
+// Schemas newer than the binary are always refused, since the binary may not know how to use them.
+func checkSchema(db *sql.DB, scheme string, migrate bool) error {
+	m := migrators[scheme]
+
+	current, latest, err := m.version(db)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case current > latest:
+		return fmt.Errorf("the database schema is at version %d, which is newer than this build supports (version %d)", current, latest)
+	case current == latest:
+		return nil
+	case !migrate:
+		return fmt.Errorf("the database schema is at version %d, but version %d is needed; use the migrate subcommand to update it", current, latest)
+	}
+
+	log.Printf("Migrating the database schema from version %d to %d.", current, latest)
+	return m.migrate(db)
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	tournament "github.com/ejacobg/tourney-tracker"
+	"github.com/ejacobg/tourney-tracker/http"
+	"github.com/ejacobg/tourney-tracker/validator"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// envPrefix starts the name of the environment variable for each setting, eg. TOURNEYTRACKER_REQUEST_TIMEOUT for -request-timeout.
+const envPrefix = "TOURNEYTRACKER_"
+
+// config holds the settings of the server.
+type config struct {
+	addr    string
+	baseURL string
+	dsn     string
+	uiDir   string
+
+	// Credentials needed for API calls.
+	challongeUsername, challongePassword string
+	startggKey                           string
+
+	defaultTier    int64
+	trashRetention time.Duration
+	keepSnapshots  int
+
+	requestTimeout, importTimeout          time.Duration
+	readTimeout, writeTimeout, idleTimeout time.Duration
+	shutdownTimeout                        time.Duration
+
+	// Features that can be turned on or off.
+	demo, migrate, purgeTrash bool
+}
+
+// secrets are the settings that may instead be read from a file named by their -file setting, eg. -dsn-file.
+var secrets = []string{"dsn", "challonge-pass", "startgg-key"}
+
+// source is where a setting was read from. Settings from sources with higher values take precedence.
+type source int
+
+const (
+	fromDefault source = iota
+	fromFile
+	fromEnv
+	fromFlag
+)
+
+func (s source) String() string {
+	switch s {
+	case fromFile:
+		return "the config file"
+	case fromEnv:
+		return "the environment"
+	case fromFlag:
+		return "the command line"
+	default:
+		return "default"
+	}
+}
+
+// loadConfig reads the server's settings from the command-line arguments, the environment, and a TOML config file, in that order of precedence.
+// The config file is named by the -config flag or the TOURNEYTRACKER_CONFIG variable, and holds a key for each flag, eg. request-timeout = "10s".
+// The settings are added to the given FlagSet, which may already hold the flags of a subcommand. These are only read from the command line.
+// An error is returned if any setting is invalid.
+func loadConfig(fs *flag.FlagSet, args []string) (cfg config, err error) {
+	sources := make(map[string]source)
+	fs.VisitAll(func(f *flag.Flag) { sources[f.Name] = fromFlag })
+
+	path := fs.String("config", "", "Path of a TOML file to read settings from. Flags and environment variables take precedence over it")
+	fs.StringVar(&cfg.addr, "addr", ":4000", "Address to listen on")
+	fs.StringVar(&cfg.baseURL, "base-url", "", "Address that users reach the server at, eg. https://tourneys.example.com. Defaults to http://localhost with the port of -addr")
+	fs.StringVar(&cfg.dsn, "dsn", "", "PostgreSQL DSN, or sqlite:<path> for an SQLite database file")
+	fs.StringVar(&cfg.challongeUsername, "challonge-user", "", "Challonge Username")
+	fs.StringVar(&cfg.challongePassword, "challonge-pass", "", "Challonge Password or API Key")
+	fs.StringVar(&cfg.startggKey, "startgg-key", "", "start.gg API Key")
+	fs.StringVar(&cfg.uiDir, "ui-dir", "", "Serve the templates and static files from this directory instead of the ones built into the binary, reloading the templates on each request (eg. -ui-dir=ui during development)")
+	fs.Int64Var(&cfg.defaultTier, "default-tier", 1, "ID of the tier given to imported tournaments")
+	fs.DurationVar(&cfg.trashRetention, "trash-retention", tournament.DefaultTrashRetention, "How long deleted tournaments and players can be restored for")
+	fs.IntVar(&cfg.keepSnapshots, "keep-snapshots", tournament.DefaultSnapshotRetention, "How many automatic snapshots to keep. Older ones are deleted alongside the trash by -purge-trash")
+	fs.DurationVar(&cfg.requestTimeout, "request-timeout", http.DefaultRequestTimeout, "How long a request may take before its database work is cancelled")
+	fs.DurationVar(&cfg.importTimeout, "import-timeout", http.DefaultImportTimeout, "How long a request importing a tournament from Challonge or start.gg may take")
+	fs.DurationVar(&cfg.readTimeout, "read-timeout", http.DefaultReadTimeout, "How long reading a request, including its body, may take")
+	fs.DurationVar(&cfg.writeTimeout, "write-timeout", http.DefaultWriteTimeout, "How long writing a response may take, counted from the end of the request")
+	fs.DurationVar(&cfg.idleTimeout, "idle-timeout", http.DefaultIdleTimeout, "How long an idle connection is kept open")
+	fs.DurationVar(&cfg.shutdownTimeout, "shutdown-timeout", http.DefaultShutdownTimeout, "How long to wait for requests and background jobs to finish after an interrupt or termination signal")
+	fs.BoolVar(&cfg.demo, "demo", false, "Serve example tournaments from memory instead of a database. Changes are lost when the server stops")
+	fs.BoolVar(&cfg.migrate, "migrate", true, "Apply pending database migrations at startup. If false, the server will not start until the migrate subcommand is run")
+	fs.BoolVar(&cfg.purgeTrash, "purge-trash", true, "Permanently delete tournaments and players left in the trash for longer than -trash-retention, and automatic snapshots beyond -keep-snapshots")
+
+	secretFiles := make(map[string]*string)
+	for _, name := range secrets {
+		secretFiles[name] = fs.String(name+"-file", "", fmt.Sprintf("File to read -%s from, so that it does not show up in process listings", name))
+	}
+
+	fs.Parse(args)
+
+	// Settings given as flags are not overridden by the environment or the config file.
+	fs.Visit(func(f *flag.Flag) { sources[f.Name] = fromFlag })
+
+	if sources["config"] != fromFlag {
+		*path = os.Getenv(envName("config"))
+	}
+	if *path != "" {
+		if err = readConfigFile(fs, *path, sources); err != nil {
+			return cfg, err
+		}
+	}
+
+	if err = readEnv(fs, sources); err != nil {
+		return cfg, err
+	}
+
+	// Each secret is taken from the source with the highest precedence that sets it, either directly or through its file.
+	// A source may only set one of the two, but a secret set one way may override a file set by a source below it, and vice versa.
+	for _, name := range secrets {
+		value, file := sources[name], sources[name+"-file"]
+		if file == fromDefault || file < value {
+			continue
+		}
+		if file == value {
+			return cfg, fmt.Errorf("only one of -%s and -%s-file may be set in %s", name, name, file)
+		}
+
+		data, err := os.ReadFile(*secretFiles[name])
+		if err != nil {
+			return cfg, fmt.Errorf("failed to read -%s-file: %w", name, err)
+		}
+		fs.Set(name, strings.TrimSpace(string(data)))
+	}
+
+	if err = cfg.validate(); err != nil {
+		return cfg, err
+	}
+
+	if cfg.baseURL == "" {
+		_, port, _ := net.SplitHostPort(cfg.addr)
+		cfg.baseURL = "http://localhost:" + port
+	}
+	cfg.baseURL = strings.TrimSuffix(cfg.baseURL, "/")
+
+	return cfg, nil
+}
+
+// envName returns the name of the environment variable for the given setting.
+func envName(setting string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(setting, "-", "_"))
+}
+
+// readEnv applies the environment variable of each setting that was not given as a flag, and records the settings it applied in sources.
+func readEnv(fs *flag.FlagSet, sources map[string]source) (err error) {
+	fs.VisitAll(func(f *flag.Flag) {
+		value, ok := os.LookupEnv(envName(f.Name))
+		if !ok || sources[f.Name] > fromEnv || f.Name == "config" || err != nil {
+			return
+		}
+		if err = fs.Set(f.Name, value); err != nil {
+			err = fmt.Errorf("invalid value for %s: %w", envName(f.Name), err)
+			return
+		}
+		sources[f.Name] = fromEnv
+	})
+	return err
+}
+
+// readConfigFile applies the settings in the given TOML file that were not given as flags, and records the settings it applied in sources.
+// The file may only hold top-level keys, named after the flags.
+func readConfigFile(fs *flag.FlagSet, path string, sources map[string]source) error {
+	var settings map[string]any
+	if _, err := toml.DecodeFile(path, &settings); err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	for name, value := range settings {
+		if name == "config" || fs.Lookup(name) == nil {
+			return fmt.Errorf("%s: unknown setting %q", path, name)
+		}
+
+		switch value.(type) {
+		case string, int64, bool:
+		default:
+			return fmt.Errorf("%s: %s must be a string, integer, or boolean", path, name)
+		}
+
+		if sources[name] > fromFile {
+			continue
+		}
+		if err := fs.Set(name, fmt.Sprint(value)); err != nil {
+			return fmt.Errorf("%s: invalid value for %s: %w", path, name, err)
+		}
+		sources[name] = fromFile
+	}
+
+	return nil
+}
+
+// validate checks that every setting has a usable value. All problems are reported together.
+func (cfg config) validate() error {
+	v := validator.New()
+
+	_, _, err := net.SplitHostPort(cfg.addr)
+	v.Check(err == nil, "addr", "must be a host and port, eg. :4000")
+
+	if cfg.baseURL != "" {
+		u, err := url.Parse(cfg.baseURL)
+		v.Check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", "base-url", "must be an http or https URL")
+	}
+
+	v.Check(cfg.demo || cfg.dsn != "", "dsn", "must be set, unless -demo is")
+	v.Check(cfg.defaultTier > 0, "default-tier", "must be greater than 0")
+	v.Check(cfg.trashRetention > 0, "trash-retention", "must be greater than 0")
+	v.Check(cfg.keepSnapshots > 0, "keep-snapshots", "must be greater than 0")
+
+	if cfg.uiDir != "" {
+		info, err := os.Stat(cfg.uiDir)
+		v.Check(err == nil && info.IsDir(), "ui-dir", "must be a directory")
+	}
+
+	timeouts := map[string]time.Duration{
+		"request-timeout":  cfg.requestTimeout,
+		"import-timeout":   cfg.importTimeout,
+		"read-timeout":     cfg.readTimeout,
+		"write-timeout":    cfg.writeTimeout,
+		"idle-timeout":     cfg.idleTimeout,
+		"shutdown-timeout": cfg.shutdownTimeout,
+	}
+	for name, timeout := range timeouts {
+		v.Check(timeout >= 0, name, "must not be negative")
+	}
+
+	// Requests must finish before the connection's write timeout, so that their errors can still be written.
+	v.Check(cfg.writeTimeout == 0 || cfg.requestTimeout < cfg.writeTimeout, "request-timeout", "must be shorter than -write-timeout")
+	v.Check(cfg.writeTimeout == 0 || cfg.importTimeout < cfg.writeTimeout, "import-timeout", "must be shorter than -write-timeout")
+
+	if v.Valid() {
+		return nil
+	}
+
+	problems := make([]string, 0, len(v.Errors))
+	for name, message := range v.Errors {
+		problems = append(problems, fmt.Sprintf("-%s %s", name, message))
+	}
+	sort.Strings(problems)
+
+	return errors.New("invalid settings: " + strings.Join(problems, "; "))
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	tournament "github.com/ejacobg/tourney-tracker"
+	"github.com/ejacobg/tourney-tracker/http"
+	"github.com/ejacobg/tourney-tracker/inmem"
+)
+
+// demoTournaments holds the example tournaments from sql/tournaments.sql, along with the ID of their Tier.
+var demoTournaments = []struct {
+	tournament.Tournament
+	tierID int64
+}{
+	{tournament.Tournament{Name: "(SSC C TIER) Gator Grind #9", URL: "https://challonge.com/kpqlgghc", Placements: []int64{17, 13, 9, 7, 5, 4, 3, 2, 1}}, 1},
+	{tournament.Tournament{Name: "(SSC C Tier) Gator Grind #12", URL: "https://challonge.com/8ozc6ffz", Placements: []int64{17, 13, 9, 7, 5, 4, 3, 2, 1}}, 1},
+	{tournament.Tournament{Name: "(SSC C Tier) Gator Grind #7", URL: "https://challonge.com/t4kq4f5b", BracketReset: true, Placements: []int64{17, 13, 9, 7, 5, 4, 3, 2, 1}}, 1},
+	{tournament.Tournament{
+		Name:       "Silver State Smash x Pirate Hackers Black Lives Matter Charity Tournament - Singles 1v1",
+		URL:        "https://start.gg/tournament/silver-state-smash-x-pirate-hackers-black-lives-matter-charity/event/singles-1v1",
+		Placements: []int64{33, 17, 13, 9, 7, 5, 4, 3, 2, 1},
+	}, 3},
+	{tournament.Tournament{Name: "Wrangler Rumble #1 - Ultimate Singles", URL: "https://start.gg/tournament/wrangler-rumble-1/event/ultimate-singles", Placements: []int64{13, 9, 7, 5, 4, 3, 2, 1}}, 2},
+	{tournament.Tournament{Name: "Shinto Series: Smash #1 - Singles 1v1", URL: "https://start.gg/tournament/shinto-series-smash-1/event/singles-1v1", BracketReset: true, Placements: []int64{97, 65, 49, 33, 25, 17, 13, 9, 7, 5, 4, 3, 2, 1}}, 1},
+}
+
+// demoPlayers are the players linked to the top entrants of each demo tournament, so that the rankings have something to show.
+var demoPlayers = []string{"Ace", "Blaze", "Cinder", "Dash", "Echo", "Flux", "Gale", "Haze", "Ion", "Jinx", "Kite", "Lumen"}
+
+// openDemo sets the services of the Server to an in-memory database, holding the example tournaments and an admin to log in as.
+// It returns the name and a randomly generated password of the admin.
+func openDemo(srv *http.Server) (user, password string, err error) {
+	// The demo data is recorded in the audit log as made by the server itself.
+	ctx := tournament.WithActor(context.Background(), nil, "demo")
+	srv.Services = inmem.NewServices(inmem.NewDB())
+
+	playerIDs := make([]int64, len(demoPlayers))
+	for i, name := range demoPlayers {
+		player := tournament.Player{Name: name}
+		if err = srv.PlayerService.CreatePlayer(ctx, &player); err != nil {
+			return "", "", err
+		}
+		playerIDs[i] = player.ID
+	}
+
+	for i, demo := range demoTournaments {
+		tourney := demo.Tournament
+		tourney.BracketType = tournament.DoubleElimination
+
+		// Each tournament starts at a different player, so that the players do not place the same way every time.
+		linked := make([]int64, 0, len(playerIDs))
+		entrants := demoEntrants(tourney.Placements)
+		for j := range entrants {
+			if j < len(playerIDs) {
+				k := (j + 5*i) % len(playerIDs)
+				entrants[j].Name = demoPlayers[k]
+				linked = append(linked, playerIDs[k])
+			}
+		}
+
+		if err = srv.TournamentService.CreateTournament(ctx, &tourney, entrants); err != nil {
+			return "", "", err
+		}
+		if err = srv.TournamentService.SetTier(ctx, tourney.ID, demo.tierID); err != nil {
+			return "", "", err
+		}
+		for j, playerID := range linked {
+			if err = srv.EntrantService.SetPlayers(ctx, entrants[j].ID, 0, []int64{playerID}); err != nil {
+				return "", "", err
+			}
+		}
+	}
+
+	// The demo's password is random, since anyone who can reach the server can log in with it.
+	if password, err = tournament.NewToken(); err != nil {
+		return "", "", err
+	}
+	admin := tournament.User{Name: "demo", Role: tournament.RoleAdmin}
+	if err = admin.SetPassword(password); err != nil {
+		return "", "", err
+	}
+	if err = srv.UserService.CreateUser(ctx, &admin); err != nil {
+		return "", "", err
+	}
+	return admin.Name, password, nil
+}
+
+// demoEntrants returns entrants for the given Placements, from first place to last. Each placement is shared by as many entrants as
+// there are places before the next one (eg. 5th is shared by two entrants when followed by 7th), and the last placement is shared by as
+// many entrants as the placement before it.
+func demoEntrants(placements []int64) []tournament.Entrant {
+	var entrants []tournament.Entrant
+	for i := len(placements) - 1; i >= 0; i-- {
+		count := int64(1)
+		if i > 0 {
+			count = placements[i-1] - placements[i]
+		} else if len(placements) > 1 {
+			count = placements[i] - placements[i+1]
+		}
+		for j := int64(0); j < count; j++ {
+			entrants = append(entrants, tournament.Entrant{Name: fmt.Sprintf("Entrant %d", len(entrants)+1), Placement: placements[i]})
+		}
+	}
+	return entrants
+}
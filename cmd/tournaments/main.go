@@ -1,80 +1,221 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"flag"
 	"fmt"
+	tournament "github.com/ejacobg/tourney-tracker"
 	"github.com/ejacobg/tourney-tracker/http"
-	"github.com/ejacobg/tourney-tracker/postgres"
+	"github.com/ejacobg/tourney-tracker/ui"
 	"html/template"
+	"io/fs"
 	"log"
-	"path/filepath"
+	"os"
+	"os/signal"
 	"strings"
-
-	_ "github.com/lib/pq"
+	"syscall"
+	"time"
 )
 
 func main() {
-	dsn := flag.String("dsn", "", "PostgreSQL DSN")
-	challongeUsername := flag.String("challonge-user", "", "Challonge Username")
-	challongePassword := flag.String("challonge-pass", "", "Challonge Password or API Key")
-	startggKey := flag.String("startgg-key", "", "start.gg API Key")
-	flag.Parse()
+	// Subcommands are handled before the server's flags are parsed, since they have their own.
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "create-user":
+			if err := createUser(os.Args[2:]); err != nil {
+				log.Fatalln("Failed to create user:", err)
+			}
+			return
+		case "migrate":
+			if err := migrateSchema(os.Args[2:]); err != nil {
+				log.Fatalln("Failed to migrate database:", err)
+			}
+			return
+		}
+	}
 
-	tc, err := newTemplateCache()
+	cfg, err := loadConfig(flag.NewFlagSet(os.Args[0], flag.ExitOnError), os.Args[1:])
 	if err != nil {
-		log.Fatalln("Failed to create template:", err)
+		log.Fatalln("Failed to load config:", err)
 	}
 
-	db, err := openDB(*dsn)
+	var files fs.FS = ui.Files
+	if cfg.uiDir != "" {
+		files = os.DirFS(cfg.uiDir)
+	}
+
+	tc, err := newTemplateCache(files)
 	if err != nil {
-		log.Fatalln("Failed to connect to database:", err)
+		log.Fatalln("Failed to create template:", err)
 	}
 
-	srv := http.NewServer(*challongeUsername, *challongePassword, *startggKey)
-	srv.Addr = ":4000"
+	srv := http.NewServer(cfg.challongeUsername, cfg.challongePassword, cfg.startggKey)
+	srv.Addr = cfg.addr
+	srv.BaseURL = cfg.baseURL
 	srv.Templates = tc
-	srv.EntrantService = postgres.EntrantService{db}
-	srv.PlayerService = postgres.PlayerService{db}
-	srv.TierService = postgres.TierService{db}
-	srv.TournamentService = postgres.TournamentService{db}
+	srv.UI = files
+	if cfg.uiDir != "" {
+		srv.LoadTemplates = func() (map[string]*template.Template, error) { return newTemplateCache(files) }
+	}
+	srv.TrashRetention = cfg.trashRetention
+	srv.RequestTimeout = cfg.requestTimeout
+	srv.ImportTimeout = cfg.importTimeout
+	srv.ReadTimeout = cfg.readTimeout
+	srv.WriteTimeout = cfg.writeTimeout
+	srv.IdleTimeout = cfg.idleTimeout
+	srv.ShutdownTimeout = cfg.shutdownTimeout
+	srv.DefaultTierID = cfg.defaultTier
+
+	// The demo keeps its data in memory, so it has no database to close.
+	var db *sql.DB
+	if cfg.demo {
+		user, password, err := openDemo(srv)
+		if err != nil {
+			log.Fatalln("Failed to set up demo:", err)
+		}
+		// The credentials are printed since the demo's data is thrown away when the server stops.
+		fmt.Printf("Serving demo data. Log in as %q with the password %q.\n", user, password)
+	} else if db, err = openBackend(cfg.dsn, srv, cfg.migrate); err != nil {
+		log.Fatalln("Failed to connect to database:", err)
+	}
 
-	fmt.Println("Serving on http://localhost:4000")
-	log.Fatalln(srv.ListenAndServe())
+	if _, err = srv.TierService.GetTier(context.Background(), cfg.defaultTier); err != nil {
+		log.Fatalf("Failed to find the default tier %d: %s", cfg.defaultTier, err)
+	}
+
+	// The server shuts down on the first interrupt or termination signal. A second signal stops it immediately.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	if cfg.purgeTrash {
+		srv.Background(func(jobCtx context.Context) {
+			// Purges are recorded in the audit log as made by the server itself.
+			jobCtx = tournament.WithActor(jobCtx, nil, "trash purge")
+			purgeTrash(jobCtx, ctx.Done(), srv.TournamentService, srv.PlayerService, srv.SnapshotService, cfg.trashRetention, cfg.keepSnapshots)
+		})
+	}
+
+	fmt.Println("Serving on", cfg.baseURL)
+	err = srv.ListenAndServe(ctx)
+
+	// The database is closed last, so that anything still running when the server stopped had the chance to finish with it.
+	if db != nil {
+		if closeErr := db.Close(); closeErr != nil {
+			log.Println("Failed to close database:", closeErr)
+		}
+	}
+
+	if err != nil {
+		log.Fatalln("Server stopped:", err)
+	}
+	log.Println("Server stopped.")
 }
 
-func openDB(dsn string) (*sql.DB, error) {
-	db, err := sql.Open("postgres", dsn)
+// purgeTimeout is how long each check of the trash may take, including the Snapshot taken before purging and the purge of old snapshots.
+const purgeTimeout = 5 * time.Minute
+
+// purgeTrash permanently deletes the tournaments and players that have been in the trash for longer than the given retention period.
+// The trash is checked once an hour, until done is closed. If anything is about to be purged, a Snapshot is taken first.
+// Each check also deletes every automatic Snapshot except for the newest keep, so that they do not pile up.
+// A check that has already started when done is closed is allowed to finish, unless ctx is cancelled first.
+func purgeTrash(ctx context.Context, done <-chan struct{}, tournaments tournament.TournamentService, players tournament.PlayerService, snapshots tournament.SnapshotService, retention time.Duration, keep int) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		purgeCtx, cancel := context.WithTimeout(ctx, purgeTimeout)
+		purgeExpired(purgeCtx, tournaments, players, snapshots, time.Now().Add(-retention))
+		if err := snapshots.PurgeSnapshots(purgeCtx, keep); err != nil {
+			log.Println("Failed to purge old snapshots:", err)
+		}
+		cancel()
+
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purgeExpired permanently deletes the tournaments and players that were moved to the trash before the given time. Failures are logged.
+func purgeExpired(ctx context.Context, tournaments tournament.TournamentService, players tournament.PlayerService, snapshots tournament.SnapshotService, before time.Time) {
+	expired, err := trashedBefore(ctx, tournaments, players, before)
 	if err != nil {
-		return nil, err
+		log.Println("Failed to check the trash:", err)
+		return
 	}
-	if err = db.Ping(); err != nil {
-		return nil, err
+	if !expired {
+		return
+	}
+
+	err = snapshots.CreateSnapshot(ctx, &tournament.Snapshot{Name: "Before purging the trash", Automatic: true})
+	if err != nil {
+		// Nothing is purged without a Snapshot, since it could not be undone.
+		log.Println("Failed to take snapshot before purging the trash:", err)
+		return
+	}
+
+	if err := tournaments.PurgeTournaments(ctx, before); err != nil {
+		log.Println("Failed to purge tournaments:", err)
 	}
-	return db, nil
+	if err := players.PurgePlayers(ctx, before); err != nil {
+		log.Println("Failed to purge players:", err)
+	}
+}
+
+// trashedBefore returns true if any tournament or player was moved to the trash before the given time.
+func trashedBefore(ctx context.Context, tournaments tournament.TournamentService, players tournament.PlayerService, before time.Time) (bool, error) {
+	trashed, err := tournaments.GetDeletedTournaments(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	deletedPlayers, err := players.GetDeletedPlayers(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	for _, t := range append(trashed, deletedPlayers...) {
+		if t.DeletedAt.Before(before) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// functions holds the custom functions available to every template.
+var functions = template.FuncMap{
+	"join": strings.Join,
 }
 
-func newTemplateCache() (cache map[string]*template.Template, err error) {
+// newTemplateCache parses every page template in the html directory of the given files, along with the base template and partials.
+func newTemplateCache(files fs.FS) (cache map[string]*template.Template, err error) {
 	cache = make(map[string]*template.Template)
 
-	pages, err := filepath.Glob("ui/html/pages/*/*.go.html")
+	pages, err := fs.Glob(files, "html/pages/*/*.go.html")
 	if err != nil {
 		return nil, err
 	}
 	// Manually adding the index and about pages.
-	pages = append(pages, "ui/html/pages/index.go.html", "ui/html/pages/about.go.html")
+	pages = append(pages, "html/pages/index.go.html", "html/pages/about.go.html")
 
 	for _, page := range pages {
-		name := strings.TrimPrefix(filepath.ToSlash(page), "ui/html/pages/")
-		fmt.Println(name)
+		name := strings.TrimPrefix(page, "html/pages/")
 
-		files := []string{
-			"ui/html/base.go.html",
-			"ui/html/partials/nav.go.html",
+		patterns := []string{
+			"html/base.go.html",
+			"html/partials/nav.go.html",
+			"html/partials/games.go.html",
+			"html/partials/errors.go.html",
 			page,
 		}
 
-		tmpl, err := template.New(name).ParseFiles(files...)
+		tmpl, err := template.New(name).Funcs(functions).Funcs(http.Functions).ParseFS(files, patterns...)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+)
+
+// migrateSchema implements the migrate subcommand, which applies any pending migrations without starting the server.
+// This is used with -migrate=false, for deployments that update their schema as a separate step.
+func migrateSchema(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	status := fs.Bool("status", false, "Print the version of the schema without applying any migrations")
+	cfg, err := loadConfig(fs, args)
+	if err != nil {
+		return err
+	}
+
+	db, scheme, err := openDB(cfg.dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	m := migrators[scheme]
+
+	current, latest, err := m.version(db)
+	if err != nil {
+		return err
+	}
+
+	if *status || current >= latest {
+		fmt.Printf("The database schema is at version %d. The latest version is %d.\n", current, latest)
+		return nil
+	}
+
+	if err = m.migrate(db); err != nil {
+		return err
+	}
+
+	fmt.Printf("Migrated the database schema from version %d to %d.\n", current, latest)
+	return nil
+}
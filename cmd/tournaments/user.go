@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	tournament "github.com/ejacobg/tourney-tracker"
+	"github.com/ejacobg/tourney-tracker/http"
+	"github.com/ejacobg/tourney-tracker/validator"
+	"os"
+	"strings"
+)
+
+// createUser implements the create-user subcommand, which adds a User to the database.
+// This is how the first User is created, since users can only be added by someone who is logged in.
+// The password is read from standard input, so that it does not show up in the shell history.
+func createUser(args []string) error {
+	fs := flag.NewFlagSet("create-user", flag.ExitOnError)
+	name := fs.String("name", "", "Name of the new user")
+	role := fs.String("role", string(tournament.RoleAdmin), "Role of the new user (viewer, editor, organizer, or admin)")
+	cfg, err := loadConfig(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(*name) == "" {
+		return errors.New("a -name must be given")
+	}
+	if !tournament.Role(*role).Valid() {
+		return fmt.Errorf("unknown role %q", *role)
+	}
+
+	fmt.Print("Password: ")
+	password, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("failed to read password: %w", err)
+	}
+
+	password = strings.TrimRight(password, "\r\n")
+	v := validator.New()
+	if tournament.ValidatePassword(v, password); !v.Valid() {
+		return errors.New(v.Errors["password"])
+	}
+
+	user := tournament.User{Name: *name, Role: tournament.Role(*role)}
+	if err = user.SetPassword(password); err != nil {
+		return err
+	}
+
+	// Only the UserService of the Server is used.
+	// The schema is never migrated here. A stale schema is reported, as the server does with -migrate=false.
+	var srv http.Server
+	db, err := openBackend(cfg.dsn, &srv, false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// There is no User to attribute the change to, so it is recorded as made by this subcommand.
+	ctx := tournament.WithActor(context.Background(), nil, "tournaments create-user")
+	err = srv.UserService.CreateUser(ctx, &user)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Created %s %q with ID %d.\n", user.Role, user.Name, user.ID)
+	return nil
+}
@@ -0,0 +1,203 @@
+package convert
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+var (
+	ErrNoMatches         = errors.New("no matches")
+	ErrIncompleteBracket = errors.New("incomplete bracket")
+)
+
+// Match represents a single completed set between two entrants.
+// Entrant IDs are whatever the platform uses to identify its participants, they only need to be consistent across matches.
+type Match struct {
+	Player1ID int64
+	Player2ID int64
+	WinnerID  int64
+	LoserID   int64
+
+	// Round is the bracket round the match was played in. Following Challonge's convention, losers bracket rounds are negative,
+	// and the third-place match of a single-elimination bracket is round 0.
+	Round int
+
+	// Order is the relative order that the match was played in. A match must have a higher Order than any match feeding into it.
+	Order int
+}
+
+// Placements derives the final placement of every entrant that appears in the given elimination matches.
+// The winner of the last match in the highest round takes first place, and everyone else is placed by the match they were knocked out in.
+// Entrants knocked out in the same round share a placement, giving the familiar 7, 7, 5, 5, 4, 3, 2, 1 standings.
+// A third-place match (round 0) splits the third place shared by the losers of the semi-finals.
+func Placements(matches []Match) (map[int64]int64, error) {
+	if len(matches) == 0 {
+		return nil, ErrNoMatches
+	}
+
+	matches = sorted(matches)
+	for _, m := range matches {
+		if m.WinnerID == 0 || m.LoserID == 0 {
+			return nil, ErrIncompleteBracket
+		}
+	}
+
+	// The third-place match is played after the semi-finals, but it does not knock anyone out of the running for first.
+	// It is left out while placing everyone else, so that it is not mistaken for a later round.
+	var thirdPlace *Match
+	bracket := make([]Match, 0, len(matches))
+	for i, m := range matches {
+		if m.Round == 0 {
+			thirdPlace = &matches[i]
+			continue
+		}
+		bracket = append(bracket, m)
+	}
+	if len(bracket) == 0 {
+		return nil, ErrIncompleteBracket
+	}
+
+	// An entrant is knocked out in the last match that they lost.
+	// Since the matches are sorted, later losses will overwrite earlier ones.
+	knockouts := make(map[int64]Match)
+	for _, m := range bracket {
+		knockouts[m.LoserID] = m
+	}
+
+	// The final is the last match of the highest round, which is the grand final (or its reset) in double elimination.
+	// Its winner must have won the tournament, so they will not be knocked out.
+	final := bracket[0]
+	for _, m := range bracket {
+		if m.Round >= final.Round {
+			final = m
+		}
+	}
+	champion := final.WinnerID
+	delete(knockouts, champion)
+
+	// Group the knocked out entrants by the round they were knocked out in.
+	// The order of each group is the order of the latest match in that round, which is used to rank the groups.
+	groups := make(map[int][]int64)
+	orders := make(map[int]int)
+	for id, m := range knockouts {
+		groups[m.Round] = append(groups[m.Round], id)
+		if m.Order > orders[m.Round] {
+			orders[m.Round] = m.Order
+		}
+	}
+
+	rounds := maps.Keys(groups)
+	slices.SortFunc(rounds, func(a, b int) bool {
+		return orders[a] > orders[b]
+	})
+
+	// Every entrant in a group takes the placement just below everyone who was knocked out after them.
+	placements := map[int64]int64{champion: 1}
+	placed := int64(1)
+	for _, round := range rounds {
+		for _, id := range groups[round] {
+			placements[id] = placed + 1
+		}
+		placed += int64(len(groups[round]))
+	}
+
+	// The loser of the third-place match drops below the winner, if they were sharing third place.
+	if thirdPlace != nil && placements[thirdPlace.LoserID] == placements[thirdPlace.WinnerID] {
+		placements[thirdPlace.LoserID]++
+	}
+
+	return placements, nil
+}
+
+// BracketReset returns true if the second-place finisher made a bracket reset.
+// Bracket reset points should be applied if:
+//  1. The last two matches occurred between first and second place.
+//     If this is true, then the last two matches must be the grand final and the grand final reset.
+//     Otherwise, the last two matches would be the loser's final and the grand final.
+//  2. The winners of the last two matches are different.
+//     If the winner of the grand final and the winner of the grand final reset are different, then that means the second-place finisher made a reset, but did not win.
+//     Note that this condition is true if the last two matches are the loser's final and the grand final. However, this case is handled by (1).
+func BracketReset(matches []Match) bool {
+	if len(matches) < 2 {
+		return false
+	}
+
+	matches = sorted(matches)
+
+	// The last match is either the grand final or the grand final reset.
+	// In either case, the first and second place finalists are both present in this match.
+	last := matches[len(matches)-1]
+
+	// The previous match is either the loser's final or the grand final.
+	prev := matches[len(matches)-2]
+
+	// 1. The last two matches occurred between first and second place.
+	if !in(last.WinnerID, prev.Player1ID, prev.Player2ID) || !in(last.LoserID, prev.Player1ID, prev.Player2ID) {
+		return false
+	}
+
+	// 2. The winners of the last two matches are different.
+	return last.WinnerID != prev.WinnerID
+}
+
+// UniquePlacements returns the unique values of the given placements, in reverse-sorted order.
+func UniquePlacements(placements map[int64]int64) []int64 {
+	unique := make(map[int64]bool)
+	for _, placement := range placements {
+		unique[placement] = true
+	}
+
+	keys := maps.Keys(unique)
+	slices.SortFunc(keys, func(a, b int64) bool {
+		return a > b
+	})
+
+	return keys
+}
+
+// PlacementMismatch is returned by CheckPlacements when a reported placement disagrees with the bracket results.
+type PlacementMismatch struct {
+	EntrantID int64
+	Reported  int64
+	Derived   int64
+}
+
+func (e *PlacementMismatch) Error() string {
+	return fmt.Sprintf("entrant %d reported in %d place, but the bracket places them in %d", e.EntrantID, e.Reported, e.Derived)
+}
+
+// CheckPlacements cross-checks the placements reported by a platform against the placements derived from its matches.
+// Entrants that do not appear in any match (eg. entrants who were disqualified before playing) are not checked.
+func CheckPlacements(reported map[int64]int64, matches []Match) error {
+	derived, err := Placements(matches)
+	if err != nil {
+		return err
+	}
+
+	// Check in a fixed order so that the same mismatch is always reported first.
+	ids := maps.Keys(derived)
+	slices.Sort(ids)
+
+	for _, id := range ids {
+		if placement, ok := reported[id]; ok && placement != derived[id] {
+			return &PlacementMismatch{EntrantID: id, Reported: placement, Derived: derived[id]}
+		}
+	}
+
+	return nil
+}
+
+// sorted returns a copy of the given matches, sorted by their play order.
+func sorted(matches []Match) []Match {
+	matches = slices.Clone(matches)
+	slices.SortStableFunc(matches, func(a, b Match) bool {
+		return a.Order < b.Order
+	})
+	return matches
+}
+
+func in(id int64, ids ...int64) bool {
+	return slices.Contains(ids, id)
+}
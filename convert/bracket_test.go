@@ -0,0 +1,94 @@
+package convert
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// Grand final and reset are between entrants 1 and 2. Entrant 2 comes from the losers bracket.
+var fourPlayers = []Match{
+	{Player1ID: 1, Player2ID: 4, WinnerID: 1, LoserID: 4, Round: 1, Order: 1},
+	{Player1ID: 2, Player2ID: 3, WinnerID: 2, LoserID: 3, Round: 1, Order: 2},
+	{Player1ID: 1, Player2ID: 2, WinnerID: 1, LoserID: 2, Round: 2, Order: 3},
+	{Player1ID: 3, Player2ID: 4, WinnerID: 3, LoserID: 4, Round: -1, Order: 4},
+	{Player1ID: 2, Player2ID: 3, WinnerID: 2, LoserID: 3, Round: -2, Order: 5},
+	{Player1ID: 1, Player2ID: 2, WinnerID: 2, LoserID: 1, Round: 3, Order: 6},
+	{Player1ID: 2, Player2ID: 1, WinnerID: 1, LoserID: 2, Round: 3, Order: 7},
+}
+
+func TestPlacements(t *testing.T) {
+	tests := []struct {
+		name    string
+		matches []Match
+		want    map[int64]int64
+		wantErr error
+	}{
+		{"four players", fourPlayers, map[int64]int64{1: 1, 2: 2, 3: 3, 4: 4}, nil},
+		{"out of order", []Match{fourPlayers[6], fourPlayers[0], fourPlayers[3], fourPlayers[5], fourPlayers[1], fourPlayers[4], fourPlayers[2]}, map[int64]int64{1: 1, 2: 2, 3: 3, 4: 4}, nil},
+		{"shared placement", []Match{
+			{Player1ID: 1, Player2ID: 3, WinnerID: 1, LoserID: 3, Round: -1, Order: 1},
+			{Player1ID: 2, Player2ID: 4, WinnerID: 2, LoserID: 4, Round: -1, Order: 2},
+			{Player1ID: 1, Player2ID: 2, WinnerID: 1, LoserID: 2, Round: 2, Order: 3},
+		}, map[int64]int64{1: 1, 2: 2, 3: 3, 4: 3}, nil},
+		{"third-place match after the final", []Match{
+			{Player1ID: 1, Player2ID: 4, WinnerID: 1, LoserID: 4, Round: 1, Order: 1},
+			{Player1ID: 2, Player2ID: 3, WinnerID: 2, LoserID: 3, Round: 1, Order: 2},
+			{Player1ID: 1, Player2ID: 2, WinnerID: 1, LoserID: 2, Round: 2, Order: 3},
+			{Player1ID: 3, Player2ID: 4, WinnerID: 4, LoserID: 3, Round: 0, Order: 4},
+		}, map[int64]int64{1: 1, 2: 2, 4: 3, 3: 4}, nil},
+		{"third-place match before the final", []Match{
+			{Player1ID: 1, Player2ID: 4, WinnerID: 1, LoserID: 4, Round: 1, Order: 1},
+			{Player1ID: 2, Player2ID: 3, WinnerID: 2, LoserID: 3, Round: 1, Order: 2},
+			{Player1ID: 3, Player2ID: 4, WinnerID: 3, LoserID: 4, Round: 0, Order: 3},
+			{Player1ID: 1, Player2ID: 2, WinnerID: 2, LoserID: 1, Round: 2, Order: 4},
+		}, map[int64]int64{2: 1, 1: 2, 3: 3, 4: 4}, nil},
+		{"no matches", nil, nil, ErrNoMatches},
+		{"incomplete", []Match{{Player1ID: 1, Player2ID: 2, Round: 1, Order: 1}}, nil, ErrIncompleteBracket},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Placements(tt.matches)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Placements() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Placements() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBracketReset(t *testing.T) {
+	tests := []struct {
+		name    string
+		matches []Match
+		want    bool
+	}{
+		{"reset with points", fourPlayers, true},
+		{"no reset", fourPlayers[:6], false},
+		{"reset with no points", append(fourPlayers[:6:6], Match{Player1ID: 2, Player2ID: 1, WinnerID: 2, LoserID: 1, Round: 3, Order: 7}), false},
+		{"single match", fourPlayers[:1], false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BracketReset(tt.matches); got != tt.want {
+				t.Errorf("BracketReset() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckPlacements(t *testing.T) {
+	err := CheckPlacements(map[int64]int64{1: 1, 2: 2, 3: 3, 4: 4}, fourPlayers)
+	if err != nil {
+		t.Errorf("CheckPlacements() error = %v, want nil", err)
+	}
+
+	var mismatch *PlacementMismatch
+	err = CheckPlacements(map[int64]int64{1: 1, 2: 2, 3: 4, 4: 3}, fourPlayers)
+	if !errors.As(err, &mismatch) || mismatch.EntrantID != 3 {
+		t.Errorf("CheckPlacements() error = %v, want mismatch for entrant 3", err)
+	}
+}
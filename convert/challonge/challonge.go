@@ -3,7 +3,9 @@
 package challonge
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	tournament "github.com/ejacobg/tourney-tracker"
 	"github.com/ejacobg/tourney-tracker/convert"
 	"golang.org/x/exp/maps"
@@ -20,6 +22,9 @@ type response struct {
 	Tournament struct {
 		Name         string        `json:"name"`
 		URL          string        `json:"full_challonge_url"`
+		Type         string        `json:"tournament_type"`
+		Teams        bool          `json:"teams"`
+		GameName     string        `json:"game_name"`
 		Participants []participant `json:"participants"`
 		Matches      []match       `json:"matches"`
 	}
@@ -27,11 +32,26 @@ type response struct {
 
 // tournament will create a Tournament object using the data from the response.
 func (r *response) tournament() tournament.Tournament {
+	bracketType := r.bracketType()
 	return tournament.Tournament{
 		Name:         r.Tournament.Name,
 		URL:          r.Tournament.URL,
-		BracketReset: applyResetPoints(r.Tournament.Matches),
+		BracketType:  bracketType,
+		BracketReset: bracketType == tournament.DoubleElimination && convert.BracketReset(r.matches()),
+		Teams:        r.Tournament.Teams,
 		Placements:   uniquePlacements(r.Tournament.Participants),
+		Game:         tournament.Game{Name: r.Tournament.GameName},
+	}
+}
+
+// bracketType returns the BracketType matching the tournament's type.
+// Challonge uses the same names as the BracketType values. Any other types (eg. free for all) are treated as double-elimination.
+func (r *response) bracketType() tournament.BracketType {
+	switch bracketType := tournament.BracketType(r.Tournament.Type); bracketType {
+	case tournament.SingleElimination, tournament.DoubleElimination, tournament.RoundRobin, tournament.Swiss:
+		return bracketType
+	default:
+		return tournament.DoubleElimination
 	}
 }
 
@@ -43,42 +63,78 @@ func (r *response) entrants() (entrants []tournament.Entrant) {
 	return
 }
 
-// applyResetPoints returns true if the second-place finisher made a bracket reset.
-// Bracket reset points should be applied if:
-//  1. The last two matches occurred between first and second place.
-//     If this is true, then the last two matches must be the grand final and the grand final reset.
-//     Otherwise, the last two matches would be the loser's final and the grand final.
-//  2. The winners of the last two matches are different.
-//     If the winner of the grand final and the winner of the grand final reset are different, then that means the second-place finisher made a reset, but did not win.
-//     Note that this condition is true if the last two matches are the loser's final and the grand final. However, this case is handled by (1).
-func applyResetPoints(matches []match) bool {
-	// The last match is either the grand final or the grand final reset.
-	// In either case, the first and second place finalists are both present in this match.
-	last := slices.IndexFunc(matches, func(m match) bool {
-		return m.Match.Order == len(matches)
-	})
-	first := matches[last].Match.WinnerID
-	second := matches[last].Match.LoserID
-
-	// The previous match is either the loser's final or the grand final.
-	prev := slices.IndexFunc(matches, func(m match) bool {
-		return m.Match.Order == len(matches)-1
-	})
+// matches converts the response matches into the format used by the bracket engine.
+func (r *response) matches() []convert.Match {
+	matches := make([]convert.Match, 0, len(r.Tournament.Matches))
+	for _, m := range r.Tournament.Matches {
+		matches = append(matches, convert.Match{
+			Player1ID: int64(m.Match.Player1ID),
+			Player2ID: int64(m.Match.Player2ID),
+			WinnerID:  int64(m.Match.WinnerID),
+			LoserID:   int64(m.Match.LoserID),
+			Round:     m.Match.Round,
+			Order:     m.Match.Order,
+		})
+	}
+	return matches
+}
 
-	// 1. The last two matches occurred between first and second place.
-	if !in(first, matches[prev].Match.Player1ID, matches[prev].Match.Player2ID) {
-		return false
+// reported returns the placements reported by Challonge, mapped by participant ID.
+// The second return value is false if any participant is missing a final rank.
+func (r *response) reported() (map[int64]int64, bool) {
+	placements := make(map[int64]int64)
+	complete := true
+	for _, p := range r.Tournament.Participants {
+		if p.Participant.FinalRank == 0 {
+			complete = false
+			continue
+		}
+		placements[int64(p.Participant.ID)] = p.Participant.FinalRank
 	}
-	if !in(second, matches[prev].Match.Player1ID, matches[prev].Match.Player2ID) {
-		return false
+	return placements, complete
+}
+
+// checkPlacements returns a *convert.PlacementMismatch if any rank reported by Challonge disagrees with the matches.
+// Only double-elimination brackets are checked. Ranks are trusted as-is if the matches cannot be placed (eg. some have no winner).
+func (r *response) checkPlacements() error {
+	if r.bracketType() != tournament.DoubleElimination {
+		return nil
 	}
 
-	// 2. The winners of the last two matches are different.
-	return matches[last].Match.WinnerID != matches[prev].Match.WinnerID
+	reported, _ := r.reported()
+	err := convert.CheckPlacements(reported, r.matches())
+	if errors.Is(err, convert.ErrNoMatches) || errors.Is(err, convert.ErrIncompleteBracket) {
+		return nil
+	}
+	return err
 }
 
-func in(id int, ids ...int) bool {
-	return slices.Contains(ids, id)
+// fillPlacements derives the final rank of each participant from the matches if Challonge did not report them.
+// This happens for brackets that were finalized without ranks.
+// Placements can only be derived for elimination brackets. Round-robin and Swiss tournaments rank their participants using tiebreakers that are not available here.
+func (r *response) fillPlacements() error {
+	if _, complete := r.reported(); complete {
+		return nil
+	}
+
+	if !r.bracketType().Elimination() {
+		return fmt.Errorf("cannot derive placements for a %s tournament", r.bracketType())
+	}
+
+	placements, err := convert.Placements(r.matches())
+	if err != nil {
+		return err
+	}
+
+	for i, p := range r.Tournament.Participants {
+		placement, ok := placements[int64(p.Participant.ID)]
+		if !ok {
+			return fmt.Errorf("participant %q did not play any matches", p.Participant.Name)
+		}
+		r.Tournament.Participants[i].Participant.FinalRank = placement
+	}
+
+	return nil
 }
 
 // uniquePlacements returns the unique placements across all the given entrants, in reverse-sorted order.
@@ -110,6 +166,7 @@ type match struct {
 		Player2ID int `json:"player2_id"`
 		WinnerID  int `json:"winner_id"`
 		LoserID   int `json:"loser_id"`
+		Round     int `json:"round"`
 		Order     int `json:"suggested_play_order"`
 	}
 }
@@ -124,7 +181,7 @@ type participant struct {
 
 // FromURL takes a URL to a Challonge tournament, calls the API with the provided credentials, and returns the parsed tournament and its entrants.
 // A tournament URL takes the form: https://challonge.com/<tournament-id> (eg. https://challonge.com/8ozc6ffz)
-func FromURL(URL *url.URL, username, password string) (tourney tournament.Tournament, entrants []tournament.Entrant, err error) {
+func FromURL(ctx context.Context, URL *url.URL, username, password string) (tourney tournament.Tournament, entrants []tournament.Entrant, err error) {
 	// Only accept challonge.com URLs.
 	if URL.Host != "challonge.com" {
 		return tourney, entrants, convert.ErrUnrecognizedURL
@@ -140,7 +197,17 @@ func FromURL(URL *url.URL, username, password string) (tourney tournament.Tourna
 		return
 	}
 
-	res, err := convert.Get[response](req)
+	res, err := convert.Get[response](ctx, req)
+	if err != nil {
+		return
+	}
+
+	err = res.checkPlacements()
+	if err != nil {
+		return
+	}
+
+	err = res.fillPlacements()
 	if err != nil {
 		return
 	}
@@ -1,6 +1,9 @@
 package challonge
 
 import (
+	"context"
+	"errors"
+	tournament "github.com/ejacobg/tourney-tracker"
 	"github.com/ejacobg/tourney-tracker/convert"
 	"golang.org/x/exp/slices"
 	"net/http"
@@ -28,13 +31,14 @@ func Test_response(t *testing.T) {
 		name           string
 		tournamentName string
 		tournamentURL  string
+		bracketType    tournament.BracketType
 		bracketReset   bool
 		placements     []int64
 		numEntrants    int
 	}{
-		{"no-reset", "(SSC C TIER) Gator Grind #9", "https://challonge.com/kpqlgghc", false, []int64{17, 13, 9, 7, 5, 4, 3, 2, 1}, 20},
-		{"reset-no-points", "(SSC C Tier) Gator Grind #12", "https://challonge.com/8ozc6ffz", false, []int64{17, 13, 9, 7, 5, 4, 3, 2, 1}, 20},
-		{"reset-with-points", "(SSC C Tier) Gator Grind #7", "https://challonge.com/t4kq4f5b", true, []int64{17, 13, 9, 7, 5, 4, 3, 2, 1}, 24},
+		{"no-reset", "(SSC C TIER) Gator Grind #9", "https://challonge.com/kpqlgghc", tournament.DoubleElimination, false, []int64{17, 13, 9, 7, 5, 4, 3, 2, 1}, 20},
+		{"reset-no-points", "(SSC C Tier) Gator Grind #12", "https://challonge.com/8ozc6ffz", tournament.DoubleElimination, false, []int64{17, 13, 9, 7, 5, 4, 3, 2, 1}, 20},
+		{"reset-with-points", "(SSC C Tier) Gator Grind #7", "https://challonge.com/t4kq4f5b", tournament.DoubleElimination, true, []int64{17, 13, 9, 7, 5, 4, 3, 2, 1}, 24},
 	}
 
 	// Attach our routes to the DefaultServeMux.
@@ -50,7 +54,7 @@ func Test_response(t *testing.T) {
 				t.Error("failed to create request:", err)
 				return
 			}
-			res, err := convert.Get[response](req)
+			res, err := convert.Get[response](context.Background(), req)
 			if err != nil {
 				t.Error("failed to get tournament:", err)
 				return
@@ -62,8 +66,14 @@ func Test_response(t *testing.T) {
 			if tourney.URL != tt.tournamentURL {
 				t.Errorf("response tournamentURL = %v, want %v", tourney.URL, tt.tournamentURL)
 			}
+			if tourney.Game.Name != "Super Smash Bros. Ultimate" {
+				t.Errorf("response Game.Name = %v, want %v", tourney.Game.Name, "Super Smash Bros. Ultimate")
+			}
+			if tourney.BracketType != tt.bracketType {
+				t.Errorf("bracketType() BracketType = %v, want %v", tourney.BracketType, tt.bracketType)
+			}
 			if tourney.BracketReset != tt.bracketReset {
-				t.Errorf("applyResetPoints() BracketReset = %v, want %v", tourney.BracketReset, tt.bracketReset)
+				t.Errorf("BracketReset() BracketReset = %v, want %v", tourney.BracketReset, tt.bracketReset)
 			}
 			if !slices.Equal(tourney.Placements, tt.placements) {
 				t.Errorf("uniquePlacements() Placements = %v, want %v", tourney.Placements, tt.placements)
@@ -72,6 +82,31 @@ func Test_response(t *testing.T) {
 			if len(entrants) != tt.numEntrants {
 				t.Errorf("entrants() length = %v, want %v", len(entrants), tt.numEntrants)
 			}
+
+			// The reported standings should agree with the bracket engine.
+			reported, _ := res.reported()
+			if err = res.checkPlacements(); err != nil {
+				t.Errorf("checkPlacements() error = %v", err)
+			}
+
+			// Changing a reported rank should cause it to be rejected.
+			changed := res.Tournament.Participants[0].Participant
+			res.Tournament.Participants[0].Participant.FinalRank = changed.FinalRank + 1
+			var mismatch *convert.PlacementMismatch
+			if err = res.checkPlacements(); !errors.As(err, &mismatch) || mismatch.EntrantID != int64(changed.ID) {
+				t.Errorf("checkPlacements() with a changed rank error = %v, want a mismatch for participant %d", err, changed.ID)
+			}
+
+			// Removing the reported standings should cause them to be derived from the matches.
+			for i := range res.Tournament.Participants {
+				res.Tournament.Participants[i].Participant.FinalRank = 0
+			}
+			if err = res.fillPlacements(); err != nil {
+				t.Errorf("fillPlacements() error = %v", err)
+			}
+			if derived, complete := res.reported(); !complete || !reflect.DeepEqual(derived, reported) {
+				t.Errorf("fillPlacements() placements = %v, want %v", derived, reported)
+			}
 		})
 	}
 }
@@ -1,6 +1,7 @@
 package convert
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -16,8 +17,9 @@ var Client = &http.Client{
 
 // Get will use the Client to send the given request. It will then attempt to fill the Response type using the data in the response body.
 // Alternatively, the Response can be an interface with the Tournament() and Entrants() methods.
-func Get[Response any](req *http.Request) (*Response, error) {
-	res, err := Client.Do(req)
+// The request is cancelled if the context is done before the response has been read.
+func Get[Response any](ctx context.Context, req *http.Request) (*Response, error) {
+	res, err := Client.Do(req.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
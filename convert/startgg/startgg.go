@@ -4,8 +4,10 @@ package startgg
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	tournament "github.com/ejacobg/tourney-tracker"
 	"github.com/ejacobg/tourney-tracker/convert"
 	"golang.org/x/exp/maps"
@@ -19,6 +21,7 @@ import (
 // Test your queries: https://developer.start.gg/explorer/
 
 // This query only supports up to 500 entrants. I currently do not have plans to support more than 500 entrants.
+// The most recent sets of each phase are used to check for a bracket reset in the final phase.
 const query = `
 query TournamentEventQuery($tournament: String, $event: String) {
     tournament(slug: $tournament) {
@@ -27,18 +30,77 @@ query TournamentEventQuery($tournament: String, $event: String) {
     event(slug: $event) {
         name
         slug
+        videogame {
+            name
+        }
+        phases {
+            id
+            name
+            bracketType
+            phaseOrder
+            sets(page: 1, perPage: 3, sortType: RECENT) {
+                nodes {
+                    fullRoundText
+                    winnerId
+                }
+            }
+        }
+        phaseGroups {
+            id
+            displayIdentifier
+            phase {
+                id
+            }
+        }
         entrants(query: { page: 1, perPage: 500 }) {
             nodes {
+                id
                 name
+                participants {
+                    gamerTag
+                }
                 standing {
                     placement
                 }
             }
         }
-        sets(page: 1, perPage: 3, sortType: RECENT) {
+    }
+}`
+
+// standingsPerPage is the number of standings requested in each page of the standingsQuery.
+const standingsPerPage = 128
+
+// standingsQuery returns a page of the standings within a single pool.
+const standingsQuery = `
+query PhaseGroupStandingsQuery($phaseGroup: ID, $page: Int, $perPage: Int) {
+    phaseGroup(id: $phaseGroup) {
+        standings(query: { page: $page, perPage: $perPage }) {
+            nodes {
+                placement
+                entrant {
+                    id
+                }
+            }
+        }
+    }
+}`
+
+// setsQuery returns a page of the sets played in a single phase.
+const setsQuery = `
+query PhaseSetsQuery($phase: ID, $page: Int) {
+    phase(id: $phase) {
+        sets(page: $page, perPage: 50) {
+            pageInfo {
+                totalPages
+            }
             nodes {
-                fullRoundText
+                round
                 winnerId
+                slots {
+                    entrant {
+                        id
+                    }
+                }
             }
         }
     }
@@ -46,8 +108,8 @@ query TournamentEventQuery($tournament: String, $event: String) {
 
 // request holds the data to be sent with the API request.
 type request struct {
-	Query     string            `json:"query"`
-	Variables map[string]string `json:"variables"`
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables"`
 }
 
 // response will hold the data returned from the above query.
@@ -57,25 +119,82 @@ type response struct {
 			Name string
 		}
 		Event struct {
-			Name     string
-			Slug     string
-			Entrants struct {
+			Name      string
+			Slug      string
+			Videogame struct {
+				Name string
+			}
+			Phases      []phase
+			PhaseGroups []phaseGroup
+			Entrants    struct {
 				Nodes []entrant
 			}
+		}
+	}
+}
+
+// standingsResponse will hold the data returned from the standingsQuery.
+type standingsResponse struct {
+	Data struct {
+		PhaseGroup struct {
+			Standings struct {
+				Nodes []standing
+			}
+		}
+	}
+}
+
+// setsResponse will hold the data returned from the setsQuery.
+type setsResponse struct {
+	Data struct {
+		Phase struct {
 			Sets struct {
-				Nodes []set
+				PageInfo struct {
+					TotalPages int
+				}
+				Nodes []struct {
+					Round    int
+					WinnerID int64
+					Slots    []struct {
+						Entrant *struct {
+							ID int64
+						}
+					}
+				}
 			}
 		}
 	}
 }
 
 type entrant struct {
-	Name     string
-	Standing struct {
+	ID           int64
+	Name         string
+	Participants []struct {
+		GamerTag string
+	}
+	Standing *struct {
 		Placement int64
 	}
 }
 
+type phase struct {
+	ID          int64
+	Name        string
+	BracketType string
+	PhaseOrder  int
+	Sets        struct {
+		Nodes []set
+	}
+}
+
+type phaseGroup struct {
+	ID                int64
+	DisplayIdentifier string
+	Phase             struct {
+		ID int64
+	}
+}
+
 type set struct {
 	FullRoundText string
 	WinnerID      int
@@ -83,22 +202,120 @@ type set struct {
 
 // tournament will create a tourney_tracker.Tournament object using the data from the response.
 func (r *response) tournament() tournament.Tournament {
+	var (
+		bracketType  = r.bracketType()
+		bracketReset bool
+		phases       []tournament.Phase
+	)
+
+	if final, ok := r.finalPhase(); ok {
+		bracketReset = bracketType == tournament.DoubleElimination && applyResetPoints(final.Sets.Nodes)
+	}
+
+	// Single-phase events will not keep track of their phase.
+	if len(r.Data.Event.Phases) > 1 {
+		for _, p := range r.Data.Event.Phases {
+			phases = append(phases, tournament.Phase{Name: p.Name, BracketType: convertBracketType(p.BracketType), Order: p.PhaseOrder})
+		}
+		slices.SortFunc(phases, func(a, b tournament.Phase) bool {
+			return a.Order < b.Order
+		})
+	}
+
 	return tournament.Tournament{
 		Name:         r.Data.Tournament.Name + " - " + r.Data.Event.Name,
 		URL:          "https://www.start.gg/" + r.Data.Event.Slug,
-		BracketReset: applyResetPoints(r.Data.Event.Sets.Nodes),
+		BracketType:  bracketType,
+		BracketReset: bracketReset,
+		Teams:        r.teams(),
 		Placements:   uniquePlacements(r.Data.Event.Entrants.Nodes),
+		Game:         tournament.Game{Name: r.Data.Event.Videogame.Name},
+		Phases:       phases,
 	}
 }
 
+// teams returns true if any entrant is made up of more than one participant.
+func (r *response) teams() bool {
+	for _, e := range r.Data.Event.Entrants.Nodes {
+		if len(e.Participants) > 1 {
+			return true
+		}
+	}
+	return false
+}
+
 // entrants will return a []Entrant using the data from the response.
-func (r *response) entrants() (entrants []tournament.Entrant) {
+// The given results should map each start.gg entrant ID to their results in each phase.
+// The participants of each entrant are only kept for team events.
+func (r *response) entrants(results map[int64][]tournament.Result) (entrants []tournament.Entrant) {
+	teams := r.teams()
 	for _, e := range r.Data.Event.Entrants.Nodes {
-		entrants = append(entrants, tournament.Entrant{Name: e.Name, Placement: e.Standing.Placement})
+		entrant := tournament.Entrant{Name: e.Name, Placement: e.placement(), Results: results[e.ID]}
+		if teams {
+			for _, p := range e.Participants {
+				entrant.Participants = append(entrant.Participants, p.GamerTag)
+			}
+		}
+		entrants = append(entrants, entrant)
+	}
+	return
+}
+
+// finalPhase returns the phase with the highest phase order. The final standings of the event are decided by this phase.
+// The second return value is false if the event has no phases.
+func (r *response) finalPhase() (final phase, ok bool) {
+	for _, p := range r.Data.Event.Phases {
+		if !ok || p.PhaseOrder > final.PhaseOrder {
+			final, ok = p, true
+		}
 	}
 	return
 }
 
+// bracketType returns the BracketType of the event's final phase, since that phase decides the final standings.
+// Events without phases are treated as double-elimination.
+func (r *response) bracketType() tournament.BracketType {
+	final, ok := r.finalPhase()
+	if !ok {
+		return tournament.DoubleElimination
+	}
+
+	return convertBracketType(final.BracketType)
+}
+
+// convertBracketType converts a start.gg bracket type into a BracketType.
+// Types with no equivalent (eg. CUSTOM_SCHEDULE) are treated as double-elimination.
+func convertBracketType(bracketType string) tournament.BracketType {
+	switch bracketType {
+	case "SINGLE_ELIMINATION":
+		return tournament.SingleElimination
+	case "ROUND_ROBIN":
+		return tournament.RoundRobin
+	case "SWISS":
+		return tournament.Swiss
+	default:
+		return tournament.DoubleElimination
+	}
+}
+
+// complete returns true if every entrant has a final placement.
+func (r *response) complete() bool {
+	for _, e := range r.Data.Event.Entrants.Nodes {
+		if e.placement() == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// placement returns the entrant's final placement, or 0 if start.gg did not report one.
+func (e entrant) placement() int64 {
+	if e.Standing == nil {
+		return 0
+	}
+	return e.Standing.Placement
+}
+
 // applyResetPoints returns true if the second-place finisher made a bracket reset.
 // Bracket reset points should be applied if:
 //  1. There exists a "Grand Final" and "Grand Final Reset" round.
@@ -126,8 +343,8 @@ func uniquePlacements(entrants []entrant) []int64 {
 
 	// If we come across a placement we haven't seen before, add it to the map.
 	for _, e := range entrants {
-		if !placements[e.Standing.Placement] {
-			placements[e.Standing.Placement] = true
+		if !placements[e.placement()] {
+			placements[e.placement()] = true
 		}
 	}
 
@@ -144,7 +361,8 @@ func uniquePlacements(entrants []entrant) []int64 {
 
 // FromURL returns takes a URL to a start.gg event, calls the API with the provided API key, and returns the parsed tournament and its entrants.
 // An event URL takes this form: https://start.gg/tournament/<tournament-slug>/event/<event-slug> (eg. https://start.gg/tournament/shinto-series-smash-1/event/singles-1v1)
-func FromURL(URL *url.URL, key string) (tourney tournament.Tournament, entrants []tournament.Entrant, err error) {
+// Events with multiple phases (eg. pools into a top-8 bracket) will also have the results of each phase fetched.
+func FromURL(ctx context.Context, URL *url.URL, key string) (tourney tournament.Tournament, entrants []tournament.Entrant, err error) {
 	// Only accept start.gg (formerly smash.gg) URLs.
 	if !(URL.Host == "www.start.gg" || URL.Host == "www.smash.gg") {
 		return tourney, entrants, convert.ErrUnrecognizedURL
@@ -160,16 +378,224 @@ func FromURL(URL *url.URL, key string) (tourney tournament.Tournament, entrants
 		return
 	}
 
-	res, err := convert.Get[response](req)
+	res, err := convert.Get[response](ctx, req)
 	if err != nil {
 		return
 	}
 
+	var results map[int64][]tournament.Result
+	if len(res.Data.Event.Phases) > 1 {
+		results, err = getResults(ctx, res, key)
+		if err != nil {
+			return
+		}
+	}
+
+	if !res.complete() {
+		err = fillPlacements(ctx, res, key)
+		if err != nil {
+			return
+		}
+	}
+
 	tourney = res.tournament()
-	entrants = res.entrants()
+	entrants = res.entrants(results)
 	return
 }
 
+// getResults fetches the standings of every pool in the event, and returns the results of each entrant.
+func getResults(ctx context.Context, res *response, key string) (map[int64][]tournament.Result, error) {
+	// Map each phase ID to its order.
+	orders := make(map[int64]int)
+	for _, p := range res.Data.Event.Phases {
+		orders[p.ID] = p.PhaseOrder
+	}
+
+	results := make(map[int64][]tournament.Result)
+	for _, group := range res.Data.Event.PhaseGroups {
+		standings, err := getStandings(ctx, group.ID, key)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, s := range standings {
+			results[s.Entrant.ID] = append(results[s.Entrant.ID], tournament.Result{
+				Phase:     orders[group.Phase.ID],
+				Group:     group.DisplayIdentifier,
+				Placement: s.Placement,
+			})
+		}
+	}
+
+	for _, r := range results {
+		slices.SortFunc(r, func(a, b tournament.Result) bool {
+			return a.Phase < b.Phase
+		})
+	}
+
+	return results, nil
+}
+
+// standing is the placement of a single entrant within a pool.
+type standing struct {
+	Placement int64
+	Entrant   struct {
+		ID int64
+	}
+}
+
+// getStandings fetches every page of the standings of the given pool.
+func getStandings(ctx context.Context, phaseGroupID int64, key string) (standings []standing, err error) {
+	// The standings do not report how many pages there are, so pages are requested until one comes back short.
+	for page := 1; ; page++ {
+		req, err := newQueryRequest(standingsQuery, map[string]any{"phaseGroup": phaseGroupID, "page": page, "perPage": standingsPerPage}, key)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := convert.Get[standingsResponse](ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		nodes := res.Data.PhaseGroup.Standings.Nodes
+		standings = append(standings, nodes...)
+		if len(nodes) < standingsPerPage {
+			return standings, nil
+		}
+	}
+}
+
+// fillPlacements derives the final placements of the event from the sets played in its final phase.
+// This is only used if start.gg did not report a placement for every entrant, since the sets are not otherwise needed.
+// Entrants who were knocked out in an earlier phase must still have a reported placement.
+// In double-elimination brackets, the placements that were reported are checked against the sets, returning a *convert.PlacementMismatch if they disagree.
+func fillPlacements(ctx context.Context, res *response, key string) error {
+	final, ok := res.finalPhase()
+	if !ok || !convertBracketType(final.BracketType).Elimination() {
+		return errors.New("cannot derive placements without a final elimination phase")
+	}
+
+	matches, err := getMatches(ctx, final.ID, key)
+	if err != nil {
+		return err
+	}
+
+	placements, err := convert.Placements(matches)
+	if err != nil {
+		return err
+	}
+
+	if convertBracketType(final.BracketType) == tournament.DoubleElimination {
+		reported := make(map[int64]int64)
+		for _, e := range res.Data.Event.Entrants.Nodes {
+			if e.placement() != 0 {
+				reported[e.ID] = e.placement()
+			}
+		}
+		if err = convert.CheckPlacements(reported, matches); err != nil {
+			return err
+		}
+	}
+
+	for i, e := range res.Data.Event.Entrants.Nodes {
+		if e.placement() != 0 {
+			continue
+		}
+
+		placement, ok := placements[e.ID]
+		if !ok {
+			return fmt.Errorf("entrant %q has no placement", e.Name)
+		}
+
+		res.Data.Event.Entrants.Nodes[i].Standing = &struct{ Placement int64 }{placement}
+	}
+
+	return nil
+}
+
+// getMatches fetches every set in the given phase, and converts them into the format used by the bracket engine.
+// Sets are ordered by their round. See orderMatches. Sets that were not played (eg. byes) are skipped.
+func getMatches(ctx context.Context, phaseID int64, key string) ([]convert.Match, error) {
+	var matches []convert.Match
+	for page, pages := 1, 1; page <= pages; page++ {
+		req, err := newQueryRequest(setsQuery, map[string]any{"phase": phaseID, "page": page}, key)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := convert.Get[setsResponse](ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		pages = res.Data.Phase.Sets.PageInfo.TotalPages
+		for _, s := range res.Data.Phase.Sets.Nodes {
+			if len(s.Slots) != 2 || s.Slots[0].Entrant == nil || s.Slots[1].Entrant == nil {
+				continue
+			}
+
+			m := convert.Match{
+				Player1ID: s.Slots[0].Entrant.ID,
+				Player2ID: s.Slots[1].Entrant.ID,
+				WinnerID:  s.WinnerID,
+				Round:     s.Round,
+			}
+			switch m.WinnerID {
+			case m.Player1ID:
+				m.LoserID = m.Player2ID
+			case m.Player2ID:
+				m.LoserID = m.Player1ID
+			}
+
+			matches = append(matches, m)
+		}
+	}
+
+	orderMatches(matches)
+	return matches, nil
+}
+
+// grandFinalStage orders the grand final rounds after every other round. See orderMatches.
+const grandFinalStage = 1 << 20
+
+// orderMatches sets the Order of each match from its round. Completion times cannot be used, since sets decided by a DQ do not have one.
+// Winners round k is ordered before losers round -k, which is the earliest losers round that its losers can drop into.
+// The grand final rounds come after the last winners round whose losers drop into the losers bracket, and are ordered last.
+func orderMatches(matches []convert.Match) {
+	losers := make(map[int64]bool)
+	for _, m := range matches {
+		if m.Round < 0 {
+			losers[m.Player1ID], losers[m.Player2ID] = true, true
+		}
+	}
+
+	lastDrop := 0
+	for _, m := range matches {
+		if m.Round > lastDrop && losers[m.LoserID] {
+			lastDrop = m.Round
+		}
+	}
+
+	stage := func(m convert.Match) int {
+		switch {
+		case m.Round < 0:
+			return -2*m.Round + 1
+		case len(losers) > 0 && m.Round > lastDrop:
+			return grandFinalStage + m.Round
+		default:
+			return 2 * m.Round
+		}
+	}
+
+	slices.SortStableFunc(matches, func(a, b convert.Match) bool {
+		return stage(a) < stage(b)
+	})
+	for i := range matches {
+		matches[i].Order = i + 1
+	}
+}
+
 // parseSlugs will extract the <tournament-slug> and <event-slug> values from the given start.gg event URL.
 func parseSlugs(URL *url.URL) (tournamentSlug, eventSlug string, err error) {
 	path := strings.Split(URL.Path, "/")
@@ -178,19 +604,25 @@ func parseSlugs(URL *url.URL) (tournamentSlug, eventSlug string, err error) {
 	}
 
 	// An ideal path would look like this: ["", "tournament", <tournament-slug>, "event", <event-slug>]
-	tournamentSlug, eventSlug = path[2], strings.Join(path[1:], "/")
+	// The API expects the full event slug, which takes the form: tournament/<tournament-slug>/event/<event-slug>
+	tournamentSlug, eventSlug = path[2], strings.Join(path[1:5], "/")
 	return
 }
 
 // newRequest returns a *http.Request populated with the data needed by the start.gg API.
 // See https://developer.start.gg/docs/sending-requests for more.
 func newRequest(tournamentSlug, eventSlug, key string) (*http.Request, error) {
+	return newQueryRequest(query, map[string]any{
+		"tournament": tournamentSlug,
+		"event":      eventSlug,
+	}, key)
+}
+
+// newQueryRequest returns a *http.Request for the given query and variables.
+func newQueryRequest(query string, variables map[string]any, key string) (*http.Request, error) {
 	data := request{
-		Query: query,
-		Variables: map[string]string{
-			"tournament": tournamentSlug,
-			"event":      eventSlug,
-		},
+		Query:     query,
+		Variables: variables,
 	}
 
 	body, err := json.Marshal(data)
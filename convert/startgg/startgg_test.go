@@ -1,6 +1,9 @@
 package startgg
 
 import (
+	"context"
+	"encoding/json"
+	tournament "github.com/ejacobg/tourney-tracker"
 	"github.com/ejacobg/tourney-tracker/convert"
 	"golang.org/x/exp/slices"
 	"net/http"
@@ -28,13 +31,14 @@ func Test_response(t *testing.T) {
 		name           string
 		tournamentName string
 		tournamentURL  string
+		bracketType    tournament.BracketType
 		bracketReset   bool
 		placements     []int64
 		numEntrants    int
 	}{
-		{"no-reset", "Silver State Smash x Pirate Hackers Black Lives Matter Charity Tournament - Singles 1v1", "https://start.gg/tournament/silver-state-smash-x-pirate-hackers-black-lives-matter-charity/event/singles-1v1", false, []int64{33, 25, 17, 13, 9, 7, 5, 4, 3, 2, 1}, 42},
-		{"reset-no-points", "Wrangler Rumble #1 - Ultimate Singles", "https://start.gg/tournament/wrangler-rumble-1/event/ultimate-singles", false, []int64{13, 9, 7, 5, 4, 3, 2, 1}, 13},
-		{"reset-with-points", "Shinto Series: Smash #1 - Singles 1v1", "https://start.gg/tournament/shinto-series-smash-1/event/singles-1v1", true, []int64{97, 65, 49, 33, 25, 17, 13, 9, 7, 5, 4, 3, 2, 1}, 128},
+		{"no-reset", "Silver State Smash x Pirate Hackers Black Lives Matter Charity Tournament - Singles 1v1", "https://www.start.gg/tournament/silver-state-smash-x-pirate-hackers-black-lives-matter-charity/event/singles-1v1", tournament.DoubleElimination, false, []int64{33, 25, 17, 13, 9, 7, 5, 4, 3, 2, 1}, 42},
+		{"reset-no-points", "Wrangler Rumble #1 - Ultimate Singles", "https://www.start.gg/tournament/wrangler-rumble-1/event/ultimate-singles", tournament.DoubleElimination, false, []int64{13, 9, 7, 5, 4, 3, 2, 1}, 13},
+		{"reset-with-points", "Shinto Series: Smash #1 - Singles 1v1", "https://www.start.gg/tournament/shinto-series-smash-1/event/singles-1v1", tournament.DoubleElimination, true, []int64{97, 65, 49, 33, 25, 17, 13, 9, 7, 5, 4, 3, 2, 1}, 128},
 	}
 
 	// Attach our routes to the DefaultServeMux.
@@ -50,7 +54,7 @@ func Test_response(t *testing.T) {
 				t.Error("failed to create request:", err)
 				return
 			}
-			res, err := convert.Get[response](req)
+			res, err := convert.Get[response](context.Background(), req)
 			if err != nil {
 				t.Error("failed to get tournament:", err)
 				return
@@ -62,13 +66,19 @@ func Test_response(t *testing.T) {
 			if tourney.URL != tt.tournamentURL {
 				t.Errorf("response tournamentURL = %v, want %v", tourney.URL, tt.tournamentURL)
 			}
+			if tourney.Game.Name != "Super Smash Bros. Ultimate" {
+				t.Errorf("response Game.Name = %v, want %v", tourney.Game.Name, "Super Smash Bros. Ultimate")
+			}
+			if tourney.BracketType != tt.bracketType {
+				t.Errorf("bracketType() BracketType = %v, want %v", tourney.BracketType, tt.bracketType)
+			}
 			if tourney.BracketReset != tt.bracketReset {
 				t.Errorf("applyResetPoints() BracketReset = %v, want %v", tourney.BracketReset, tt.bracketReset)
 			}
 			if !slices.Equal(tourney.Placements, tt.placements) {
 				t.Errorf("uniquePlacements() Placements = %v, want %v", tourney.Placements, tt.placements)
 			}
-			entrants := res.entrants()
+			entrants := res.entrants(nil)
 			if len(entrants) != tt.numEntrants {
 				t.Errorf("entrants() length = %v, want %v", len(entrants), tt.numEntrants)
 			}
@@ -87,9 +97,9 @@ func Test_parseSlugs(t *testing.T) {
 		wantEventSlug      string
 		wantErr            bool
 	}{
-		{"correct path", args{&url.URL{Path: "/tournament/shinto-series-smash-1/event/singles-1v1"}}, "shinto-series-smash-1", "singles-1v1", false},
+		{"correct path", args{&url.URL{Path: "/tournament/shinto-series-smash-1/event/singles-1v1"}}, "shinto-series-smash-1", "tournament/shinto-series-smash-1/event/singles-1v1", false},
 		{"path too short", args{&url.URL{Path: "/tournament/shinto-series-smash-1"}}, "", "", true},
-		{"path too long", args{&url.URL{Path: "/tournament/shinto-series-smash-1/event/singles-1v1/standings"}}, "shinto-series-smash-1", "singles-1v1", false},
+		{"path too long", args{&url.URL{Path: "/tournament/shinto-series-smash-1/event/singles-1v1/standings"}}, "shinto-series-smash-1", "tournament/shinto-series-smash-1/event/singles-1v1", false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -136,3 +146,139 @@ func Test_newRequest(t *testing.T) {
 		})
 	}
 }
+
+func Test_bracketType(t *testing.T) {
+	tests := []struct {
+		name   string
+		phases []phase
+		want   tournament.BracketType
+	}{
+		{"no phases", nil, tournament.DoubleElimination},
+		{"single phase", []phase{{BracketType: "SINGLE_ELIMINATION", PhaseOrder: 1}}, tournament.SingleElimination},
+		{"pools into bracket", []phase{{BracketType: "DOUBLE_ELIMINATION", PhaseOrder: 2}, {BracketType: "ROUND_ROBIN", PhaseOrder: 1}}, tournament.DoubleElimination},
+		{"swiss", []phase{{BracketType: "SWISS", PhaseOrder: 1}}, tournament.Swiss},
+		{"unsupported", []phase{{BracketType: "CUSTOM_SCHEDULE", PhaseOrder: 1}}, tournament.DoubleElimination},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var res response
+			res.Data.Event.Phases = tt.phases
+			if got := res.bracketType(); got != tt.want {
+				t.Errorf("bracketType() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_response_phases(t *testing.T) {
+	var res response
+	res.Data.Event.Phases = []phase{
+		{ID: 20, Name: "Top 8", BracketType: "DOUBLE_ELIMINATION", PhaseOrder: 2},
+		{ID: 10, Name: "Pools", BracketType: "ROUND_ROBIN", PhaseOrder: 1},
+	}
+	res.Data.Event.Phases[0].Sets.Nodes = []set{{"Grand Final Reset", 1}, {"Grand Final", 2}, {"Losers Final", 2}}
+	res.Data.Event.Phases[1].Sets.Nodes = []set{{"Round 3", 3}}
+
+	tourney := res.tournament()
+	wantPhases := []tournament.Phase{
+		{Name: "Pools", BracketType: tournament.RoundRobin, Order: 1},
+		{Name: "Top 8", BracketType: tournament.DoubleElimination, Order: 2},
+	}
+	if !reflect.DeepEqual(tourney.Phases, wantPhases) {
+		t.Errorf("tournament() Phases = %v, want %v", tourney.Phases, wantPhases)
+	}
+	if !tourney.BracketReset {
+		t.Errorf("tournament() BracketReset = %v, want %v", tourney.BracketReset, true)
+	}
+
+	res.Data.Event.Entrants.Nodes = []entrant{{ID: 1, Name: "one"}, {ID: 2, Name: "two"}}
+	results := map[int64][]tournament.Result{1: {{Phase: 1, Group: "A1", Placement: 1}, {Phase: 2, Group: "1", Placement: 1}}}
+	entrants := res.entrants(results)
+	if !reflect.DeepEqual(entrants[0].Results, results[1]) || entrants[1].Results != nil {
+		t.Errorf("entrants() Results = %v, %v, want %v, nil", entrants[0].Results, entrants[1].Results, results[1])
+	}
+}
+
+func Test_response_teams(t *testing.T) {
+	var res response
+	res.Data.Event.Entrants.Nodes = []entrant{{ID: 1, Name: "Team A"}, {ID: 2, Name: "Team B"}}
+	res.Data.Event.Entrants.Nodes[0].Participants = []struct{ GamerTag string }{{"one"}, {"two"}}
+	res.Data.Event.Entrants.Nodes[1].Participants = []struct{ GamerTag string }{{"three"}}
+
+	if !res.tournament().Teams {
+		t.Errorf("tournament() Teams = %v, want %v", false, true)
+	}
+
+	entrants := res.entrants(nil)
+	if !slices.Equal(entrants[0].Participants, []string{"one", "two"}) || !slices.Equal(entrants[1].Participants, []string{"three"}) {
+		t.Errorf("entrants() Participants = %v, %v, want [one two], [three]", entrants[0].Participants, entrants[1].Participants)
+	}
+}
+
+// roundTripFunc lets a function stand in for the transport of the convert.Client.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func Test_getStandings(t *testing.T) {
+	// The pool has one full page of standings, followed by a short page.
+	sizes := []int{standingsPerPage, 3}
+
+	var requests int
+	client := convert.Client
+	convert.Client = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		var body request
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			t.Fatal("failed to decode request:", err)
+		}
+		requests++
+
+		var res standingsResponse
+		if page := int(body.Variables["page"].(float64)); page <= len(sizes) {
+			res.Data.PhaseGroup.Standings.Nodes = make([]standing, sizes[page-1])
+		}
+
+		rec := httptest.NewRecorder()
+		json.NewEncoder(rec).Encode(res)
+		return rec.Result(), nil
+	})}
+	defer func() { convert.Client = client }()
+
+	standings, err := getStandings(context.Background(), 1, "key")
+	if err != nil {
+		t.Fatal("getStandings() error:", err)
+	}
+	if len(standings) != standingsPerPage+3 || requests != 2 {
+		t.Errorf("getStandings() = %d standings in %d requests, want %d in 2", len(standings), requests, standingsPerPage+3)
+	}
+}
+
+func Test_orderMatches(t *testing.T) {
+	// A four-player double-elimination bracket with a reset, in the order that start.gg might list its sets.
+	matches := []convert.Match{
+		{Player1ID: 2, Player2ID: 1, WinnerID: 1, LoserID: 2, Round: 4},
+		{Player1ID: 2, Player2ID: 3, WinnerID: 2, LoserID: 3, Round: -2},
+		{Player1ID: 1, Player2ID: 4, WinnerID: 1, LoserID: 4, Round: 1},
+		{Player1ID: 1, Player2ID: 2, WinnerID: 2, LoserID: 1, Round: 3},
+		{Player1ID: 3, Player2ID: 4, WinnerID: 3, LoserID: 4, Round: -1},
+		{Player1ID: 1, Player2ID: 2, WinnerID: 1, LoserID: 2, Round: 2},
+		{Player1ID: 2, Player2ID: 3, WinnerID: 2, LoserID: 3, Round: 1},
+	}
+
+	orderMatches(matches)
+
+	var rounds []int
+	for _, m := range matches {
+		rounds = append(rounds, m.Round)
+	}
+	if want := []int{1, 1, -1, 2, -2, 3, 4}; !slices.Equal(rounds, want) {
+		t.Errorf("orderMatches() rounds = %v, want %v", rounds, want)
+	}
+
+	placements, err := convert.Placements(matches)
+	if want := map[int64]int64{1: 1, 2: 2, 3: 3, 4: 4}; err != nil || !reflect.DeepEqual(placements, want) {
+		t.Errorf("Placements() = %v, %v, want %v", placements, err, want)
+	}
+}
@@ -1,43 +1,68 @@
 package tourney_tracker
 
-import "database/sql"
+import (
+	"context"
+	"strings"
+)
 
-// Entrant represents a participant in a Tournament. Entrants may or may not represent a Player.
+// Entrant represents a participant in a Tournament. Entrants may represent no players, a single Player, or (for team events) several players.
 type Entrant struct {
-	ID           int64          `json:"id"`
-	Name         string         `json:"name"`
-	Placement    int64          `json:"placement"`
-	TournamentID int64          `json:"tournamentID"`
-	PlayerName   sql.NullString `json:"playerName"` // Storing the name rather than the player ID.
+	ID           int64    `json:"id"`
+	Name         string   `json:"name"`
+	Placement    int64    `json:"placement"`
+	TournamentID int64    `json:"tournamentID"`
+	Players      []Player `json:"players"`
+
+	// Participants holds the names of each team member, as reported by the tournament platform. This is used to help link teammates to players.
+	Participants []string `json:"participants"`
+
+	// Results holds the Entrant's placement in each Phase of the Tournament, ordered by Phase.
+	Results []Result `json:"results"`
+
+	// Version is incremented each time the players of the Entrant are changed. See EntrantService.SetPlayers().
+	Version int `json:"version"`
 }
 
 // EntrantService represents a service for managing entrants.
 type EntrantService interface {
-	// GetEntrants returns all entrants for a given Tournament.
-	GetEntrants(tournamentID int64) ([]Entrant, error)
+	// GetEntrants returns all entrants for a given Tournament, including their Phase results.
+	GetEntrants(ctx context.Context, tournamentID int64) ([]Entrant, error)
 
 	// GetEntrantWithPoints returns a single Entrant by ID, as well as the points earned by that Entrant.
-	GetEntrantWithPoints(id int64) (Entrant, int, error)
+	GetEntrantWithPoints(ctx context.Context, id int64) (Entrant, int, error)
 
 	// GetAttendance returns all attendance records for a given Player.
-	GetAttendance(playerID int64) ([]Attendee, error)
+	GetAttendance(ctx context.Context, playerID int64) ([]Attendee, error)
 
 	// CreateEntrants adds all the given entrants to the given tournament.
 	// Entrants are typically parsed in bulk by the program, so it makes sense to just add them all at once.
-	CreateEntrants(entrants []Entrant, tournamentID int64) error
+	CreateEntrants(ctx context.Context, entrants []Entrant, tournamentID int64) error
 
-	// SetPlayer updates the Player of the given Tier.
-	SetPlayer(entrantID int64, playerID sql.NullInt64) error
+	// SetPlayers replaces the players of the given Entrant, and increments its Version. An empty slice removes all players.
+	// If version is not 0, the players are only replaced if the Entrant's Version is still the given version.
+	// A Player may only be assigned to one Entrant per Tournament.
+	// An ECONFLICT error is returned if the Version has changed, or if a Player is already assigned to another Entrant of the Tournament.
+	// An ENOTFOUND error is returned if the Entrant does not exist.
+	SetPlayers(ctx context.Context, entrantID int64, version int, playerIDs []int64) error
 
 	// DeleteEntrants deletes all entrants for the given Tournament.
-	DeleteEntrants(tournamentID int64) error
+	DeleteEntrants(ctx context.Context, tournamentID int64) error
+}
+
+// PlayerNames returns the names of the Entrant's players, separated by slashes.
+func (e Entrant) PlayerNames() string {
+	names := make([]string, len(e.Players))
+	for i, player := range e.Players {
+		names[i] = player.Name
+	}
+	return strings.Join(names, " / ")
 }
 
 // Attendee represents a player's participation in a Tournament.
 type Attendee struct {
-	Tournament Preview
+	Tournament Preview `json:"tournament"`
 	Entrant    struct {
-		Name      string
-		Placement int64
-	}
+		Name      string `json:"name"`
+		Placement int64  `json:"placement"`
+	} `json:"entrant"`
 }
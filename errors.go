@@ -0,0 +1,72 @@
+package tourney_tracker
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ejacobg/tourney-tracker/validator"
+)
+
+// Error codes describe what kind of problem an Error is, so that callers can react to it without knowing where it came from.
+// The http package maps each code to a status code.
+const (
+	ECONFLICT = "conflict"  // The change conflicts with the current data, eg. a duplicate name, or a change made by someone else first.
+	EINTERNAL = "internal"  // An unexpected problem, such as a failed database query.
+	EINVALID  = "invalid"   // The input was invalid, eg. a reference to a Tier that does not exist.
+	ENOTFOUND = "not_found" // The requested object does not exist.
+	EUPSTREAM = "upstream"  // A tournament platform such as Challonge or start.gg failed, or returned something that could not be used.
+)
+
+// Error is an error whose message can be shown to users, unless its code is EINTERNAL.
+// Errors that are not an *Error are treated as internal errors, and their details should not be shown to users.
+type Error struct {
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// Errorf returns an *Error with the given code and formatted message.
+func Errorf(code, format string, args ...any) *Error {
+	return &Error{
+		Code:    code,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// ErrorCode returns the code of the given error, or EINTERNAL if it is not an *Error. It returns an empty string if the error is nil.
+func ErrorCode(err error) string {
+	var e *Error
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &e):
+		return e.Code
+	default:
+		return EINTERNAL
+	}
+}
+
+// ErrorMessage returns the message of the given error, or a generic message if it is an internal error. It returns an empty string if the error is nil.
+// The messages of EINTERNAL errors describe problems with the tracker itself, so they are only meant for its logs.
+func ErrorMessage(err error) string {
+	var e *Error
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &e) && e.Code != EINTERNAL:
+		return e.Message
+	default:
+		return "Something went wrong. Please try again later."
+	}
+}
+
+// checkFound adds the given message to the Validator if err is an ENOTFOUND error. Any other error is returned.
+func checkFound(v *validator.Validator, key, message string, err error) error {
+	if ErrorCode(err) == ENOTFOUND {
+		v.AddError(key, message)
+		return nil
+	}
+	return err
+}
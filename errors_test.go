@@ -0,0 +1,32 @@
+package tourney_tracker
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestErrorCode(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantCode    string
+		wantMessage string
+	}{
+		{"nil", nil, "", ""},
+		{"domain", Errorf(ENOTFOUND, "Player not found."), ENOTFOUND, "Player not found."},
+		{"wrapped", fmt.Errorf("failed to get player: %w", Errorf(ENOTFOUND, "Player not found.")), ENOTFOUND, "Player not found."},
+		{"other", errors.New("connection refused"), EINTERNAL, "Something went wrong. Please try again later."},
+		{"internal", Errorf(EINTERNAL, "Entrant 1 has placement 3, which is not one of the placements of their tournament."), EINTERNAL, "Something went wrong. Please try again later."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ErrorCode(tt.err); got != tt.wantCode {
+				t.Errorf("ErrorCode() = %q, want %q", got, tt.wantCode)
+			}
+			if got := ErrorMessage(tt.err); got != tt.wantMessage {
+				t.Errorf("ErrorMessage() = %q, want %q", got, tt.wantMessage)
+			}
+		})
+	}
+}
@@ -1,33 +1,133 @@
 package tourney_tracker
 
-// Variables used in the point formula. These are not stored in the database.
-const (
+import (
+	"context"
+	"fmt"
+	"github.com/ejacobg/tourney-tracker/validator"
+	"golang.org/x/exp/slices"
+)
+
+// Formula holds the variables used in the point formula. Admins can change them, so they are kept by the FormulaService.
+type Formula struct {
 	// UP is the points given for each unique placement.
-	UP = 5
+	UP int `json:"up"`
 
 	// ATT is the points given for showing up to a tournament.
-	ATT = 10
+	ATT int `json:"att"`
 
 	// FIRST is the points given for winning a tournament.
-	FIRST = 10
+	FIRST int `json:"first"`
 
 	// BR is the points given to the second-place finisher if they made a bracket reset.
-	BR = 5
-)
+	BR int `json:"br"`
+}
+
+// DefaultFormula holds the variables that the tracker starts with.
+var DefaultFormula = Formula{UP: 5, ATT: 10, FIRST: 10, BR: 5}
+
+// MaxFormulaPoints is the largest value that a variable of the Formula may have.
+const MaxFormulaPoints = 1000
+
+// ValidateFormula checks that every variable of the Formula is between 0 and MaxFormulaPoints.
+func ValidateFormula(v *validator.Validator, f Formula) {
+	variables := []struct {
+		key, name string
+		value     int
+	}{
+		{"up", "UP", f.UP},
+		{"att", "ATT", f.ATT},
+		{"first", "FIRST", f.FIRST},
+		{"br", "BR", f.BR},
+	}
+	for _, variable := range variables {
+		v.Check(variable.value >= 0, variable.key, fmt.Sprintf("%s must not be negative.", variable.name))
+		v.Check(variable.value <= MaxFormulaPoints, variable.key, fmt.Sprintf("%s must not be greater than %d.", variable.name, MaxFormulaPoints))
+	}
+}
+
+// FormulaService represents a service for managing the point formula.
+type FormulaService interface {
+	// GetFormula returns the current Formula, or the DefaultFormula if it has never been changed.
+	GetFormula(ctx context.Context) (Formula, error)
+
+	// UpdateFormula replaces the current Formula. Points are calculated when they are read, so this changes the points of every tournament.
+	UpdateFormula(ctx context.Context, formula Formula) error
+}
 
 // NewPointMap returns a mapping from each placement to the number of points it is worth.
 // The point formula is as follows: (UP * PV + ATT + FIRST? + BR?) * TIER
-func NewPointMap(bracketReset bool, placements []int64, multiplier int) map[int64]int {
+func (f Formula) NewPointMap(tourney Tournament) map[int64]int {
 	pm := make(map[int64]int)
-	for i, placement := range placements {
-		points := UP*i + ATT
-		if placement == 1 {
-			points += FIRST
-		} else if placement == 2 && bracketReset {
-			points += BR
+	for _, placement := range tourney.Placements {
+		pm[placement], _ = f.Points(tourney, placement)
+	}
+	return pm
+}
+
+// Points returns the number of points earned by the given placement in the given Tournament.
+// The second return value is false if the placement does not exist in the Tournament.
+// Only the BracketType, BracketReset, Placements, and Tier.Multiplier fields of the Tournament are used.
+//
+// The rules for each bracket type are:
+//   - Double-elimination tournaments use the full formula.
+//   - Single-elimination tournaments do not have a grand final reset, so BR is never awarded.
+//   - Round-robin and Swiss tournaments rank every entrant individually, so BR is never awarded and their PV is calculated using PlacementValue().
+func (f Formula) Points(tourney Tournament, placement int64) (int, bool) {
+	PV := PlacementValue(tourney.BracketType, tourney.Placements, placement)
+	if PV == -1 {
+		return 0, false
+	}
+
+	points := f.UP*PV + f.ATT
+	if placement == 1 {
+		points += f.FIRST
+	} else if placement == 2 && tourney.BracketReset && tourney.BracketType == DoubleElimination {
+		points += f.BR
+	}
+
+	return points * tourney.Tier.Multiplier, true
+}
+
+// PlacementValue returns the PV of the given placement, or -1 if it is not one of the given unique placements.
+// For elimination brackets, this is the index of the placement.
+// Round-robin and Swiss tournaments give every entrant their own placement, which would make them worth far more than a bracket of the same size.
+// Instead, their placements are grouped into the placements of a double-elimination bracket first, so a 16-player pool is worth as much as a 16-player bracket.
+func PlacementValue(bracketType BracketType, placements []int64, placement int64) int {
+	if bracketType.Elimination() || bracketType == "" {
+		return slices.Index(placements, placement)
+	}
+
+	if !slices.Contains(placements, placement) {
+		return -1
+	}
+
+	// Placements are in reverse-sorted order, so the grouped placements will be too.
+	var grouped []int64
+	for _, p := range placements {
+		p = eliminationPlacement(p)
+		if !slices.Contains(grouped, p) {
+			grouped = append(grouped, p)
 		}
+	}
+
+	return slices.Index(grouped, eliminationPlacement(placement))
+}
 
-		pm[placement] = points * multiplier
+// eliminationPlacement returns the double-elimination placement that the given rank would fall into.
+// Double-elimination placements look like this: 1, 2, 3, 4, 5, 7, 9, 13, 17, 25, 33, 49, ...
+func eliminationPlacement(rank int64) int64 {
+	if rank <= 5 {
+		return rank
 	}
-	return pm
+
+	// After 5th place, the gap between placements doubles every two placements.
+	placement, gap := int64(5), int64(2)
+	for i := 0; placement+gap <= rank; i++ {
+		placement += gap
+		if i%2 == 1 {
+			gap *= 2
+		}
+	}
+
+	return placement
 }
@@ -0,0 +1,50 @@
+package tourney_tracker
+
+import "testing"
+
+func TestFormula_Points(t *testing.T) {
+	UP, ATT, FIRST, BR := DefaultFormula.UP, DefaultFormula.ATT, DefaultFormula.FIRST, DefaultFormula.BR
+	tests := []struct {
+		name      string
+		tourney   Tournament
+		placement int64
+		want      int
+		wantOK    bool
+	}{
+		{"double elimination last", Tournament{BracketType: DoubleElimination, Placements: []int64{7, 5, 4, 3, 2, 1}, Tier: Tier{Multiplier: 1}}, 7, ATT, true},
+		{"double elimination first", Tournament{BracketType: DoubleElimination, Placements: []int64{7, 5, 4, 3, 2, 1}, Tier: Tier{Multiplier: 2}}, 1, (UP*5 + ATT + FIRST) * 2, true},
+		{"double elimination reset", Tournament{BracketType: DoubleElimination, BracketReset: true, Placements: []int64{7, 5, 4, 3, 2, 1}, Tier: Tier{Multiplier: 1}}, 2, UP*4 + ATT + BR, true},
+		{"single elimination reset", Tournament{BracketType: SingleElimination, BracketReset: true, Placements: []int64{5, 3, 2, 1}, Tier: Tier{Multiplier: 1}}, 2, UP*2 + ATT, true},
+		{"round robin grouped", Tournament{BracketType: RoundRobin, Placements: []int64{8, 7, 6, 5, 4, 3, 2, 1}, Tier: Tier{Multiplier: 1}}, 6, UP*1 + ATT, true},
+		{"round robin last", Tournament{BracketType: RoundRobin, Placements: []int64{8, 7, 6, 5, 4, 3, 2, 1}, Tier: Tier{Multiplier: 1}}, 8, ATT, true},
+		{"swiss first", Tournament{BracketType: Swiss, Placements: []int64{8, 7, 6, 5, 4, 3, 2, 1}, Tier: Tier{Multiplier: 1}}, 1, UP*5 + ATT + FIRST, true},
+		{"missing placement", Tournament{BracketType: DoubleElimination, Placements: []int64{7, 5, 4, 3, 2, 1}, Tier: Tier{Multiplier: 1}}, 6, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := DefaultFormula.Points(tt.tourney, tt.placement)
+			if ok != tt.wantOK {
+				t.Errorf("Points() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if got != tt.want {
+				t.Errorf("Points() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Changing the Formula changes the points of every placement.
+	custom := Formula{UP: 1, ATT: 2, FIRST: 3, BR: 4}
+	reset := Tournament{BracketType: DoubleElimination, BracketReset: true, Placements: []int64{3, 2, 1}, Tier: Tier{Multiplier: 10}}
+	if got, _ := custom.Points(reset, 2); got != (1*1+2+4)*10 {
+		t.Errorf("Points() with a custom formula = %d, want %d", got, (1*1+2+4)*10)
+	}
+}
+
+func Test_eliminationPlacement(t *testing.T) {
+	want := map[int64]int64{1: 1, 4: 4, 5: 5, 6: 5, 7: 7, 8: 7, 9: 9, 12: 9, 13: 13, 16: 13, 17: 17, 24: 17, 25: 25, 33: 33, 48: 33, 49: 49, 65: 65}
+	for rank, placement := range want {
+		if got := eliminationPlacement(rank); got != placement {
+			t.Errorf("eliminationPlacement(%d) = %d, want %d", rank, got, placement)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package tourney_tracker
+
+import (
+	"context"
+	"github.com/ejacobg/tourney-tracker/validator"
+)
+
+// Game represents a video game that tournaments are played in. Each game has its own rankings.
+type Game struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// GameService represents a service for managing games.
+type GameService interface {
+	// GetGames returns all games, ordered by name.
+	GetGames(ctx context.Context) ([]Game, error)
+
+	// GetGame returns a single Game by ID.
+	GetGame(ctx context.Context, id int64) (Game, error)
+
+	// CreateGame adds the given Game to the database.
+	// If a Game with the same name already exists, its ID will be used instead.
+	CreateGame(ctx context.Context, game *Game) error
+}
+
+// ValidateGameID checks that the given Game exists. Errors are added under the given key.
+// An error is only returned if the Game could not be checked.
+func ValidateGameID(ctx context.Context, v *validator.Validator, gs GameService, key string, id int64) error {
+	_, err := gs.GetGame(ctx, id)
+	return checkFound(v, key, "That game does not exist.", err)
+}
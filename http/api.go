@@ -0,0 +1,595 @@
+package http
+
+import (
+	"fmt"
+	tournament "github.com/ejacobg/tourney-tracker"
+	"github.com/ejacobg/tourney-tracker/validator"
+	"golang.org/x/exp/slices"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// apiPrefix is prepended to every API route. Breaking changes to the API should be made under a new version.
+const apiPrefix = "/api/v1"
+
+// apiRoutes returns every route of the API. The same list is used to register the routes and to generate the OpenAPI document,
+// so a route cannot be added without also being documented.
+func (s *Server) apiRoutes() []apiRoute {
+	var (
+		gameQuery = apiParam{"game", "Only include results from the Game with this ID. Omit or use 0 for every game."}
+		id        = func(resource string) apiParam {
+			return apiParam{"id", fmt.Sprintf("The ID of the %s.", resource)}
+		}
+	)
+
+	return []apiRoute{
+		{Method: http.MethodGet, Path: "/rankings", Role: tournament.RoleViewer, Tag: "Rankings", Summary: "Get the player rankings.",
+			Query:    []apiParam{{"leaderboard", `Use "doubles" for the doubles leaderboard. Omit for the singles rankings.`}, gameQuery},
+			Response: envelope{"ranks": []tournament.Rank{}}, Handler: s.apiGetRankings},
+		{Method: http.MethodGet, Path: "/games", Role: tournament.RoleViewer, Tag: "Games", Summary: "List all games.",
+			Response: envelope{"games": []tournament.Game{}}, Handler: s.apiGetGames},
+
+		{Method: http.MethodGet, Path: "/players", Role: tournament.RoleViewer, Tag: "Players", Summary: "List all players.",
+			Query:    []apiParam{gameQuery},
+			Response: envelope{"players": []tournament.Player{}}, Handler: s.apiGetPlayers},
+		{Method: http.MethodPost, Path: "/players", Role: tournament.RoleEditor, Tag: "Players", Summary: "Create a player.",
+			Request: playerInput{}, Status: http.StatusCreated,
+			Response: envelope{"player": tournament.Player{}}, Handler: s.apiPostPlayer},
+		{Method: http.MethodGet, Path: "/players/:id", Role: tournament.RoleViewer, Tag: "Players", Summary: "Get a player and their tournament history.",
+			Params:   []apiParam{id("player")},
+			Response: envelope{"player": tournament.Player{}, "attendance": []tournament.Attendee{}}, Handler: s.apiGetPlayer},
+		{Method: http.MethodPut, Path: "/players/:id", Role: tournament.RoleEditor, Tag: "Players", Summary: "Rename a player.",
+			Params: []apiParam{id("player")}, Request: playerInput{},
+			Response: envelope{"player": tournament.Player{}}, Handler: s.apiPutPlayer},
+		{Method: http.MethodDelete, Path: "/players/:id", Role: tournament.RoleOrganizer, Tag: "Players", Summary: "Move a player to the trash, hiding them from their entrants.",
+			Params: []apiParam{id("player")}, Status: http.StatusNoContent, Handler: s.apiDeletePlayer},
+		{Method: http.MethodPut, Path: "/players/:id/restore", Role: tournament.RoleOrganizer, Tag: "Players", Summary: "Restore a player from the trash.",
+			Params:   []apiParam{id("player")},
+			Response: envelope{"player": tournament.Player{}}, Handler: s.apiPutPlayerRestore},
+
+		{Method: http.MethodGet, Path: "/tournaments", Role: tournament.RoleViewer, Tag: "Tournaments", Summary: "List all tournaments.",
+			Query:    []apiParam{gameQuery},
+			Response: envelope{"tournaments": []tournament.Preview{}}, Handler: s.apiGetTournaments},
+		{Method: http.MethodPost, Path: "/tournaments", Role: tournament.RoleOrganizer, Tag: "Tournaments", Summary: "Import a tournament from a Challonge or start.gg URL.",
+			Request: tournamentInput{}, Status: http.StatusCreated, Import: true,
+			Response: envelope{"tournament": tournament.Tournament{}}, Handler: s.apiPostTournament},
+		{Method: http.MethodGet, Path: "/tournaments/:id", Role: tournament.RoleViewer, Tag: "Tournaments", Summary: "Get a tournament and the points that each placement is worth.",
+			Params:   []apiParam{id("tournament")},
+			Response: envelope{"tournament": tournament.Tournament{}, "points": map[int64]int{}}, Handler: s.apiGetTournament},
+		{Method: http.MethodGet, Path: "/tournaments/:id/entrants", Role: tournament.RoleViewer, Tag: "Tournaments", Summary: "List the entrants of a tournament.",
+			Params:   []apiParam{id("tournament")},
+			Response: envelope{"entrants": []tournament.Entrant{}}, Handler: s.apiGetTournamentEntrants},
+		{Method: http.MethodPut, Path: "/tournaments/:id/tier", Role: tournament.RoleAdmin, Tag: "Tournaments", Summary: "Change the tier of a tournament.",
+			Params: []apiParam{id("tournament")}, Request: tierInput{},
+			Response: envelope{"tier": tournament.Tier{}}, Handler: s.apiPutTournamentTier},
+		{Method: http.MethodDelete, Path: "/tournaments/:id", Role: tournament.RoleOrganizer, Tag: "Tournaments", Summary: "Move a tournament and its entrants to the trash.",
+			Params: []apiParam{id("tournament")}, Status: http.StatusNoContent, Handler: s.apiDeleteTournament},
+		{Method: http.MethodPut, Path: "/tournaments/:id/restore", Role: tournament.RoleOrganizer, Tag: "Tournaments", Summary: "Restore a tournament from the trash.",
+			Params:   []apiParam{id("tournament")},
+			Response: envelope{"tournament": tournament.Tournament{}}, Handler: s.apiPutTournamentRestore},
+
+		{Method: http.MethodGet, Path: "/trash", Role: tournament.RoleOrganizer, Tag: "Trash", Summary: "List the deleted tournaments and players that can still be restored.",
+			Response: envelope{"tournaments": []tournament.Trashed{}, "players": []tournament.Trashed{}}, Handler: s.apiGetTrash},
+
+		{Method: http.MethodGet, Path: "/tiers", Role: tournament.RoleViewer, Tag: "Tiers", Summary: "List all tiers.",
+			Response: envelope{"tiers": []tournament.Tier{}}, Handler: s.apiGetTiers},
+		{Method: http.MethodGet, Path: "/tiers/:id", Role: tournament.RoleViewer, Tag: "Tiers", Summary: "Get a tier and the names of its tournaments.",
+			Params:   []apiParam{id("tier")},
+			Response: envelope{"tier": tournament.Tier{}, "tournaments": []tournament.Name{}}, Handler: s.apiGetTier},
+
+		{Method: http.MethodGet, Path: "/entrants/:id", Role: tournament.RoleViewer, Tag: "Entrants", Summary: "Get an entrant and the points they earned.",
+			Params:   []apiParam{id("entrant")},
+			Response: envelope{"entrant": tournament.Entrant{}, "points": 0}, Handler: s.apiGetEntrant},
+		{Method: http.MethodPut, Path: "/entrants/:id/players", Role: tournament.RoleEditor, Tag: "Entrants", Summary: "Replace the players linked to an entrant.",
+			Params: []apiParam{id("entrant")}, Request: entrantPlayersInput{},
+			Response: envelope{"entrant": tournament.Entrant{}, "points": 0}, Handler: s.apiPutEntrantPlayers},
+	}
+}
+
+// The API mirrors the HTML routes, but every request and response body is JSON.
+// Errors are always returned in the form described by JSONErrorResponse.
+// Like the HTML routes, anyone may read from the API, but changes must be made by a logged-in User with the right Role.
+func (s *Server) registerAPIRoutes() {
+	for _, route := range s.apiRoutes() {
+		if route.Import {
+			s.handleImport(route.Method, apiPrefix+route.Path, route.Role, route.Handler)
+			continue
+		}
+		s.handle(route.Method, apiPrefix+route.Path, route.Role, route.Handler)
+	}
+
+	s.handle(http.MethodGet, "/api/openapi.json", tournament.RoleViewer, s.getOpenAPI)
+	s.handle(http.MethodGet, "/api/docs", tournament.RoleViewer, s.getAPIDocs)
+}
+
+// Request bodies accepted by the API.
+type (
+	playerInput struct {
+		Name string `json:"name"`
+
+		// Version is the Version of the Player that the change was based on. If set, the change is rejected if the Player has changed since.
+		Version int `json:"version,omitempty"`
+	}
+
+	tournamentInput struct {
+		URL string `json:"url"`
+	}
+
+	tierInput struct {
+		TierID int64 `json:"tierID"`
+	}
+
+	entrantPlayersInput struct {
+		PlayerIDs []int64 `json:"playerIDs"`
+
+		// Version is the Version of the Entrant that the change was based on. If set, the change is rejected if the Entrant has changed since.
+		Version int `json:"version,omitempty"`
+	}
+)
+
+// isAPIRequest returns true if the request was made to an API route.
+func isAPIRequest(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.Path, apiPrefix+"/")
+}
+
+// apiGetRankings responds with the singles rankings, or the doubles leaderboard if the "leaderboard" query parameter is "doubles".
+// The rankings may be limited to a single Game using the "game" query parameter.
+func (s *Server) apiGetRankings(w http.ResponseWriter, r *http.Request) {
+	filter := tournament.RankFilter{
+		Doubles: r.URL.Query().Get("leaderboard") == "doubles",
+		GameID:  readGameQuery(r),
+	}
+
+	ranks, err := s.PlayerService.GetRanks(r.Context(), filter)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, envelope{"ranks": ranks})
+}
+
+// apiGetGames responds with all known games.
+func (s *Server) apiGetGames(w http.ResponseWriter, r *http.Request) {
+	games, err := s.GameService.GetGames(r.Context())
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, envelope{"games": games})
+}
+
+// apiGetPlayers responds with all saved players. The players may be limited to a single Game using the "game" query parameter.
+func (s *Server) apiGetPlayers(w http.ResponseWriter, r *http.Request) {
+	players, err := s.PlayerService.GetPlayers(r.Context(), readGameQuery(r))
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, envelope{"players": players})
+}
+
+// apiPostPlayer accepts a JSON object with a "name" field, and responds with the newly created Player.
+func (s *Server) apiPostPlayer(w http.ResponseWriter, r *http.Request) {
+	var input playerInput
+
+	err := readJSON(w, r, &input)
+	if err != nil {
+		JSONBadRequestResponse(w, err.Error())
+		return
+	}
+
+	player := tournament.Player{Name: input.Name}
+
+	v := validator.New()
+	if tournament.ValidatePlayer(v, &player); !v.Valid() {
+		JSONFailedValidationResponse(w, v.Errors)
+		return
+	}
+
+	err = s.PlayerService.CreatePlayer(r.Context(), &player)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("%s/players/%d", apiPrefix, player.ID))
+	writeJSON(w, http.StatusCreated, envelope{"player": player})
+}
+
+// apiGetPlayer responds with the given Player and their tournament history.
+func (s *Server) apiGetPlayer(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		JSONNotFoundResponse(w, "Invalid player ID.")
+		return
+	}
+
+	player, err := s.PlayerService.GetPlayer(r.Context(), id)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	attendance, err := s.EntrantService.GetAttendance(r.Context(), id)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, envelope{"player": player, "attendance": attendance})
+}
+
+// apiPutPlayer accepts a JSON object with a "name" field, which will be applied to the given Player.
+// The updated Player is returned.
+func (s *Server) apiPutPlayer(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		JSONNotFoundResponse(w, "Invalid player ID.")
+		return
+	}
+
+	var input playerInput
+
+	err = readJSON(w, r, &input)
+	if err != nil {
+		JSONBadRequestResponse(w, err.Error())
+		return
+	}
+
+	player := tournament.Player{
+		ID:      id,
+		Name:    input.Name,
+		Version: input.Version,
+	}
+
+	v := validator.New()
+	if tournament.ValidatePlayer(v, &player); !v.Valid() {
+		JSONFailedValidationResponse(w, v.Errors)
+		return
+	}
+
+	err = s.PlayerService.UpdatePlayer(r.Context(), &player)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, envelope{"player": player})
+}
+
+// apiDeletePlayer moves the given Player to the trash.
+func (s *Server) apiDeletePlayer(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		JSONNotFoundResponse(w, "Invalid player ID.")
+		return
+	}
+
+	err = s.PlayerService.DeletePlayer(r.Context(), id)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// apiPutPlayerRestore moves the given Player out of the trash, and responds with the restored Player.
+func (s *Server) apiPutPlayerRestore(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		JSONNotFoundResponse(w, "Invalid player ID.")
+		return
+	}
+
+	err = s.PlayerService.RestorePlayer(r.Context(), id)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	player, err := s.PlayerService.GetPlayer(r.Context(), id)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, envelope{"player": player})
+}
+
+// apiGetTournaments responds with previews of all saved tournaments.
+// The tournaments may be limited to a single Game using the "game" query parameter.
+func (s *Server) apiGetTournaments(w http.ResponseWriter, r *http.Request) {
+	previews, err := s.TournamentService.GetPreviews(r.Context(), readGameQuery(r))
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, envelope{"tournaments": previews})
+}
+
+// apiPostTournament accepts a JSON object with a "url" field containing a URL to a tournament.
+// The tournament will be imported in the same way as the HTML form, and the new Tournament is returned.
+func (s *Server) apiPostTournament(w http.ResponseWriter, r *http.Request) {
+	var input tournamentInput
+
+	err := readJSON(w, r, &input)
+	if err != nil {
+		JSONBadRequestResponse(w, err.Error())
+		return
+	}
+
+	v := validator.New()
+
+	URL, err := url.Parse(strings.TrimSpace(input.URL))
+	v.Check(validator.NotBlank(input.URL), "url", "URL must not be empty.")
+	v.Check(err == nil, "url", "Failed to parse URL.")
+	if !v.Valid() {
+		JSONFailedValidationResponse(w, v.Errors)
+		return
+	}
+
+	tourney, entrants, err := s.fetchTournament(r.Context(), URL)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	if tournament.ValidateTournament(v, &tourney, entrants); !v.Valid() {
+		JSONFailedValidationResponse(w, v.Errors)
+		return
+	}
+
+	err = s.TournamentService.CreateTournament(r.Context(), &tourney, entrants)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("%s/tournaments/%d", apiPrefix, tourney.ID))
+	writeJSON(w, http.StatusCreated, envelope{"tournament": tourney})
+}
+
+// apiGetTournament responds with the given Tournament, as well as the number of points that each of its placements is worth.
+func (s *Server) apiGetTournament(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		JSONNotFoundResponse(w, "Invalid tournament ID.")
+		return
+	}
+
+	tourney, err := s.TournamentService.GetTournament(r.Context(), id)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	formula, err := s.FormulaService.GetFormula(r.Context())
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, envelope{"tournament": tourney, "points": formula.NewPointMap(tourney)})
+}
+
+// apiGetTournamentEntrants responds with all the entrants of the given Tournament.
+func (s *Server) apiGetTournamentEntrants(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		JSONNotFoundResponse(w, "Invalid tournament ID.")
+		return
+	}
+
+	entrants, err := s.EntrantService.GetEntrants(r.Context(), id)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, envelope{"entrants": entrants})
+}
+
+// apiPutTournamentTier accepts a JSON object with a "tierID" field, which will be applied to the given Tournament.
+// The new Tier is returned.
+func (s *Server) apiPutTournamentTier(w http.ResponseWriter, r *http.Request) {
+	tournamentID, err := readIDParam(r)
+	if err != nil {
+		JSONNotFoundResponse(w, "Invalid tournament ID.")
+		return
+	}
+
+	var input tierInput
+
+	err = readJSON(w, r, &input)
+	if err != nil {
+		JSONBadRequestResponse(w, err.Error())
+		return
+	}
+
+	v := validator.New()
+	if err = tournament.ValidateTierID(r.Context(), v, s.TierService, "tierID", input.TierID); err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+	if !v.Valid() {
+		JSONFailedValidationResponse(w, v.Errors)
+		return
+	}
+
+	err = s.TournamentService.SetTier(r.Context(), tournamentID, input.TierID)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	tier, err := s.TierService.GetTournamentTier(r.Context(), tournamentID)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, envelope{"tier": tier})
+}
+
+// apiDeleteTournament moves the given Tournament to the trash.
+func (s *Server) apiDeleteTournament(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		JSONNotFoundResponse(w, "Invalid tournament ID.")
+		return
+	}
+
+	err = s.TournamentService.DeleteTournament(r.Context(), id)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// apiPutTournamentRestore moves the given Tournament out of the trash, and responds with the restored Tournament.
+func (s *Server) apiPutTournamentRestore(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		JSONNotFoundResponse(w, "Invalid tournament ID.")
+		return
+	}
+
+	err = s.TournamentService.RestoreTournament(r.Context(), id)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	tourney, err := s.TournamentService.GetTournament(r.Context(), id)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, envelope{"tournament": tourney})
+}
+
+// apiGetTrash responds with the deleted tournaments and players that can still be restored.
+func (s *Server) apiGetTrash(w http.ResponseWriter, r *http.Request) {
+	tournaments, err := s.TournamentService.GetDeletedTournaments(r.Context())
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	players, err := s.PlayerService.GetDeletedPlayers(r.Context())
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, envelope{"tournaments": tournaments, "players": players})
+}
+
+// apiGetTiers responds with all the current tiers.
+func (s *Server) apiGetTiers(w http.ResponseWriter, r *http.Request) {
+	tiers, err := s.TierService.GetTiers(r.Context())
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, envelope{"tiers": tiers})
+}
+
+// apiGetTier responds with the given Tier, as well as the names of all tournaments with that Tier.
+func (s *Server) apiGetTier(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		JSONNotFoundResponse(w, "Invalid tier ID.")
+		return
+	}
+
+	tier, err := s.TierService.GetTier(r.Context(), id)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	names, err := s.TournamentService.GetNamesByTier(r.Context(), id)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, envelope{"tier": tier, "tournaments": names})
+}
+
+// apiGetEntrant responds with the given Entrant, as well as the points that they earned.
+func (s *Server) apiGetEntrant(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		JSONNotFoundResponse(w, "Invalid entrant ID.")
+		return
+	}
+
+	entrant, points, err := s.EntrantService.GetEntrantWithPoints(r.Context(), id)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, envelope{"entrant": entrant, "points": points})
+}
+
+// apiPutEntrantPlayers accepts a JSON object with a "playerIDs" field, which replaces the players linked to the given Entrant.
+// An empty list unlinks every player. The updated Entrant is returned.
+func (s *Server) apiPutEntrantPlayers(w http.ResponseWriter, r *http.Request) {
+	entrantID, err := readIDParam(r)
+	if err != nil {
+		JSONNotFoundResponse(w, "Invalid entrant ID.")
+		return
+	}
+
+	var input entrantPlayersInput
+
+	err = readJSON(w, r, &input)
+	if err != nil {
+		JSONBadRequestResponse(w, err.Error())
+		return
+	}
+
+	var playerIDs []int64
+	for _, playerID := range input.PlayerIDs {
+		if !slices.Contains(playerIDs, playerID) {
+			playerIDs = append(playerIDs, playerID)
+		}
+	}
+
+	v := validator.New()
+	if err = tournament.ValidatePlayerIDs(r.Context(), v, s.PlayerService, "playerIDs", playerIDs); err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+	if !v.Valid() {
+		JSONFailedValidationResponse(w, v.Errors)
+		return
+	}
+
+	err = s.EntrantService.SetPlayers(r.Context(), entrantID, input.Version, playerIDs)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	entrant, points, err := s.EntrantService.GetEntrantWithPoints(r.Context(), entrantID)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, envelope{"entrant": entrant, "points": points})
+}
@@ -0,0 +1,66 @@
+package http
+
+import (
+	tournament "github.com/ejacobg/tourney-tracker"
+	"net/http"
+	"strconv"
+)
+
+// auditPageSize is the number of entries shown on each page of the audit log.
+const auditPageSize = 50
+
+func (s *Server) registerAuditRoutes() {
+	s.handle(http.MethodGet, "/audit", tournament.RoleEditor, s.getAudit)
+}
+
+// getAudit renders a page of the audit log. The entries may be filtered using the "actor", "action", "subject", and "id" query parameters.
+// The "page" query parameter selects older entries, starting from 1.
+func (s *Server) getAudit(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter := tournament.EntryFilter{
+		Actor:   query.Get("actor"),
+		Action:  tournament.Action(query.Get("action")),
+		Subject: query.Get("subject"),
+		Limit:   auditPageSize + 1, // One extra entry is fetched to find out if there is another page.
+	}
+	filter.SubjectID, _ = strconv.ParseInt(query.Get("id"), 10, 64)
+
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	filter.Offset = (page - 1) * auditPageSize
+
+	entries, err := s.AuditService.GetEntries(r.Context(), filter)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	more := len(entries) > auditPageSize
+	if more {
+		entries = entries[:auditPageSize]
+	}
+
+	// Links to other pages keep the current filter.
+	pageURL := func(page int) string {
+		query.Set("page", strconv.Itoa(page))
+		return "/audit?" + query.Encode()
+	}
+	var newer, older string
+	if page > 1 {
+		newer = pageURL(page - 1)
+	}
+	if more {
+		older = pageURL(page + 1)
+	}
+
+	s.Render(w, r, 200, "audit/index.go.html", "base", map[string]any{
+		"Entries": entries,
+		"Actions": tournament.Actions,
+		"Filter":  filter,
+		"Newer":   newer,
+		"Older":   older,
+	})
+}
@@ -0,0 +1,110 @@
+package http
+
+import (
+	"context"
+	tournament "github.com/ejacobg/tourney-tracker"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestContextSetUser_actor(t *testing.T) {
+	srv, admin := newInmemServer(t)
+
+	player := tournament.Player{Name: "before"}
+	if err := srv.PlayerService.CreatePlayer(context.Background(), &player); err != nil {
+		t.Fatalf("CreatePlayer() error = %v", err)
+	}
+
+	r := httptest.NewRequest(http.MethodPut, "/players/1/name", nil)
+	r = contextSetUser(r, admin)
+
+	err := srv.PlayerService.UpdatePlayer(r.Context(), &tournament.Player{ID: player.ID, Name: "after", Version: 1})
+	if err != nil {
+		t.Fatalf("UpdatePlayer() error = %v", err)
+	}
+
+	entries, err := srv.AuditService.GetEntries(context.Background(), tournament.EntryFilter{Action: tournament.ActionUpdatePlayer})
+	if err != nil {
+		t.Fatalf("GetEntries() error = %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("GetEntries() returned %d entries, want 1", len(entries))
+	}
+
+	entry := entries[0]
+	if entry.SubjectID != player.ID {
+		t.Errorf("entry subject = %d, want %d", entry.SubjectID, player.ID)
+	}
+	if entry.UserID == nil || *entry.UserID != admin.ID || entry.Actor != admin.Name {
+		t.Errorf("entry actor = %v (%q), want %d (%q)", entry.UserID, entry.Actor, admin.ID, admin.Name)
+	}
+	if string(entry.Before) != `{"id":1,"name":"before","version":1}` || string(entry.After) != `{"id":1,"name":"after","version":2}` {
+		t.Errorf("entry before = %s, after = %s", entry.Before, entry.After)
+	}
+}
+
+func TestContextSetUser_unrecorded(t *testing.T) {
+	srv, _ := newInmemServer(t)
+
+	player := tournament.Player{Name: "before"}
+	if err := srv.PlayerService.CreatePlayer(context.Background(), &player); err != nil {
+		t.Fatalf("CreatePlayer() error = %v", err)
+	}
+
+	// A User who does not exist cannot be recorded, so the change is not made.
+	r := httptest.NewRequest(http.MethodPut, "/players/1/name", nil)
+	r = contextSetUser(r, &tournament.User{ID: 99, Name: "ghost"})
+
+	err := srv.PlayerService.UpdatePlayer(r.Context(), &tournament.Player{ID: player.ID, Name: "after", Version: 1})
+	if err == nil {
+		t.Fatal("UpdatePlayer() error = nil, want an error")
+	}
+
+	got, err := srv.PlayerService.GetPlayer(context.Background(), player.ID)
+	if err != nil || got.Name != "before" {
+		t.Errorf("GetPlayer() = %+v, %v, want the name to be unchanged", got, err)
+	}
+}
+
+// snapshotService is a SnapshotService that records the order in which snapshots are taken and restored.
+type snapshotService struct {
+	tournament.SnapshotService
+	calls *[]string
+}
+
+func (ss snapshotService) GetSnapshot(_ context.Context, id int64) (tournament.Snapshot, error) {
+	return tournament.Snapshot{ID: id, Name: "saved"}, nil
+}
+
+func (ss snapshotService) CreateSnapshot(_ context.Context, snapshot *tournament.Snapshot) error {
+	*ss.calls = append(*ss.calls, "create "+snapshot.Name)
+	return nil
+}
+
+func (ss snapshotService) RestoreSnapshot(context.Context, int64) error {
+	*ss.calls = append(*ss.calls, "restore")
+	return nil
+}
+
+func TestServer_putSnapshotRestore(t *testing.T) {
+	srv, admin := newInmemServer(t)
+
+	var calls []string
+	srv.SnapshotService = snapshotService{calls: &calls}
+
+	r := httptest.NewRequest(http.MethodPut, "/snapshots/1/restore", nil)
+	r = contextSetUser(r, admin)
+	w := httptest.NewRecorder()
+
+	srv.router.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %v, want %v: %s", w.Code, http.StatusOK, w.Body)
+	}
+
+	// The current data should be saved before it is replaced.
+	if len(calls) != 2 || calls[0] != `create Before restoring "saved"` || calls[1] != "restore" {
+		t.Errorf("putSnapshotRestore() calls = %q", calls)
+	}
+}
@@ -0,0 +1,272 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	tournament "github.com/ejacobg/tourney-tracker"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// sessionCookie is the name of the cookie holding the session token.
+	sessionCookie = "session"
+
+	// sessionLifetime is how long a User stays logged in for.
+	sessionLifetime = 7 * 24 * time.Hour
+)
+
+type contextKey string
+
+const (
+	userContextKey      = contextKey("user")
+	csrfTokenContextKey = contextKey("csrfToken")
+)
+
+// contextSetUser returns a copy of the request with the given User attached to its context.
+// Changes made with the request's context are recorded as made by the User.
+func contextSetUser(r *http.Request, user *tournament.User) *http.Request {
+	ctx := context.WithValue(r.Context(), userContextKey, user)
+	ctx = tournament.WithActor(ctx, &user.ID, user.Name)
+	return r.WithContext(ctx)
+}
+
+// contextGetUser returns the logged-in User of the request, or nil if the visitor is not logged in.
+func contextGetUser(r *http.Request) *tournament.User {
+	user, _ := r.Context().Value(userContextKey).(*tournament.User)
+	return user
+}
+
+func (s *Server) registerAuthRoutes() {
+	s.handle(http.MethodGet, "/login", tournament.RoleViewer, s.getLogin)
+	s.handle(http.MethodPost, "/login", tournament.RoleViewer, s.postLogin)
+	s.handle(http.MethodPost, "/logout", tournament.RoleViewer, s.postLogout)
+}
+
+// authenticate attaches the User of the API token or session cookie (if any) to the request context.
+// Requests without a valid session are still served, but will be rejected by requireRole.
+// Requests with an invalid API token are rejected immediately, since the client expected to be authenticated.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Responses differ depending on who is logged in, so they should not be cached across users.
+		w.Header().Add("Vary", "Authorization")
+		w.Header().Add("Vary", "Cookie")
+
+		if header := r.Header.Get("Authorization"); header != "" {
+			user, ok := s.authenticateToken(r.Context(), header)
+			if !ok {
+				message := "Invalid or revoked API token. Tokens must be sent as \"Authorization: Bearer <token>\"."
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				if isAPIRequest(r) {
+					JSONUnauthorizedResponse(w, message)
+				} else {
+					UnauthorizedResponse(w, message)
+				}
+				return
+			}
+
+			next.ServeHTTP(w, contextSetUser(r, user))
+			return
+		}
+
+		cookie, err := r.Cookie(sessionCookie)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		user, err := s.SessionService.GetSessionUser(r.Context(), cookie.Value)
+		if err != nil {
+			// Expired and unknown sessions are treated as logged out.
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		r = contextSetUser(r, &user)
+		r = contextSetCSRFToken(r, csrfToken(cookie.Value))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authenticateToken returns the User that the bearer token in the given Authorization header acts as.
+// The User has the Role of the token rather than their own, which the TokenService limits to their own. Their name notes which token was used.
+// The second return value is false if the header is malformed, or the token does not exist.
+func (s *Server) authenticateToken(ctx context.Context, header string) (*tournament.User, bool) {
+	scheme, plaintext, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || plaintext == "" {
+		return nil, false
+	}
+
+	token, err := s.TokenService.AuthenticateToken(ctx, plaintext)
+	if err != nil {
+		return nil, false
+	}
+
+	return &tournament.User{
+		ID:   token.UserID,
+		Name: fmt.Sprintf("%s (token: %s)", token.UserName, token.Label),
+		Role: token.Role,
+	}, true
+}
+
+// handle registers the handler for the given method and path, allowing only users with the given Role to use it.
+// Every route should be registered with handle, so that none are accidentally left without a permission check.
+func (s *Server) handle(method, path string, role tournament.Role, handler http.HandlerFunc) {
+	s.router.HandlerFunc(method, path, s.requireRole(role, withTimeout(&s.RequestTimeout, handler)))
+}
+
+// handleImport is like handle, but for routes that import a tournament from another site. These are given the longer ImportTimeout.
+func (s *Server) handleImport(method, path string, role tournament.Role, handler http.HandlerFunc) {
+	s.router.HandlerFunc(method, path, s.requireRole(role, withTimeout(&s.ImportTimeout, handler)))
+}
+
+// withTimeout cancels the context of each request once the given timeout has passed, or never if it is 0.
+// The timeout is read on each request, since routes are registered before the Server's timeouts are set.
+func withTimeout(timeout *time.Duration, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if *timeout <= 0 {
+			next(w, r)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), *timeout)
+		defer cancel()
+
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// requireRole rejects requests that are not made by a User with the given Role.
+// Routes requiring the RoleViewer can be used by anyone, including visitors who are not logged in.
+func (s *Server) requireRole(role tournament.Role, next http.HandlerFunc) http.HandlerFunc {
+	if role == tournament.RoleViewer {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := contextGetUser(r)
+
+		switch {
+		case user == nil && isAPIRequest(r):
+			JSONUnauthorizedResponse(w, "You must be logged in to do that.")
+		case user == nil:
+			UnauthorizedResponse(w, "You must be logged in to do that.")
+		case !user.Role.Includes(role) && isAPIRequest(r):
+			JSONForbiddenResponse(w, "You do not have permission to do that.")
+		case !user.Role.Includes(role):
+			ForbiddenResponse(w, "You do not have permission to do that.")
+		default:
+			next(w, r)
+		}
+	}
+}
+
+// getLogin renders the login form. Visitors who are not logged in are given a CSRF token for it, since they have no session to derive one from.
+func (s *Server) getLogin(w http.ResponseWriter, r *http.Request) {
+	if contextGetCSRFToken(r) == "" {
+		var err error
+		if r, err = s.setLoginCSRFToken(w, r); err != nil {
+			ServerErrorResponse(w, "Failed to create CSRF token.")
+			return
+		}
+	}
+
+	s.Render(w, r, 200, "users/login.go.html", "base", nil)
+}
+
+// postLogin accepts form data consisting of "name", "password", and "csrf_token" fields.
+// If the credentials are correct, a session cookie is set and a redirect to the homepage is returned.
+func (s *Server) postLogin(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		BadRequestResponse(w, "Failed to parse form.")
+		return
+	}
+
+	// Visitors who are already logged in have had their session's CSRF token checked by verifyCSRF.
+	if contextGetCSRFToken(r) == "" && !verifyLoginCSRF(r) {
+		ForbiddenResponse(w, csrfFailedMessage)
+		return
+	}
+
+	user, err := tournament.Authenticate(r.Context(), s.UserService, r.PostForm.Get("name"), r.PostForm.Get("password"))
+	if errors.Is(err, tournament.ErrInvalidCredentials) {
+		UnauthorizedResponse(w, "Invalid name or password.")
+		return
+	}
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	session, err := tournament.NewSession(user.ID, sessionLifetime)
+	if err != nil {
+		ServerErrorResponse(w, "Failed to create session.")
+		return
+	}
+
+	err = s.SessionService.CreateSession(r.Context(), session)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	// Logging in is rare enough that this is a convenient time to clean up old sessions.
+	if err = s.SessionService.DeleteExpiredSessions(r.Context()); err != nil {
+		log.Println("Failed to delete expired sessions:", err)
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    session.Token,
+		Path:     "/",
+		Expires:  session.Expiry,
+		HttpOnly: true,
+		Secure:   s.secure(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	// The login form's CSRF token is no longer needed once there is a session.
+	http.SetCookie(w, &http.Cookie{
+		Name:     loginCSRFCookie,
+		Value:    "",
+		Path:     "/login",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   s.secure(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	redirect(w, r, "/")
+}
+
+// postLogout deletes the current session, then returns a redirect to the homepage.
+func (s *Server) postLogout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(sessionCookie); err == nil {
+		err = s.SessionService.DeleteSession(r.Context(), cookie.Value)
+		if err != nil {
+			ServiceErrorResponse(w, r, err)
+			return
+		}
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   s.secure(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	redirect(w, r, "/")
+}
+
+// secure returns true if cookies set in response to the request should only be sent over HTTPS.
+func (s *Server) secure(r *http.Request) bool {
+	return r.TLS != nil || strings.HasPrefix(s.BaseURL, "https://")
+}
@@ -0,0 +1,105 @@
+package http
+
+import (
+	"context"
+	"errors"
+	tournament "github.com/ejacobg/tourney-tracker"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestServer_requireRole(t *testing.T) {
+	ok := func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		user     *tournament.User
+		role     tournament.Role
+		wantCode int
+	}{
+		{"public", "/players", nil, tournament.RoleViewer, http.StatusOK},
+		{"not logged in", "/players/new", nil, tournament.RoleEditor, http.StatusUnauthorized},
+		{"viewer", "/players/new", &tournament.User{Role: tournament.RoleViewer}, tournament.RoleEditor, http.StatusForbidden},
+		{"editor", "/players/new", &tournament.User{Role: tournament.RoleEditor}, tournament.RoleEditor, http.StatusOK},
+		{"admin", "/players/new", &tournament.User{Role: tournament.RoleAdmin}, tournament.RoleEditor, http.StatusOK},
+		{"organizer", "/tournaments/1/tier", &tournament.User{Role: tournament.RoleOrganizer}, tournament.RoleAdmin, http.StatusForbidden},
+		{"api", apiPrefix + "/players", nil, tournament.RoleEditor, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.user != nil {
+				r = contextSetUser(r, tt.user)
+			}
+			w := httptest.NewRecorder()
+
+			new(Server).requireRole(tt.role, ok)(w, r)
+
+			if w.Code != tt.wantCode {
+				t.Errorf("requireRole() status = %v, want %v", w.Code, tt.wantCode)
+			}
+			if isAPIRequest(r) && w.Header().Get("Content-Type") != "application/json" {
+				t.Errorf("requireRole() Content-Type = %v, want application/json", w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+// tokenService is a TokenService holding a single Token.
+type tokenService struct {
+	tournament.TokenService
+	plaintext string
+	token     tournament.Token
+}
+
+func (ts tokenService) AuthenticateToken(_ context.Context, plaintext string) (tournament.Token, error) {
+	if plaintext != ts.plaintext {
+		return tournament.Token{}, errors.New("record not found")
+	}
+	return ts.token, nil
+}
+
+func TestServer_authenticate(t *testing.T) {
+	srv := Server{Services: tournament.Services{TokenService: tokenService{
+		plaintext: "secret",
+		token:     tournament.Token{Label: "bot", Role: tournament.RoleEditor, UserID: 1, UserName: "admin"},
+	}}}
+
+	var got *tournament.User
+	handler := srv.authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = contextGetUser(r)
+	}))
+
+	tests := []struct {
+		name     string
+		header   string
+		wantCode int
+		wantRole tournament.Role
+	}{
+		{"valid token", "Bearer secret", http.StatusOK, tournament.RoleEditor},
+		{"lowercase scheme", "bearer secret", http.StatusOK, tournament.RoleEditor},
+		{"unknown token", "Bearer wrong", http.StatusUnauthorized, ""},
+		{"wrong scheme", "Basic secret", http.StatusUnauthorized, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = nil
+			r := httptest.NewRequest(http.MethodPost, apiPrefix+"/players", nil)
+			r.Header.Set("Authorization", tt.header)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantCode {
+				t.Errorf("authenticate() status = %v, want %v", w.Code, tt.wantCode)
+			}
+			if tt.wantRole != "" && (got == nil || got.Role != tt.wantRole || got.ID != 1) {
+				t.Errorf("authenticate() user = %+v, want ID 1 with role %v", got, tt.wantRole)
+			}
+		})
+	}
+}
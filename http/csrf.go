@@ -0,0 +1,123 @@
+package http
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	tournament "github.com/ejacobg/tourney-tracker"
+	"net/http"
+	"time"
+)
+
+// csrfHeader is the header that HTMX requests carry the CSRF token in. See the hx-headers attribute in base.go.html.
+// Plain form submissions may send the token in a csrfField form field instead.
+const (
+	csrfHeader = "X-CSRF-Token"
+	csrfField  = "csrf_token"
+)
+
+const (
+	// loginCSRFCookie is the name of the cookie that the login form's CSRF token is derived from, for visitors without a session.
+	loginCSRFCookie = "login_csrf"
+
+	// loginCSRFLifetime is how long the login form can be left open before it must be reloaded.
+	loginCSRFLifetime = 24 * time.Hour
+)
+
+// csrfFailedMessage is shown when a request is rejected for having the wrong CSRF token.
+const csrfFailedMessage = "Invalid CSRF token. Reload the page and try again."
+
+// csrfToken returns the CSRF token of the session with the given token.
+// The CSRF token is derived from the session token, so every session has its own without needing to store it.
+// Since the hash is one-way, a leaked CSRF token cannot be used to recover the session token.
+func csrfToken(sessionToken string) string {
+	hash := sha256.Sum256([]byte("csrf:" + sessionToken))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+// contextSetCSRFToken returns a copy of the request with the given CSRF token attached to its context.
+func contextSetCSRFToken(r *http.Request, token string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), csrfTokenContextKey, token))
+}
+
+// contextGetCSRFToken returns the CSRF token of the request's session, or an empty string if the request has no session.
+func contextGetCSRFToken(r *http.Request) string {
+	token, _ := r.Context().Value(csrfTokenContextKey).(string)
+	return token
+}
+
+// verifyCSRF rejects requests that make changes using a session cookie, but do not include the session's CSRF token.
+// Browsers send cookies with requests made by other sites, but those sites cannot read the token from our pages.
+// Requests using an API token are not checked, since browsers never send API tokens on their own.
+func (s *Server) verifyCSRF(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		expected := contextGetCSRFToken(r)
+
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if expected == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token := r.Header.Get(csrfHeader)
+		if token == "" {
+			token = r.PostFormValue(csrfField)
+		}
+
+		if subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+			if isAPIRequest(r) {
+				JSONForbiddenResponse(w, csrfFailedMessage)
+			} else {
+				ForbiddenResponse(w, csrfFailedMessage)
+			}
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// setLoginCSRFToken returns a copy of the request with a CSRF token for the login form attached, setting the cookie that it is derived from.
+// Visitors who are not logged in have no session to derive a token from, so the form and the cookie must instead carry matching values.
+// Other sites can make browsers send the cookie, but cannot read it to fill in the form. The cookie is reused, so that several open forms all work.
+func (s *Server) setLoginCSRFToken(w http.ResponseWriter, r *http.Request) (*http.Request, error) {
+	var secret string
+	if cookie, err := r.Cookie(loginCSRFCookie); err == nil && cookie.Value != "" {
+		secret = cookie.Value
+	} else if secret, err = tournament.NewToken(); err != nil {
+		return r, err
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     loginCSRFCookie,
+		Value:    secret,
+		Path:     "/login",
+		MaxAge:   int(loginCSRFLifetime.Seconds()),
+		HttpOnly: true,
+		Secure:   s.secure(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return contextSetCSRFToken(r, csrfToken(secret)), nil
+}
+
+// verifyLoginCSRF reports whether a login request includes the CSRF token derived from its login cookie. See setLoginCSRFToken.
+func verifyLoginCSRF(r *http.Request) bool {
+	cookie, err := r.Cookie(loginCSRFCookie)
+	if err != nil || cookie.Value == "" {
+		return false
+	}
+
+	token := r.Header.Get(csrfHeader)
+	if token == "" {
+		token = r.PostFormValue(csrfField)
+	}
+
+	return subtle.ConstantTimeCompare([]byte(token), []byte(csrfToken(cookie.Value))) == 1
+}
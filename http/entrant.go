@@ -1,16 +1,17 @@
 package http
 
 import (
-	"database/sql"
-	"fmt"
+	tournament "github.com/ejacobg/tourney-tracker"
+	"github.com/ejacobg/tourney-tracker/validator"
+	"golang.org/x/exp/slices"
 	"net/http"
 	"strconv"
 )
 
 func (s *Server) registerEntrantRoutes() {
-	s.router.HandlerFunc(http.MethodGet, "/entrants/:id/player", s.getEntrantPlayer)
-	s.router.HandlerFunc(http.MethodGet, "/entrants/:id/player/edit", s.getEntrantPlayerForm)
-	s.router.HandlerFunc(http.MethodPut, "/entrants/:id/player", s.putEntrantPlayer)
+	s.handle(http.MethodGet, "/entrants/:id/player", tournament.RoleViewer, s.getEntrantPlayer)
+	s.handle(http.MethodGet, "/entrants/:id/player/edit", tournament.RoleEditor, s.getEntrantPlayerForm)
+	s.handle(http.MethodPut, "/entrants/:id/player", tournament.RoleEditor, s.putEntrantPlayer)
 }
 
 // getEntrantPlayer returns a table row showing the Entrant's name, Player name, points earned, and their placement.
@@ -21,13 +22,13 @@ func (s *Server) getEntrantPlayer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	entrant, points, err := s.EntrantService.GetEntrantWithPoints(id)
+	entrant, points, err := s.EntrantService.GetEntrantWithPoints(r.Context(), id)
 	if err != nil {
-		ServerErrorResponse(w, fmt.Sprintf("Failed to get entrant and points: %s", err))
+		ServiceErrorResponse(w, r, err)
 		return
 	}
 
-	s.Render(w, 200, "entrants/view.go.html", "player", map[string]any{
+	s.Render(w, r, 200, "entrants/view.go.html", "player", map[string]any{
 		"Entrant": entrant,
 		"Points":  points,
 	})
@@ -41,27 +42,63 @@ func (s *Server) getEntrantPlayerForm(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	entrant, points, err := s.EntrantService.GetEntrantWithPoints(id)
+	s.renderEntrantPlayerForm(w, r, http.StatusOK, id, nil)
+}
+
+// renderEntrantPlayerForm renders the form for selecting the players of the given Entrant, alongside any errors found in a previous submission.
+func (s *Server) renderEntrantPlayerForm(w http.ResponseWriter, r *http.Request, status int, id int64, errors map[string]string) {
+	entrant, points, err := s.EntrantService.GetEntrantWithPoints(r.Context(), id)
 	if err != nil {
-		ServerErrorResponse(w, fmt.Sprintf("Failed to get entrant and points: %s", err))
+		ServiceErrorResponse(w, r, err)
 		return
 	}
 
-	players, err := s.PlayerService.GetPlayers()
+	tourney, err := s.TournamentService.GetTournament(r.Context(), entrant.TournamentID)
 	if err != nil {
-		ServerErrorResponse(w, fmt.Sprintf("Failed to get players: %s", err))
+		ServiceErrorResponse(w, r, err)
 		return
 	}
 
-	s.Render(w, 200, "entrants/edit.go.html", "player", map[string]any{
+	players, err := s.PlayerService.GetPlayers(r.Context(), 0)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	s.Render(w, r, status, "entrants/edit.go.html", "player", map[string]any{
 		"Entrant": entrant,
 		"Points":  points,
 		"Players": players,
+		"Slots":   playerSlots(tourney, entrant),
+		"Errors":  errors,
 	})
 }
 
-// putEntrantPlayer accepts form data consisting of a "player" field containing the value of the new Player ID.
-// The new Player will then be applied to the Entrant, and an updated table row element will be returned.
+// playerSlots returns the IDs of the Entrant's current players, padded with zeroes to the number of players the Entrant may have.
+// Singles entrants have 1 slot. Team entrants have a slot for each participant, with a minimum of 2.
+func playerSlots(tourney tournament.Tournament, entrant tournament.Entrant) []int64 {
+	size := 1
+	if tourney.Teams {
+		size = 2
+		if len(entrant.Participants) > size {
+			size = len(entrant.Participants)
+		}
+	}
+	if len(entrant.Players) > size {
+		size = len(entrant.Players)
+	}
+
+	slots := make([]int64, size)
+	for i, player := range entrant.Players {
+		slots[i] = player.ID
+	}
+	return slots
+}
+
+// putEntrantPlayer accepts form data consisting of "player" fields containing the IDs of the new players,
+// and a "version" field containing the Version of the Entrant that the form was rendered with.
+// The new players will then be applied to the Entrant, and an updated table row element will be returned.
+// If the Entrant has been changed since the form was rendered, nothing is changed and a conflict is returned instead.
 func (s *Server) putEntrantPlayer(w http.ResponseWriter, r *http.Request) {
 	// Get Entrant ID.
 	entrantID, err := readIDParam(r)
@@ -70,37 +107,51 @@ func (s *Server) putEntrantPlayer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get Player ID.
+	// Get Player IDs.
 	err = r.ParseForm()
 	if err != nil {
 		BadRequestResponse(w, "Failed to parse form.")
 		return
 	}
 
-	var playerID sql.NullInt64
-	playerID.Int64, err = strconv.ParseInt(r.PostForm.Get("player"), 10, 64)
-	playerID.Valid = true
+	var playerIDs []int64
+	for _, value := range r.PostForm["player"] {
+		// If a "player" field could not be parsed, then it will be treated as an empty slot.
+		playerID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || slices.Contains(playerIDs, playerID) {
+			continue
+		}
+		playerIDs = append(playerIDs, playerID)
+	}
 
-	// If the "player" field could not be parsed, then it will be treated as a null value.
-	if err != nil {
-		playerID.Valid = false
+	// A missing version skips the check, rather than rejecting the change.
+	version, _ := strconv.Atoi(r.PostForm.Get("version"))
+
+	v := validator.New()
+	if err = tournament.ValidatePlayerIDs(r.Context(), v, s.PlayerService, "player", playerIDs); err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+	if !v.Valid() {
+		s.renderEntrantPlayerForm(w, r, http.StatusUnprocessableEntity, entrantID, v.Errors)
+		return
 	}
 
-	// Apply new Player to Entrant.
-	err = s.EntrantService.SetPlayer(entrantID, playerID)
+	// Apply new players to Entrant.
+	err = s.EntrantService.SetPlayers(r.Context(), entrantID, version, playerIDs)
 	if err != nil {
-		ServerErrorResponse(w, fmt.Sprintf("Failed to update player: %s", err))
+		ServiceErrorResponse(w, r, err)
 		return
 	}
 
 	// Render updated row.
-	entrant, points, err := s.EntrantService.GetEntrantWithPoints(entrantID)
+	entrant, points, err := s.EntrantService.GetEntrantWithPoints(r.Context(), entrantID)
 	if err != nil {
-		ServerErrorResponse(w, fmt.Sprintf("Failed to get entrant and points: %s", err))
+		ServiceErrorResponse(w, r, err)
 		return
 	}
 
-	s.Render(w, 200, "entrants/view.go.html", "player", map[string]any{
+	s.Render(w, r, 200, "entrants/view.go.html", "player", map[string]any{
 		"Entrant": entrant,
 		"Points":  points,
 	})
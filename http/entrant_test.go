@@ -0,0 +1,85 @@
+package http
+
+import (
+	"context"
+	tournament "github.com/ejacobg/tourney-tracker"
+	"github.com/ejacobg/tourney-tracker/inmem"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// newInmemServer returns a Server backed by an empty in-memory database, along with an admin to make requests as.
+func newInmemServer(t *testing.T) (*Server, *tournament.User) {
+	t.Helper()
+
+	srv := NewServer("", "", "")
+	srv.Services = inmem.NewServices(inmem.NewDB())
+
+	admin := &tournament.User{Name: "admin", Role: tournament.RoleAdmin}
+	if err := srv.UserService.CreateUser(context.Background(), admin); err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+
+	return srv, admin
+}
+
+func TestServer_apiPutEntrantPlayers(t *testing.T) {
+	ctx := context.Background()
+
+	srv, admin := newInmemServer(t)
+
+	tourney := tournament.Tournament{Name: "Weekly", BracketType: tournament.DoubleElimination, Placements: []int64{2, 1}}
+	entrants := []tournament.Entrant{{Name: "A", Placement: 1}, {Name: "B", Placement: 2}}
+	if err := srv.TournamentService.CreateTournament(ctx, &tourney, entrants); err != nil {
+		t.Fatalf("CreateTournament() error = %v", err)
+	}
+
+	player := tournament.Player{Name: "Mango"}
+	if err := srv.PlayerService.CreatePlayer(ctx, &player); err != nil {
+		t.Fatalf("CreatePlayer() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		entrant  int64
+		body     string
+		wantCode int
+		wantBody string
+	}{
+		{"link", entrants[0].ID, `{"playerIDs": [1], "version": 1}`, http.StatusOK, `"name": "Mango"`},
+		{"stale version", entrants[0].ID, `{"playerIDs": [], "version": 1}`, http.StatusConflict, "changed by someone else"},
+		{"another entrant", entrants[1].ID, `{"playerIDs": [1]}`, http.StatusConflict, "already linked to another entrant"},
+		{"unknown player", entrants[1].ID, `{"playerIDs": [2]}`, http.StatusUnprocessableEntity, "Player 2 does not exist."},
+		{"unknown entrant", 99, `{"playerIDs": [1]}`, http.StatusNotFound, "Entrant not found."},
+		{"unlink", entrants[0].ID, `{"playerIDs": [], "version": 2}`, http.StatusOK, `"players": null`},
+		{"link after unlink", entrants[1].ID, `{"playerIDs": [1]}`, http.StatusOK, `"name": "Mango"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPut, apiPrefix+"/entrants/"+strconv.FormatInt(tt.entrant, 10)+"/players", strings.NewReader(tt.body))
+			r = contextSetUser(r, admin)
+			w := httptest.NewRecorder()
+
+			srv.router.ServeHTTP(w, r)
+
+			if w.Code != tt.wantCode {
+				t.Errorf("status = %v, want %v: %s", w.Code, tt.wantCode, w.Body)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want it to contain %q", w.Body, tt.wantBody)
+			}
+		})
+	}
+
+	// Every successful change is recorded.
+	entries, err := srv.AuditService.GetEntries(ctx, tournament.EntryFilter{Action: tournament.ActionSetPlayers})
+	if err != nil {
+		t.Fatalf("GetEntries() error = %v", err)
+	}
+	if len(entries) != 3 {
+		t.Errorf("GetEntries() returned %d entries, want 3", len(entries))
+	}
+}
@@ -1,6 +1,7 @@
 package http
 
 import (
+	tournament "github.com/ejacobg/tourney-tracker"
 	"log"
 	"net/http"
 )
@@ -27,6 +28,14 @@ func UnprocessableEntityResponse(w http.ResponseWriter, error string) {
 	ErrorResponse(w, error, http.StatusUnprocessableEntity)
 }
 
+func UnauthorizedResponse(w http.ResponseWriter, error string) {
+	ErrorResponse(w, error, http.StatusUnauthorized)
+}
+
+func ForbiddenResponse(w http.ResponseWriter, error string) {
+	ErrorResponse(w, error, http.StatusForbidden)
+}
+
 func NotFoundResponse(w http.ResponseWriter, error string) {
 	ErrorResponse(w, error, http.StatusNotFound)
 }
@@ -34,3 +43,101 @@ func NotFoundResponse(w http.ResponseWriter, error string) {
 func MethodNotAllowedResponse(w http.ResponseWriter, error string) {
 	ErrorResponse(w, error, http.StatusMethodNotAllowed)
 }
+
+func ConflictResponse(w http.ResponseWriter, error string) {
+	ErrorResponse(w, error, http.StatusConflict)
+}
+
+// JSONErrorResponse is the API counterpart of ErrorResponse. The error is logged, then written as a JSON object of the form:
+//
+//	{"error": {"status": 404, "message": "Player not found."}}
+func JSONErrorResponse(w http.ResponseWriter, error string, code int) {
+	log.Println(error)
+
+	writeJSON(w, code, envelope{"error": map[string]any{
+		"status":  code,
+		"message": error,
+	}})
+}
+
+func JSONServerErrorResponse(w http.ResponseWriter, error string) {
+	JSONErrorResponse(w, error, http.StatusInternalServerError)
+}
+
+func JSONBadRequestResponse(w http.ResponseWriter, error string) {
+	JSONErrorResponse(w, error, http.StatusBadRequest)
+}
+
+func JSONUnprocessableEntityResponse(w http.ResponseWriter, error string) {
+	JSONErrorResponse(w, error, http.StatusUnprocessableEntity)
+}
+
+func JSONUnauthorizedResponse(w http.ResponseWriter, error string) {
+	JSONErrorResponse(w, error, http.StatusUnauthorized)
+}
+
+func JSONForbiddenResponse(w http.ResponseWriter, error string) {
+	JSONErrorResponse(w, error, http.StatusForbidden)
+}
+
+func JSONNotFoundResponse(w http.ResponseWriter, error string) {
+	JSONErrorResponse(w, error, http.StatusNotFound)
+}
+
+func JSONMethodNotAllowedResponse(w http.ResponseWriter, error string) {
+	JSONErrorResponse(w, error, http.StatusMethodNotAllowed)
+}
+
+func JSONConflictResponse(w http.ResponseWriter, error string) {
+	JSONErrorResponse(w, error, http.StatusConflict)
+}
+
+// JSONFailedValidationResponse responds with the errors found by a validator.Validator, keyed by the name of each invalid field:
+//
+//	{"error": {"status": 422, "message": "Invalid input.", "fields": {"name": "Name must not be empty."}}}
+func JSONFailedValidationResponse(w http.ResponseWriter, errors map[string]string) {
+	log.Println("Invalid input:", errors)
+
+	writeJSON(w, http.StatusUnprocessableEntity, envelope{"error": map[string]any{
+		"status":  http.StatusUnprocessableEntity,
+		"message": "Invalid input.",
+		"fields":  errors,
+	}})
+}
+
+// errorStatuses maps the codes of domain errors to response codes.
+var errorStatuses = map[string]int{
+	tournament.ECONFLICT: http.StatusConflict,
+	tournament.EINTERNAL: http.StatusInternalServerError,
+	tournament.EINVALID:  http.StatusUnprocessableEntity,
+	tournament.ENOTFOUND: http.StatusNotFound,
+	tournament.EUPSTREAM: http.StatusBadGateway,
+}
+
+// ServiceErrorResponse responds with the message of an error returned by a service, using the response code matching its error code.
+// The details of internal errors are logged, but are replaced with a generic message in the response.
+// API requests receive a JSON response, and pages are told to reload after a conflict.
+// Requests that ran out of time, or whose client went away, are answered with a 503 instead, since the work was abandoned rather than failed.
+func ServiceErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	code := tournament.ErrorCode(err)
+	status := errorStatuses[code]
+	message := tournament.ErrorMessage(err)
+
+	// Drivers do not all return the context's error when a query is cancelled, so the context itself is checked.
+	if ctxErr := r.Context().Err(); ctxErr != nil {
+		log.Printf("%s %s: %s: %s", r.Method, r.URL.Path, ctxErr, err)
+		code, status, message = "", http.StatusServiceUnavailable, "The request took too long. Try again later."
+	} else if code == tournament.EINTERNAL {
+		log.Printf("%s %s: %s", r.Method, r.URL.Path, err)
+	}
+
+	if isAPIRequest(r) {
+		JSONErrorResponse(w, message, status)
+		return
+	}
+
+	if code == tournament.ECONFLICT {
+		message += " Reload the page and try again."
+	}
+	ErrorResponse(w, message, status)
+}
@@ -0,0 +1,98 @@
+package http
+
+import (
+	"context"
+	"errors"
+	tournament "github.com/ejacobg/tourney-tracker"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServiceErrorResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		err      error
+		wantCode int
+		wantBody string
+	}{
+		{"not found", "/players/1", tournament.Errorf(tournament.ENOTFOUND, "Player not found."), http.StatusNotFound, "Player not found."},
+		{"conflict", "/players/1", tournament.Errorf(tournament.ECONFLICT, "Another player already has that name."), http.StatusConflict, "Another player already has that name. Reload the page and try again."},
+		{"invalid", "/tournaments/1/tier", tournament.Errorf(tournament.EINVALID, "That tier does not exist."), http.StatusUnprocessableEntity, "That tier does not exist."},
+		{"upstream", "/tournaments/new", tournament.Errorf(tournament.EUPSTREAM, "Failed to import tournament."), http.StatusBadGateway, "Failed to import tournament."},
+		{"internal", "/players/1", errors.New("connection refused"), http.StatusInternalServerError, "Something went wrong. Please try again later."},
+		{"internal detail", "/api/v1/entrants/1", tournament.Errorf(tournament.EINTERNAL, "Entrant 1 has placement 3."), http.StatusInternalServerError, `"message": "Something went wrong. Please try again later."`},
+		{"api conflict", "/api/v1/players/1", tournament.Errorf(tournament.ECONFLICT, "Another player already has that name."), http.StatusConflict, `"message": "Another player already has that name."`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			ServiceErrorResponse(w, httptest.NewRequest(http.MethodPut, tt.path, nil), tt.err)
+
+			if w.Code != tt.wantCode {
+				t.Errorf("ServiceErrorResponse() code = %d, want %d", w.Code, tt.wantCode)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("ServiceErrorResponse() body = %s, want it to contain %s", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+// slowTierService is a TierService whose GetTiers only returns once its context is done.
+type slowTierService struct {
+	tournament.TierService
+}
+
+func (slowTierService) GetTiers(ctx context.Context) ([]tournament.Tier, error) {
+	<-ctx.Done()
+	return nil, errors.New("pq: canceling statement due to user request")
+}
+
+func TestServer_RequestTimeout(t *testing.T) {
+	srv := NewServer("", "", "")
+	srv.TierService = slowTierService{}
+	srv.RequestTimeout = 10 * time.Millisecond
+
+	w := httptest.NewRecorder()
+	srv.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, apiPrefix+"/tiers", nil))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d: %s", w.Code, http.StatusServiceUnavailable, w.Body)
+	}
+	if !strings.Contains(w.Body.String(), "took too long") {
+		t.Errorf("body = %s, want it to say the request took too long", w.Body)
+	}
+}
+
+func TestServer_Render(t *testing.T) {
+	srv := NewServer("", "", "")
+	srv.Templates = map[string]*template.Template{
+		"broken.go.html": template.Must(template.New("broken.go.html").Funcs(Functions).Parse(`{{define "base"}}{{.Missing.Field}}{{end}}`)),
+	}
+
+	tests := []struct {
+		name string
+		tmpl string
+	}{
+		{"missing template", "missing.go.html"},
+		{"failed execution", "broken.go.html"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			srv.Render(w, httptest.NewRequest(http.MethodGet, "/", nil), http.StatusOK, tt.tmpl, "base", 1)
+
+			if w.Code != http.StatusInternalServerError {
+				t.Errorf("Render() code = %d, want %d", w.Code, http.StatusInternalServerError)
+			}
+			if body := strings.TrimSpace(w.Body.String()); body != "Something went wrong. Please try again later." {
+				t.Errorf("Render() body = %q, want the generic message", body)
+			}
+		})
+	}
+}
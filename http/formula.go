@@ -1,11 +1,76 @@
 package http
 
-import "net/http"
+import (
+	"fmt"
+	tournament "github.com/ejacobg/tourney-tracker"
+	"github.com/ejacobg/tourney-tracker/validator"
+	"net/http"
+	"strconv"
+)
 
 func (s *Server) registerFormulaRoutes() {
-	s.router.HandlerFunc(http.MethodGet, "/about", s.getFormula)
+	s.handle(http.MethodGet, "/about", tournament.RoleViewer, s.getFormula)
+	s.handle(http.MethodPut, "/formula", tournament.RoleAdmin, s.putFormula)
 }
 
-func (s *Server) getFormula(w http.ResponseWriter, _ *http.Request) {
-	s.Render(w, 200, "about.go.html", "base", nil)
+// getFormula renders a page explaining the point formula, using its current variables.
+func (s *Server) getFormula(w http.ResponseWriter, r *http.Request) {
+	formula, err := s.FormulaService.GetFormula(r.Context())
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	s.Render(w, r, 200, "about.go.html", "base", map[string]any{"Formula": formula})
+}
+
+// putFormula accepts form data consisting of "up", "att", "first", and "br" fields holding the new variables of the point formula.
+// The new Formula is applied to every tournament, and a refresh of the page is returned.
+// If any variable is invalid, the form is rendered again with the errors.
+func (s *Server) putFormula(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		BadRequestResponse(w, "Failed to parse form.")
+		return
+	}
+
+	v := validator.New()
+
+	// Variables that are not whole numbers are reported as errors, rather than rejecting the whole form.
+	readVariable := func(key, name string) int {
+		value, err := strconv.Atoi(r.PostForm.Get(key))
+		v.Check(err == nil, key, fmt.Sprintf("%s must be a whole number.", name))
+		return value
+	}
+	formula := tournament.Formula{
+		UP:    readVariable("up", "UP"),
+		ATT:   readVariable("att", "ATT"),
+		FIRST: readVariable("first", "FIRST"),
+		BR:    readVariable("br", "BR"),
+	}
+
+	if tournament.ValidateFormula(v, formula); !v.Valid() {
+		s.Render(w, r, http.StatusUnprocessableEntity, "about.go.html", "formula", map[string]any{
+			"Formula": formula,
+			"Errors":  v.Errors,
+		})
+		return
+	}
+
+	// The Formula changes the points of every player, so the current data is saved first.
+	err = s.takeSnapshot(r, "Before changing the point formula")
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	err = s.FormulaService.UpdateFormula(r.Context(), formula)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	// Refresh is needed to update the values shown in the rest of the page.
+	w.Header()["HX-Refresh"] = []string{"true"}
+	w.WriteHeader(http.StatusOK)
 }
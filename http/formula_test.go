@@ -0,0 +1,70 @@
+package http
+
+import (
+	"context"
+	tournament "github.com/ejacobg/tourney-tracker"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestServer_putFormula(t *testing.T) {
+	srv, admin := newInmemServer(t)
+
+	// The real templates are loaded by the main package, so only the errors of the form are rendered here.
+	srv.Templates = map[string]*template.Template{
+		"about.go.html": template.Must(template.New("").Parse(`{{define "formula"}}{{range .Errors}}{{.}}{{end}}{{end}}`)),
+	}
+
+	tests := []struct {
+		name     string
+		form     url.Values
+		wantCode int
+		wantBody string
+	}{
+		{"negative", url.Values{"up": {"-1"}, "att": {"10"}, "first": {"10"}, "br": {"5"}}, http.StatusUnprocessableEntity, "UP must not be negative."},
+		{"not a number", url.Values{"up": {"5"}, "att": {"ten"}, "first": {"10"}, "br": {"5"}}, http.StatusUnprocessableEntity, "ATT must be a whole number."},
+		{"too large", url.Values{"up": {"5"}, "att": {"10"}, "first": {"1001"}, "br": {"5"}}, http.StatusUnprocessableEntity, "FIRST must not be greater than 1000."},
+		{"valid", url.Values{"up": {"1"}, "att": {"2"}, "first": {"3"}, "br": {"4"}}, http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPut, "/formula", strings.NewReader(tt.form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			r = contextSetUser(r, admin)
+			w := httptest.NewRecorder()
+
+			srv.router.ServeHTTP(w, r)
+
+			if w.Code != tt.wantCode {
+				t.Errorf("status = %v, want %v: %s", w.Code, tt.wantCode, w.Body)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want it to contain %q", w.Body, tt.wantBody)
+			}
+		})
+	}
+
+	// Only the valid change is saved and recorded.
+	formula, err := srv.FormulaService.GetFormula(context.Background())
+	if want := (tournament.Formula{UP: 1, ATT: 2, FIRST: 3, BR: 4}); err != nil || formula != want {
+		t.Errorf("GetFormula() = %+v, %v, want %+v", formula, err, want)
+	}
+
+	// The formula from before the change can be restored.
+	snapshots, err := srv.SnapshotService.GetSnapshots(context.Background())
+	if err != nil || len(snapshots) != 1 || !snapshots[0].Automatic {
+		t.Errorf("GetSnapshots() = %+v, %v, want one automatic snapshot", snapshots, err)
+	}
+
+	entries, err := srv.AuditService.GetEntries(context.Background(), tournament.EntryFilter{Action: tournament.ActionUpdateFormula})
+	if err != nil {
+		t.Fatalf("GetEntries() error = %v", err)
+	}
+	if len(entries) != 1 || string(entries[0].Before) != `{"up":5,"att":10,"first":10,"br":5}` {
+		t.Errorf("GetEntries() = %+v, want one entry changing the default formula", entries)
+	}
+}
@@ -2,26 +2,71 @@ package http
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
+	tournament "github.com/ejacobg/tourney-tracker"
 	"github.com/julienschmidt/httprouter"
+	"html/template"
+	"io"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
 )
 
+// Functions holds the template functions that depend on the current request. They must be added to every template before it is parsed.
+// The functions defined here are placeholders, which Render replaces with request-specific versions.
+var Functions = template.FuncMap{
+	// user returns the logged-in User, or nil if the visitor is not logged in.
+	"user": func() *tournament.User { return nil },
+
+	// can returns true if the logged-in User has the given Role, eg. {{if can "editor"}}.
+	"can": func(role string) bool { return false },
+
+	// csrfToken returns the CSRF token of the current session, or an empty string if the visitor is not logged in.
+	"csrfToken": func() string { return "" },
+}
+
 // Render will execute the "name" template of "tmpl", then write it to the response with the given status code.
-func (s *Server) Render(w http.ResponseWriter, status int, tmpl, name string, data any) {
-	t, ok := s.Templates[tmpl]
+// Template failures are internal errors, so their details are logged rather than shown.
+func (s *Server) Render(w http.ResponseWriter, r *http.Request, status int, tmpl, name string, data any) {
+	templates := s.Templates
+	if s.LoadTemplates != nil {
+		var err error
+		if templates, err = s.LoadTemplates(); err != nil {
+			ServiceErrorResponse(w, r, fmt.Errorf("failed to load templates: %w", err))
+			return
+		}
+	}
+
+	t, ok := templates[tmpl]
 	if !ok {
-		ServerErrorResponse(w, fmt.Sprintf("The template %q does not exist.", tmpl))
+		ServiceErrorResponse(w, r, fmt.Errorf("the template %q does not exist", tmpl))
+		return
+	}
+
+	// Templates are shared between requests, so the request-specific functions must be bound to a copy.
+	t, err := t.Clone()
+	if err != nil {
+		ServiceErrorResponse(w, r, fmt.Errorf("failed to clone template: %w", err))
 		return
 	}
 
+	user, csrf := contextGetUser(r), contextGetCSRFToken(r)
+	t.Funcs(template.FuncMap{
+		"user": func() *tournament.User { return user },
+		"can": func(role string) bool {
+			return user != nil && user.Role.Includes(tournament.Role(role))
+		},
+		"csrfToken": func() string { return csrf },
+	})
+
 	buf := new(bytes.Buffer)
 
-	err := t.ExecuteTemplate(buf, name, data)
+	err = t.ExecuteTemplate(buf, name, data)
 	if err != nil {
-		ServerErrorResponse(w, fmt.Sprintf("Failed to render template: %s", err))
+		ServiceErrorResponse(w, r, fmt.Errorf("failed to render template: %w", err))
 		return
 	}
 
@@ -29,6 +74,18 @@ func (s *Server) Render(w http.ResponseWriter, status int, tmpl, name string, da
 	buf.WriteTo(w)
 }
 
+// redirect sends the browser to the given URL. HTMX requests follow redirects without changing the page,
+// so they are given an HX-Redirect header instead of a 303 response.
+func redirect(w http.ResponseWriter, r *http.Request, url string) {
+	if r.Header.Get("HX-Request") == "true" {
+		w.Header()["HX-Redirect"] = []string{url}
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	http.Redirect(w, r, url, http.StatusSeeOther)
+}
+
 // readIDParam returns the value of the :id route parameter, or an error if it could not be read.
 func readIDParam(r *http.Request) (int64, error) {
 	params := httprouter.ParamsFromContext(r.Context())
@@ -40,3 +97,77 @@ func readIDParam(r *http.Request) (int64, error) {
 
 	return id, nil
 }
+
+// readGameQuery returns the value of the "game" query parameter, or 0 if it is missing or invalid.
+// A value of 0 means that every game should be shown.
+func readGameQuery(r *http.Request) int64 {
+	id, err := strconv.ParseInt(r.URL.Query().Get("game"), 10, 64)
+	if err != nil || id < 1 {
+		return 0
+	}
+
+	return id
+}
+
+// envelope wraps every JSON response in a top-level object, so that fields can be added to responses without breaking clients.
+type envelope map[string]any
+
+// writeJSON encodes the given data as JSON, then writes it to the response with the given status code.
+func writeJSON(w http.ResponseWriter, status int, data any) {
+	js, err := json.MarshalIndent(data, "", "\t")
+	if err != nil {
+		// Writing the error itself as JSON could fail again, so fall back to a plain response.
+		log.Println("Failed to encode response:", err)
+		http.Error(w, tournament.ErrorMessage(err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(append(js, '\n'))
+}
+
+// readJSON decodes a single JSON object from the request body into dst.
+// Unknown fields and bodies over 1MB are rejected. The returned errors are suitable for showing to API clients.
+func readJSON(w http.ResponseWriter, r *http.Request, dst any) error {
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	err := dec.Decode(dst)
+	if err != nil {
+		var (
+			syntaxError   *json.SyntaxError
+			typeError     *json.UnmarshalTypeError
+			maxBytesError *http.MaxBytesError
+		)
+
+		switch {
+		case errors.As(err, &syntaxError):
+			return fmt.Errorf("body contains badly-formed JSON (at character %d)", syntaxError.Offset)
+		case errors.Is(err, io.ErrUnexpectedEOF):
+			return errors.New("body contains badly-formed JSON")
+		case errors.As(err, &typeError):
+			if typeError.Field != "" {
+				return fmt.Errorf("body contains incorrect JSON type for field %q", typeError.Field)
+			}
+			return fmt.Errorf("body contains incorrect JSON type (at character %d)", typeError.Offset)
+		case errors.Is(err, io.EOF):
+			return errors.New("body must not be empty")
+		case strings.HasPrefix(err.Error(), "json: unknown field "):
+			return fmt.Errorf("body contains unknown field %s", strings.TrimPrefix(err.Error(), "json: unknown field "))
+		case errors.As(err, &maxBytesError):
+			return fmt.Errorf("body must not be larger than %d bytes", maxBytesError.Limit)
+		default:
+			return err
+		}
+	}
+
+	// A second call to Decode should hit the end of the body.
+	if err = dec.Decode(&struct{}{}); err != io.EOF {
+		return errors.New("body must only contain a single JSON value")
+	}
+
+	return nil
+}
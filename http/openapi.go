@@ -0,0 +1,309 @@
+package http
+
+import (
+	"fmt"
+	tournament "github.com/ejacobg/tourney-tracker"
+	"golang.org/x/exp/slices"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// apiRoute describes a single API route. Routes are registered and documented from the same description, see apiRoutes().
+type apiRoute struct {
+	// Method and Path are passed to the router. Path is relative to apiPrefix, and uses the router's :param syntax.
+	Method, Path string
+
+	// Role is the Role needed to use the route.
+	Role tournament.Role
+
+	// Tag groups related routes together in the documentation.
+	Tag string
+
+	Summary string
+
+	// Params and Query describe the path and query parameters of the route. Path parameters are always integer IDs.
+	Params, Query []apiParam
+
+	// Request is a zero value of the JSON body accepted by the route, or nil if the route does not accept a body.
+	Request any
+
+	// Status is the status code of a successful response, or 200 if unset.
+	Status int
+
+	// Response holds zero values of each field in the response envelope. It is ignored if Status is 204.
+	Response envelope
+
+	// Import is true if the route imports a tournament from another site, which gives it the Server's ImportTimeout.
+	Import bool
+
+	Handler http.HandlerFunc
+}
+
+// Protected returns true if the route can only be used by a logged-in User.
+func (route apiRoute) Protected() bool {
+	return route.Role != tournament.RoleViewer
+}
+
+// apiParam describes a path or query parameter.
+type apiParam struct {
+	Name        string
+	Description string
+}
+
+// queryTypes holds the OpenAPI type of each query parameter. Parameters not listed here are strings.
+var queryTypes = map[string]string{
+	"game": "integer",
+}
+
+// openAPIDocument builds an OpenAPI 3 document describing the given routes.
+func openAPIDocument(routes []apiRoute) map[string]any {
+	schemas := map[string]any{
+		"Error": map[string]any{
+			"type":     "object",
+			"required": []string{"error"},
+			"properties": map[string]any{
+				"error": map[string]any{
+					"type":     "object",
+					"required": []string{"status", "message"},
+					"properties": map[string]any{
+						"status":  map[string]any{"type": "integer"},
+						"message": map[string]any{"type": "string"},
+						"fields": map[string]any{
+							"type":                 "object",
+							"description":          "Included with 422 responses. Maps the name of each invalid field to its error message.",
+							"additionalProperties": map[string]any{"type": "string"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	paths := make(map[string]any)
+	for _, route := range routes {
+		path, operations := openAPIPath(route.Path), map[string]any{}
+		if existing, ok := paths[path]; ok {
+			operations = existing.(map[string]any)
+		}
+		operations[strings.ToLower(route.Method)] = openAPIOperation(route, schemas)
+		paths[path] = operations
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":       "Tourney Tracker API",
+			"version":     strings.TrimPrefix(apiPrefix, "/api/"),
+			"description": "Rankings, players, tournaments, tiers, and entrants tracked by Tourney Tracker.",
+		},
+		"servers": []any{map[string]any{"url": apiPrefix}},
+		"paths":   paths,
+		"components": map[string]any{
+			"schemas": schemas,
+			"securitySchemes": map[string]any{
+				"session": map[string]any{
+					"type":        "apiKey",
+					"in":          "cookie",
+					"name":        sessionCookie,
+					"description": "The session cookie set by logging in at /login. Requests that make changes must also send the session's CSRF token in the X-CSRF-Token header.",
+				},
+				"token": map[string]any{
+					"type":        "http",
+					"scheme":      "bearer",
+					"description": "An API token created by an admin at /tokens. A token can only use routes allowed by its role.",
+				},
+			},
+		},
+	}
+}
+
+// openAPIPath converts a router path into an OpenAPI path, eg. /players/:id becomes /players/{id}.
+func openAPIPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// operationID derives a unique name for a route from its method and path, eg. GET /players/:id becomes getPlayersByID.
+func operationID(route apiRoute) string {
+	id := strings.ToLower(route.Method)
+	for _, segment := range strings.Split(route.Path, "/") {
+		switch {
+		case segment == "":
+		case strings.HasPrefix(segment, ":"):
+			id += "By" + strings.ToUpper(segment[1:])
+		default:
+			id += strings.ToUpper(segment[:1]) + segment[1:]
+		}
+	}
+	return id
+}
+
+func openAPIOperation(route apiRoute, schemas map[string]any) map[string]any {
+	operation := map[string]any{
+		"operationId": operationID(route),
+		"summary":     route.Summary,
+		"tags":        []string{route.Tag},
+	}
+
+	if route.Protected() {
+		operation["security"] = []any{
+			map[string]any{"token": []string{}},
+			map[string]any{"session": []string{}},
+		}
+		operation["description"] = fmt.Sprintf("Requires the %s role.", route.Role)
+	}
+
+	var params []any
+	for _, param := range route.Params {
+		params = append(params, map[string]any{
+			"name":        param.Name,
+			"in":          "path",
+			"required":    true,
+			"description": param.Description,
+			"schema":      map[string]any{"type": "integer", "format": "int64"},
+		})
+	}
+	for _, param := range route.Query {
+		typ, ok := queryTypes[param.Name]
+		if !ok {
+			typ = "string"
+		}
+		params = append(params, map[string]any{
+			"name":        param.Name,
+			"in":          "query",
+			"description": param.Description,
+			"schema":      map[string]any{"type": typ},
+		})
+	}
+	if params != nil {
+		operation["parameters"] = params
+	}
+
+	if route.Request != nil {
+		operation["requestBody"] = map[string]any{
+			"required": true,
+			"content": map[string]any{
+				"application/json": map[string]any{"schema": schemaOf(reflect.TypeOf(route.Request), schemas)},
+			},
+		}
+	}
+
+	status := route.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	success := map[string]any{"description": http.StatusText(status)}
+	if status != http.StatusNoContent {
+		properties := make(map[string]any)
+		var required []string
+		for name, value := range route.Response {
+			properties[name] = schemaOf(reflect.TypeOf(value), schemas)
+			required = append(required, name)
+		}
+		slices.Sort(required)
+		success["content"] = map[string]any{
+			"application/json": map[string]any{"schema": map[string]any{
+				"type":       "object",
+				"required":   required,
+				"properties": properties,
+			}},
+		}
+	}
+
+	operation["responses"] = map[string]any{
+		fmt.Sprint(status): success,
+		"default": map[string]any{
+			"description": "An error occurred.",
+			"content": map[string]any{
+				"application/json": map[string]any{"schema": map[string]any{"$ref": "#/components/schemas/Error"}},
+			},
+		},
+	}
+
+	return operation
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaOf returns the JSON schema of the given type, as encoded by encoding/json.
+// Named structs are added to schemas and referenced by name, so that clients can generate a single type for each of them.
+func schemaOf(t reflect.Type, schemas map[string]any) map[string]any {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]any{"type": "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": schemaOf(t.Elem(), schemas)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaOf(t.Elem(), schemas)}
+	case reflect.Struct:
+		if t == timeType {
+			return map[string]any{"type": "string", "format": "date-time"}
+		}
+		if t.Name() == "" {
+			return structSchema(t, schemas)
+		}
+
+		name := strings.ToUpper(t.Name()[:1]) + t.Name()[1:]
+		if _, ok := schemas[name]; !ok {
+			// Reserve the name first, in case the struct refers to itself.
+			schemas[name] = nil
+			schemas[name] = structSchema(t, schemas)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + name}
+	default:
+		return map[string]any{}
+	}
+}
+
+// structSchema returns the schema of a struct's exported fields, using the same field names as encoding/json.
+func structSchema(t reflect.Type, schemas map[string]any) map[string]any {
+	properties := make(map[string]any)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		properties[name] = schemaOf(field.Type, schemas)
+	}
+
+	return map[string]any{"type": "object", "properties": properties}
+}
+
+// getOpenAPI responds with the OpenAPI document of the API.
+func (s *Server) getOpenAPI(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, openAPIDocument(s.apiRoutes()))
+}
+
+// getAPIDocs renders a page that displays the OpenAPI document in a readable form.
+func (s *Server) getAPIDocs(w http.ResponseWriter, r *http.Request) {
+	s.Render(w, r, 200, "api/docs.go.html", "base", nil)
+}
@@ -0,0 +1,65 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOpenAPIDocument(t *testing.T) {
+	srv := NewServer("", "", "")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil)
+	rec := httptest.NewRecorder()
+	srv.router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/openapi.json status = %v, want %v", rec.Code, http.StatusOK)
+	}
+
+	var doc struct {
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	body := rec.Body.String()
+	if err := json.Unmarshal([]byte(body), &doc); err != nil {
+		t.Fatalf("failed to decode document: %v", err)
+	}
+
+	// Every API route should be documented.
+	for _, route := range srv.apiRoutes() {
+		if _, ok := doc.Paths[openAPIPath(route.Path)][strings.ToLower(route.Method)]; !ok {
+			t.Errorf("route %s %s is missing from the document", route.Method, route.Path)
+		}
+	}
+
+	// Every reference should point to a schema in the document.
+	for _, ref := range strings.Split(body, `"$ref": "#/components/schemas/`)[1:] {
+		name, _, _ := strings.Cut(ref, `"`)
+		if _, ok := doc.Components.Schemas[name]; !ok {
+			t.Errorf("reference to undefined schema %q", name)
+		}
+	}
+}
+
+func Test_operationID(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		want   string
+	}{
+		{http.MethodGet, "/rankings", "getRankings"},
+		{http.MethodGet, "/players/:id", "getPlayersByID"},
+		{http.MethodPut, "/entrants/:id/players", "putEntrantsByIDPlayers"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := operationID(apiRoute{Method: tt.method, Path: tt.path}); got != tt.want {
+				t.Errorf("operationID() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
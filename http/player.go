@@ -3,39 +3,72 @@ package http
 import (
 	"fmt"
 	tournament "github.com/ejacobg/tourney-tracker"
+	"github.com/ejacobg/tourney-tracker/validator"
 	"net/http"
+	"strconv"
 )
 
 func (s *Server) registerPlayerRoutes() {
-	s.router.HandlerFunc(http.MethodGet, "/", s.getRankings)
-	s.router.HandlerFunc(http.MethodGet, "/players", s.getPlayers)
-	s.router.HandlerFunc(http.MethodPost, "/players/new", s.postPlayer)
-	s.router.HandlerFunc(http.MethodGet, "/players/:id", s.getPlayer)
-	s.router.HandlerFunc(http.MethodGet, "/players/:id/name", s.getPlayerName)
-	s.router.HandlerFunc(http.MethodGet, "/players/:id/name/edit", s.getPlayerNameForm)
-	s.router.HandlerFunc(http.MethodPut, "/players/:id/name", s.putPlayerName)
-	s.router.HandlerFunc(http.MethodDelete, "/players/:id", s.deletePlayer)
+	s.handle(http.MethodGet, "/", tournament.RoleViewer, s.getRankings)
+	s.handle(http.MethodGet, "/players", tournament.RoleViewer, s.getPlayers)
+	s.handle(http.MethodPost, "/players/new", tournament.RoleEditor, s.postPlayer)
+	s.handle(http.MethodGet, "/players/:id", tournament.RoleViewer, s.getPlayer)
+	s.handle(http.MethodGet, "/players/:id/name", tournament.RoleViewer, s.getPlayerName)
+	s.handle(http.MethodGet, "/players/:id/name/edit", tournament.RoleEditor, s.getPlayerNameForm)
+	s.handle(http.MethodPut, "/players/:id/name", tournament.RoleEditor, s.putPlayerName)
+	s.handle(http.MethodDelete, "/players/:id", tournament.RoleOrganizer, s.deletePlayer)
 }
 
+// getRankings renders the singles rankings, or the doubles leaderboard if the "leaderboard" query parameter is "doubles".
+// The rankings may be limited to a single Game using the "game" query parameter.
 func (s *Server) getRankings(w http.ResponseWriter, r *http.Request) {
-	ranks, err := s.PlayerService.GetRanks()
+	filter := tournament.RankFilter{
+		Doubles: r.URL.Query().Get("leaderboard") == "doubles",
+		GameID:  readGameQuery(r),
+	}
+
+	ranks, err := s.PlayerService.GetRanks(r.Context(), filter)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	games, err := s.GameService.GetGames(r.Context())
 	if err != nil {
-		ServerErrorResponse(w, "Failed to get ranks.")
+		ServiceErrorResponse(w, r, err)
 		return
 	}
 
-	s.Render(w, 200, "index.go.html", "base", ranks)
+	s.Render(w, r, 200, "index.go.html", "base", map[string]any{
+		"Ranks":   ranks,
+		"Doubles": filter.Doubles,
+		"Games":   games,
+		"GameID":  filter.GameID,
+	})
 }
 
 // getPlayers renders a table of all saved players, as well as a form for adding a new Player.
-func (s *Server) getPlayers(w http.ResponseWriter, _ *http.Request) {
-	players, err := s.PlayerService.GetPlayers()
+// The players may be limited to those who have played a single Game using the "game" query parameter.
+func (s *Server) getPlayers(w http.ResponseWriter, r *http.Request) {
+	gameID := readGameQuery(r)
+
+	players, err := s.PlayerService.GetPlayers(r.Context(), gameID)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	games, err := s.GameService.GetGames(r.Context())
 	if err != nil {
-		ServerErrorResponse(w, "Failed to get players.")
+		ServiceErrorResponse(w, r, err)
 		return
 	}
 
-	s.Render(w, 200, "players/index.go.html", "base", players)
+	s.Render(w, r, 200, "players/index.go.html", "base", map[string]any{
+		"Players": players,
+		"Games":   games,
+		"GameID":  gameID,
+	})
 }
 
 // postPlayer accepts form data consisting of a "name" field containing the name of the Player to create.
@@ -49,9 +82,18 @@ func (s *Server) postPlayer(w http.ResponseWriter, r *http.Request) {
 
 	player := tournament.Player{Name: r.PostForm.Get("name")}
 
-	err = s.PlayerService.CreatePlayer(&player)
+	v := validator.New()
+	if tournament.ValidatePlayer(v, &player); !v.Valid() {
+		s.Render(w, r, http.StatusUnprocessableEntity, "players/index.go.html", "new", map[string]any{
+			"Name":   player.Name,
+			"Errors": v.Errors,
+		})
+		return
+	}
+
+	err = s.PlayerService.CreatePlayer(r.Context(), &player)
 	if err != nil {
-		ServerErrorResponse(w, fmt.Sprintf("Failed to create player: %s", err))
+		ServiceErrorResponse(w, r, err)
 		return
 	}
 
@@ -68,19 +110,19 @@ func (s *Server) getPlayer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	player, err := s.PlayerService.GetPlayer(id)
+	player, err := s.PlayerService.GetPlayer(r.Context(), id)
 	if err != nil {
-		ServerErrorResponse(w, fmt.Sprintf("Failed to get player: %s", err))
+		ServiceErrorResponse(w, r, err)
 		return
 	}
 
-	attendance, err := s.EntrantService.GetAttendance(id)
+	attendance, err := s.EntrantService.GetAttendance(r.Context(), id)
 	if err != nil {
-		ServerErrorResponse(w, fmt.Sprintf("Failed to get player attendance: %s", err))
+		ServiceErrorResponse(w, r, err)
 		return
 	}
 
-	s.Render(w, 200, "players/view.go.html", "base", map[string]any{
+	s.Render(w, r, 200, "players/view.go.html", "base", map[string]any{
 		"Player":     player,
 		"Attendance": attendance,
 	})
@@ -94,13 +136,13 @@ func (s *Server) getPlayerName(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	player, err := s.PlayerService.GetPlayer(id)
+	player, err := s.PlayerService.GetPlayer(r.Context(), id)
 	if err != nil {
-		ServerErrorResponse(w, fmt.Sprintf("Failed to get player: %s", err))
+		ServiceErrorResponse(w, r, err)
 		return
 	}
 
-	s.Render(w, 200, "players/view.go.html", "name", player)
+	s.Render(w, r, 200, "players/view.go.html", "name", player)
 }
 
 // getPlayerNameForm will respond with a form element that allows for changing of a Player's name.
@@ -111,17 +153,19 @@ func (s *Server) getPlayerNameForm(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	player, err := s.PlayerService.GetPlayer(id)
+	player, err := s.PlayerService.GetPlayer(r.Context(), id)
 	if err != nil {
-		ServerErrorResponse(w, fmt.Sprintf("Failed to get player: %s", err))
+		ServiceErrorResponse(w, r, err)
 		return
 	}
 
-	s.Render(w, 200, "players/edit.go.html", "name", player)
+	s.Render(w, r, 200, "players/edit.go.html", "name", map[string]any{"Player": player})
 }
 
-// putPlayerName accepts form data consisting of a "name" field containing the value of the new name.
+// putPlayerName accepts form data consisting of a "name" field containing the value of the new name,
+// and a "version" field containing the Version of the Player that the form was rendered with.
 // The new name will then be applied to the Player, and a refresh of the Player page will be returned.
+// If the Player has been changed since the form was rendered, nothing is changed and a conflict is returned instead.
 func (s *Server) putPlayerName(w http.ResponseWriter, r *http.Request) {
 	id, err := readIDParam(r)
 	if err != nil {
@@ -140,9 +184,21 @@ func (s *Server) putPlayerName(w http.ResponseWriter, r *http.Request) {
 		Name: r.PostForm.Get("name"),
 	}
 
-	err = s.PlayerService.UpdatePlayer(&player)
+	// A missing version skips the check, rather than rejecting the change.
+	player.Version, _ = strconv.Atoi(r.PostForm.Get("version"))
+
+	v := validator.New()
+	if tournament.ValidatePlayer(v, &player); !v.Valid() {
+		s.Render(w, r, http.StatusUnprocessableEntity, "players/edit.go.html", "name", map[string]any{
+			"Player": player,
+			"Errors": v.Errors,
+		})
+		return
+	}
+
+	err = s.PlayerService.UpdatePlayer(r.Context(), &player)
 	if err != nil {
-		ServerErrorResponse(w, fmt.Sprintf("Failed to update player: %s", err))
+		ServiceErrorResponse(w, r, err)
 		return
 	}
 
@@ -151,7 +207,7 @@ func (s *Server) putPlayerName(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
-// deletePlayer deletes the given Player and updates all of its Entrant records.
+// deletePlayer moves the given Player to the trash.
 func (s *Server) deletePlayer(w http.ResponseWriter, r *http.Request) {
 	id, err := readIDParam(r)
 	if err != nil {
@@ -159,9 +215,9 @@ func (s *Server) deletePlayer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = s.PlayerService.DeletePlayer(id)
+	err = s.PlayerService.DeletePlayer(r.Context(), id)
 	if err != nil {
-		ServerErrorResponse(w, fmt.Sprintf("Failed to delete player: %s", err))
+		ServiceErrorResponse(w, r, err)
 		return
 	}
 
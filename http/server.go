@@ -1,13 +1,37 @@
 package http
 
 import (
+	"context"
+	"fmt"
 	tournament "github.com/ejacobg/tourney-tracker"
+	"github.com/ejacobg/tourney-tracker/ui"
 	"github.com/julienschmidt/httprouter"
 	"html/template"
+	"io/fs"
+	"log"
+	"net"
 	"net/http"
+	"sync"
 	"time"
 )
 
+// The default deadlines of each request. Both are shorter than the DefaultWriteTimeout, so that an error can still be written.
+const (
+	DefaultRequestTimeout = 10 * time.Second
+	DefaultImportTimeout  = 25 * time.Second
+)
+
+// The default timeouts of the connections accepted by ListenAndServe.
+const (
+	DefaultReadTimeout  = 10 * time.Second
+	DefaultWriteTimeout = 30 * time.Second
+	DefaultIdleTimeout  = 1 * time.Minute
+)
+
+// DefaultShutdownTimeout is how long a Server waits for requests and background jobs to finish when shutting down.
+// It is longer than the DefaultImportTimeout, so that imports that have already started can finish.
+const DefaultShutdownTimeout = 30 * time.Second
+
 // Server provides several HTTP handlers for servicing tournament-related requests.
 type Server struct {
 	router *httprouter.Router
@@ -15,18 +39,50 @@ type Server struct {
 	// Addr is the address for the Server to listen on.
 	Addr string
 
+	// BaseURL is the address that users reach the Server at, eg. https://tourneys.example.com.
+	// If it uses https, cookies are only sent over HTTPS, even when TLS is handled by a proxy in front of the Server.
+	BaseURL string
+
 	// Templates holds all the templates used by the application.
 	Templates map[string]*template.Template
 
+	// LoadTemplates, if set, is called before each page is rendered, and its templates are used instead of Templates.
+	// This is meant for development, so that changes to the templates show up without restarting the server.
+	LoadTemplates func() (map[string]*template.Template, error)
+
+	// UI holds the files of the web interface. Its static directory is served under /static/. Defaults to the embedded ui.Files.
+	UI fs.FS
+
 	// Credentials needed for API calls.
 	challongeUsername, challongePassword string
 	startggKey                           string
 
+	// TrashRetention is how long deleted tournaments and players are kept before they are purged.
+	// The Server does not purge them itself, but needs to know when they will be purged.
+	TrashRetention time.Duration
+
+	// RequestTimeout is how long a request may take before its context is cancelled, abandoning any database work still running.
+	// Requests that import a tournament wait on other sites, so they are given the ImportTimeout instead. A timeout of 0 means no deadline.
+	RequestTimeout time.Duration
+	ImportTimeout  time.Duration
+
+	// Timeouts of the connections accepted by ListenAndServe. See http.Server for details.
+	ReadTimeout, WriteTimeout, IdleTimeout time.Duration
+
+	// ShutdownTimeout is how long Serve waits for requests and background jobs to finish once it starts shutting down.
+	// Requests and background jobs still running after the ShutdownTimeout have their contexts cancelled, abandoning any database work.
+	ShutdownTimeout time.Duration
+
+	// jobs counts the background jobs that are still running, and cancelJobs cancels their context. See Background().
+	jobs       sync.WaitGroup
+	jobsCtx    context.Context
+	cancelJobs context.CancelFunc
+
+	// DefaultTierID is the Tier given to imported tournaments. A DefaultTierID of 0 leaves the choice to the TournamentService.
+	DefaultTierID int64
+
 	// Services used by the various HTTP routes.
-	EntrantService    tournament.EntrantService
-	PlayerService     tournament.PlayerService
-	TierService       tournament.TierService
-	TournamentService tournament.TournamentService
+	tournament.Services
 }
 
 // NewServer creates a Server with the given credentials. The other fields should be applied manually.
@@ -36,33 +92,131 @@ func NewServer(challongeUsername, challongePassword, startggKey string) *Server
 		challongeUsername: challongeUsername,
 		challongePassword: challongePassword,
 		startggKey:        startggKey,
+		TrashRetention:    tournament.DefaultTrashRetention,
+		RequestTimeout:    DefaultRequestTimeout,
+		ImportTimeout:     DefaultImportTimeout,
+		ReadTimeout:       DefaultReadTimeout,
+		WriteTimeout:      DefaultWriteTimeout,
+		IdleTimeout:       DefaultIdleTimeout,
+		ShutdownTimeout:   DefaultShutdownTimeout,
+		UI:                ui.Files,
 	}
+	srv.jobsCtx, srv.cancelJobs = context.WithCancel(context.Background())
 
-	srv.router.Handler("GET", "/static/*filepath", http.FileServer(http.Dir("ui")))
-	srv.router.NotFound = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+	// The UI is read on each request, so that it can be replaced after the Server is created.
+	srv.router.HandlerFunc("GET", "/static/*filepath", func(w http.ResponseWriter, r *http.Request) {
+		http.FileServer(http.FS(srv.UI)).ServeHTTP(w, r)
+	})
+	srv.router.NotFound = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isAPIRequest(r) {
+			JSONNotFoundResponse(w, "Resource not found.")
+			return
+		}
 		NotFoundResponse(w, "Page not found.")
 	})
-	srv.router.MethodNotAllowed = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+	srv.router.MethodNotAllowed = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isAPIRequest(r) {
+			JSONMethodNotAllowedResponse(w, "Method not allowed.")
+			return
+		}
 		MethodNotAllowedResponse(w, "Method not allowed.")
 	})
 
+	srv.registerAuditRoutes()
+	srv.registerAuthRoutes()
 	srv.registerEntrantRoutes()
 	srv.registerPlayerRoutes()
 	srv.registerTierRoutes()
 	srv.registerTournamentRoutes()
+	srv.registerUserRoutes()
+	srv.registerTokenRoutes()
+	srv.registerTrashRoutes()
+	srv.registerSnapshotRoutes()
 	srv.registerFormulaRoutes()
+	srv.registerAPIRoutes()
 
 	return &srv
 }
 
-func (s *Server) ListenAndServe() error {
+// ListenAndServe listens on the Server's Addr, then calls Serve.
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	addr := s.Addr
+	if addr == "" {
+		addr = ":http"
+	}
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(ctx, l)
+}
+
+// Serve accepts connections on the given listener until the context is cancelled, then shuts down.
+// Shutting down stops accepting connections, then waits up to the ShutdownTimeout for the requests and background jobs still running to finish.
+// A nil error is returned if everything finished in time. Otherwise, whatever is left is cancelled.
+// Serve does not return until every background job has returned, so that nothing they use is closed under them.
+func (s *Server) Serve(ctx context.Context, l net.Listener) error {
+	defer func() {
+		s.cancelJobs()
+		s.jobs.Wait()
+	}()
+
+	// Requests are not given the context passed to Serve, so that they can finish while shutting down.
+	// Instead, they are cancelled if they are still running at the end of the ShutdownTimeout.
+	requests, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+
 	srv := http.Server{
-		Addr:         s.Addr,
-		Handler:      s.router,
-		IdleTimeout:  1 * time.Minute,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 30 * time.Second,
+		Handler:      s.authenticate(s.verifyCSRF(s.router)),
+		IdleTimeout:  s.IdleTimeout,
+		ReadTimeout:  s.ReadTimeout,
+		WriteTimeout: s.WriteTimeout,
+		BaseContext:  func(net.Listener) context.Context { return requests },
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- srv.Serve(l)
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Printf("Shutting down. Waiting up to %s for requests and background jobs to finish.", s.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.ShutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		srv.Close()
+		return fmt.Errorf("requests were still running after %s: %w", s.ShutdownTimeout, err)
 	}
 
-	return srv.ListenAndServe()
+	jobs := make(chan struct{})
+	go func() {
+		s.jobs.Wait()
+		close(jobs)
+	}()
+
+	select {
+	case <-jobs:
+		return nil
+	case <-shutdownCtx.Done():
+		return fmt.Errorf("background jobs were still running after %s, and were cancelled", s.ShutdownTimeout)
+	}
+}
+
+// Background runs the given job in its own goroutine. Serve waits for the job to return when shutting down,
+// so jobs should stop starting new work once the context given to Serve is cancelled.
+// The context given to the job is cancelled if it is still running at the end of the ShutdownTimeout, and the job must then return promptly.
+func (s *Server) Background(job func(ctx context.Context)) {
+	s.jobs.Add(1)
+	go func() {
+		defer s.jobs.Done()
+		job(s.jobsCtx)
+	}()
 }
@@ -0,0 +1,161 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestServer_static(t *testing.T) {
+	srv := NewServer("", "", "")
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+	}{
+		{"embedded", "/static/css/ejacobg.css", http.StatusOK},
+		{"missing", "/static/css/missing.css", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			srv.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+		})
+	}
+
+	t.Run("replaced", func(t *testing.T) {
+		srv.UI = fstest.MapFS{"static/css/dev.css": {Data: []byte("body {}")}}
+
+		rec := httptest.NewRecorder()
+		srv.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/static/css/dev.css", nil))
+		if rec.Code != http.StatusOK || rec.Body.String() != "body {}" {
+			t.Errorf("got %d %q, want 200 %q", rec.Code, rec.Body.String(), "body {}")
+		}
+	})
+}
+
+// serve starts the Server on a random port, and returns its address and the error returned by Serve.
+func serve(t *testing.T, srv *Server, ctx context.Context) (string, <-chan error) {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- srv.Serve(ctx, l)
+	}()
+
+	return "http://" + l.Addr().String(), errs
+}
+
+func TestServer_Serve_drains(t *testing.T) {
+	srv := NewServer("", "", "")
+	started := make(chan struct{})
+	srv.router.HandlerFunc(http.MethodGet, "/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(50 * time.Millisecond)
+		if r.Context().Err() != nil {
+			t.Error("request context cancelled while shutting down")
+		}
+		w.Write([]byte("done"))
+	})
+
+	jobDone := false
+	ctx, cancel := context.WithCancel(context.Background())
+	srv.Background(func(context.Context) {
+		<-ctx.Done()
+		time.Sleep(20 * time.Millisecond)
+		jobDone = true
+	})
+
+	addr, errs := serve(t, srv, ctx)
+
+	responses := make(chan string, 1)
+	go func() {
+		res, err := http.Get(addr + "/slow")
+		if err != nil {
+			responses <- err.Error()
+			return
+		}
+		defer res.Body.Close()
+		body, _ := io.ReadAll(res.Body)
+		responses <- string(body)
+	}()
+
+	<-started
+	cancel()
+
+	if err := <-errs; err != nil {
+		t.Errorf("Serve() error = %v, want nil", err)
+	}
+	if got := <-responses; got != "done" {
+		t.Errorf("response = %q, want %q", got, "done")
+	}
+	if !jobDone {
+		t.Error("Serve() returned before the background job finished")
+	}
+}
+
+func TestServer_Serve_shutdownTimeout(t *testing.T) {
+	srv := NewServer("", "", "")
+	srv.ShutdownTimeout = 10 * time.Millisecond
+
+	started, cancelled := make(chan struct{}), make(chan struct{})
+	srv.router.HandlerFunc(http.MethodGet, "/stuck", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+		close(cancelled)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	addr, errs := serve(t, srv, ctx)
+	go http.Get(addr + "/stuck")
+
+	<-started
+	cancel()
+
+	if err := <-errs; err == nil {
+		t.Error("Serve() error = nil, want an error for the stuck request")
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("stuck request was not cancelled after the ShutdownTimeout")
+	}
+}
+
+func TestServer_Serve_jobTimeout(t *testing.T) {
+	srv := NewServer("", "", "")
+	srv.ShutdownTimeout = 10 * time.Millisecond
+
+	jobDone := false
+	srv.Background(func(ctx context.Context) {
+		<-ctx.Done()
+		time.Sleep(20 * time.Millisecond)
+		jobDone = true
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	_, errs := serve(t, srv, ctx)
+	cancel()
+
+	if err := <-errs; err == nil {
+		t.Error("Serve() error = nil, want an error for the stuck job")
+	}
+	if !jobDone {
+		t.Error("Serve() returned before the cancelled background job finished")
+	}
+}
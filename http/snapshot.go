@@ -0,0 +1,114 @@
+package http
+
+import (
+	"fmt"
+	tournament "github.com/ejacobg/tourney-tracker"
+	"net/http"
+	"strings"
+)
+
+func (s *Server) registerSnapshotRoutes() {
+	s.handle(http.MethodGet, "/snapshots", tournament.RoleAdmin, s.getSnapshots)
+	s.handle(http.MethodPost, "/snapshots/new", tournament.RoleAdmin, s.postSnapshot)
+	s.handle(http.MethodPut, "/snapshots/:id/restore", tournament.RoleAdmin, s.putSnapshotRestore)
+	s.handle(http.MethodDelete, "/snapshots/:id", tournament.RoleAdmin, s.deleteSnapshot)
+}
+
+// getSnapshots renders a table of all snapshots, as well as a form for taking a new Snapshot.
+func (s *Server) getSnapshots(w http.ResponseWriter, r *http.Request) {
+	snapshots, err := s.SnapshotService.GetSnapshots(r.Context())
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	s.Render(w, r, 200, "snapshots/index.go.html", "base", snapshots)
+}
+
+// postSnapshot accepts form data consisting of a "name" field, and saves a Snapshot of the current data under that name.
+// If successful, a refresh of the snapshots page will be returned.
+func (s *Server) postSnapshot(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		BadRequestResponse(w, "Failed to parse form.")
+		return
+	}
+
+	snapshot := tournament.Snapshot{Name: strings.TrimSpace(r.PostForm.Get("name"))}
+	if snapshot.Name == "" {
+		UnprocessableEntityResponse(w, "Name must not be empty.")
+		return
+	}
+
+	// Snapshots are named after the User who took them.
+	if user := contextGetUser(r); user != nil {
+		snapshot.CreatedBy = user.Name
+	}
+
+	err = s.SnapshotService.CreateSnapshot(r.Context(), &snapshot)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	w.Header()["HX-Refresh"] = []string{"true"}
+	w.WriteHeader(http.StatusCreated)
+}
+
+// putSnapshotRestore replaces the current data with the given Snapshot, then returns a refresh of the snapshots page.
+// A Snapshot of the current data is taken first, so that the restore can be undone.
+func (s *Server) putSnapshotRestore(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		NotFoundResponse(w, "Invalid snapshot ID.")
+		return
+	}
+
+	saved, err := s.SnapshotService.GetSnapshot(r.Context(), id)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	err = s.takeSnapshot(r, fmt.Sprintf("Before restoring %q", saved.Name))
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	err = s.SnapshotService.RestoreSnapshot(r.Context(), id)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	w.Header()["HX-Refresh"] = []string{"true"}
+	w.WriteHeader(http.StatusOK)
+}
+
+// deleteSnapshot deletes the given Snapshot. The current data is not affected.
+func (s *Server) deleteSnapshot(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		NotFoundResponse(w, "Invalid snapshot ID.")
+		return
+	}
+
+	err = s.SnapshotService.DeleteSnapshot(r.Context(), id)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// takeSnapshot takes an automatic Snapshot with the given name, so that the change about to be made by the request can be undone.
+func (s *Server) takeSnapshot(r *http.Request, name string) error {
+	snapshot := tournament.Snapshot{Name: name, Automatic: true}
+	if user := contextGetUser(r); user != nil {
+		snapshot.CreatedBy = user.Name
+	}
+
+	return s.SnapshotService.CreateSnapshot(r.Context(), &snapshot)
+}
@@ -1,24 +1,24 @@
 package http
 
 import (
-	"fmt"
+	tournament "github.com/ejacobg/tourney-tracker"
 	"net/http"
 )
 
 func (s *Server) registerTierRoutes() {
-	s.router.HandlerFunc(http.MethodGet, "/tiers", s.getTiers)
-	s.router.HandlerFunc(http.MethodGet, "/tiers/:id", s.getTier)
+	s.handle(http.MethodGet, "/tiers", tournament.RoleViewer, s.getTiers)
+	s.handle(http.MethodGet, "/tiers/:id", tournament.RoleViewer, s.getTier)
 }
 
 // getTiers renders all the current tiers. Right now, the current tiers are considered immutable.
-func (s *Server) getTiers(w http.ResponseWriter, _ *http.Request) {
-	tiers, err := s.TierService.GetTiers()
+func (s *Server) getTiers(w http.ResponseWriter, r *http.Request) {
+	tiers, err := s.TierService.GetTiers(r.Context())
 	if err != nil {
-		ServerErrorResponse(w, "Failed to get tiers.")
+		ServiceErrorResponse(w, r, err)
 		return
 	}
 
-	s.Render(w, 200, "tiers/index.go.html", "base", tiers)
+	s.Render(w, r, 200, "tiers/index.go.html", "base", tiers)
 }
 
 // getTier renders the names of all the tournaments with the given Tier.
@@ -29,19 +29,19 @@ func (s *Server) getTier(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tier, err := s.TierService.GetTier(id)
+	tier, err := s.TierService.GetTier(r.Context(), id)
 	if err != nil {
-		ServerErrorResponse(w, fmt.Sprintf("Failed to get tier: %s", err))
+		ServiceErrorResponse(w, r, err)
 		return
 	}
 
-	names, err := s.TournamentService.GetNamesByTier(id)
+	names, err := s.TournamentService.GetNamesByTier(r.Context(), id)
 	if err != nil {
-		ServerErrorResponse(w, fmt.Sprintf("Failed to get names: %s", err))
+		ServiceErrorResponse(w, r, err)
 		return
 	}
 
-	s.Render(w, 200, "tiers/view.go.html", "base", map[string]any{
+	s.Render(w, r, 200, "tiers/view.go.html", "base", map[string]any{
 		"Tier":  tier,
 		"Names": names,
 	})
@@ -0,0 +1,90 @@
+package http
+
+import (
+	tournament "github.com/ejacobg/tourney-tracker"
+	"github.com/ejacobg/tourney-tracker/validator"
+	"net/http"
+)
+
+func (s *Server) registerTokenRoutes() {
+	s.handle(http.MethodGet, "/tokens", tournament.RoleAdmin, s.getTokens)
+	s.handle(http.MethodPost, "/tokens/new", tournament.RoleAdmin, s.postToken)
+	s.handle(http.MethodDelete, "/tokens/:id", tournament.RoleAdmin, s.deleteToken)
+}
+
+// getTokens renders a table of all API tokens, as well as a form for creating a new Token.
+func (s *Server) getTokens(w http.ResponseWriter, r *http.Request) {
+	tokens, err := s.TokenService.GetTokens(r.Context())
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	s.Render(w, r, 200, "tokens/index.go.html", "base", map[string]any{
+		"Tokens": tokens,
+		"Roles":  tournament.Roles,
+	})
+}
+
+// postToken accepts form data consisting of "label" and "role" fields.
+// The new Token is returned in an element that shows its value. This is the only time that the value can be seen.
+func (s *Server) postToken(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		BadRequestResponse(w, "Failed to parse form.")
+		return
+	}
+
+	user := contextGetUser(r)
+	token := tournament.Token{
+		Label:    r.PostForm.Get("label"),
+		Role:     tournament.Role(r.PostForm.Get("role")),
+		UserID:   user.ID,
+		UserName: user.Name,
+	}
+
+	v := validator.New()
+	if tournament.ValidateToken(v, &token, user.Role); !v.Valid() {
+		// The form is rendered again in place of itself, rather than in the element that shows new tokens.
+		w.Header()["HX-Retarget"] = []string{"#new-token-form"}
+		w.Header()["HX-Reswap"] = []string{"outerHTML"}
+		s.Render(w, r, http.StatusUnprocessableEntity, "tokens/index.go.html", "new", map[string]any{
+			"Roles":  tournament.Roles,
+			"Label":  token.Label,
+			"Role":   token.Role,
+			"Errors": v.Errors,
+		})
+		return
+	}
+
+	token.Plaintext, err = tournament.NewToken()
+	if err != nil {
+		ServerErrorResponse(w, "Failed to generate token.")
+		return
+	}
+
+	err = s.TokenService.CreateToken(r.Context(), &token)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	s.Render(w, r, http.StatusCreated, "tokens/index.go.html", "created", token)
+}
+
+// deleteToken revokes the given Token.
+func (s *Server) deleteToken(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		NotFoundResponse(w, "Invalid token ID.")
+		return
+	}
+
+	err = s.TokenService.RevokeToken(r.Context(), id)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
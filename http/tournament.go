@@ -1,36 +1,58 @@
 package http
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	tournament "github.com/ejacobg/tourney-tracker"
 	"github.com/ejacobg/tourney-tracker/convert"
 	"github.com/ejacobg/tourney-tracker/convert/challonge"
 	"github.com/ejacobg/tourney-tracker/convert/startgg"
+	"github.com/ejacobg/tourney-tracker/validator"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 )
 
 func (s *Server) registerTournamentRoutes() {
-	s.router.HandlerFunc(http.MethodGet, "/tournaments", s.getTournaments)
-	s.router.HandlerFunc(http.MethodPost, "/tournaments/new", s.postTournamentURL)
-	s.router.HandlerFunc(http.MethodGet, "/tournaments/:id", s.getTournament)
-	s.router.HandlerFunc(http.MethodGet, "/tournaments/:id/tier", s.getTournamentTier)
-	s.router.HandlerFunc(http.MethodGet, "/tournaments/:id/tier/edit", s.getTournamentTierForm)
-	s.router.HandlerFunc(http.MethodPut, "/tournaments/:id/tier", s.putTournamentTier)
-	s.router.HandlerFunc(http.MethodDelete, "/tournaments/:id", s.deleteTournament)
+	s.handle(http.MethodGet, "/tournaments", tournament.RoleViewer, s.getTournaments)
+	s.handleImport(http.MethodPost, "/tournaments/new", tournament.RoleOrganizer, s.postTournamentURL)
+	s.handle(http.MethodGet, "/tournaments/:id", tournament.RoleViewer, s.getTournament)
+	s.handle(http.MethodGet, "/tournaments/:id/tier", tournament.RoleViewer, s.getTournamentTier)
+	s.handle(http.MethodGet, "/tournaments/:id/tier/edit", tournament.RoleAdmin, s.getTournamentTierForm)
+	s.handle(http.MethodPut, "/tournaments/:id/tier", tournament.RoleAdmin, s.putTournamentTier)
+	s.handle(http.MethodGet, "/tournaments/:id/game", tournament.RoleViewer, s.getTournamentGame)
+	s.handle(http.MethodGet, "/tournaments/:id/game/edit", tournament.RoleOrganizer, s.getTournamentGameForm)
+	s.handle(http.MethodPut, "/tournaments/:id/game", tournament.RoleOrganizer, s.putTournamentGame)
+	s.handle(http.MethodGet, "/tournaments/:id/scoring", tournament.RoleViewer, s.getTournamentScoring)
+	s.handle(http.MethodGet, "/tournaments/:id/scoring/edit", tournament.RoleOrganizer, s.getTournamentScoringForm)
+	s.handle(http.MethodPut, "/tournaments/:id/scoring", tournament.RoleOrganizer, s.putTournamentScoring)
+	s.handle(http.MethodDelete, "/tournaments/:id", tournament.RoleOrganizer, s.deleteTournament)
 }
 
 // getTournaments renders a table of all saved tournaments, as well as a form for adding a new Tournament.
-func (s *Server) getTournaments(w http.ResponseWriter, _ *http.Request) {
-	previews, err := s.TournamentService.GetPreviews()
+// The tournaments may be limited to a single Game using the "game" query parameter.
+func (s *Server) getTournaments(w http.ResponseWriter, r *http.Request) {
+	gameID := readGameQuery(r)
+
+	previews, err := s.TournamentService.GetPreviews(r.Context(), gameID)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	games, err := s.GameService.GetGames(r.Context())
 	if err != nil {
-		ServerErrorResponse(w, "Failed to get previews.")
+		ServiceErrorResponse(w, r, err)
 		return
 	}
 
-	s.Render(w, 200, "tournaments/index.go.html", "base", previews)
+	s.Render(w, r, 200, "tournaments/index.go.html", "base", map[string]any{
+		"Previews": previews,
+		"Games":    games,
+		"GameID":   gameID,
+	})
 }
 
 // postTournamentURL accepts form data consisting of a "url" field containing a URL to a tournament.
@@ -43,39 +65,43 @@ func (s *Server) postTournamentURL(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	URL, err := url.Parse(r.PostForm.Get("url"))
-	if err != nil {
-		UnprocessableEntityResponse(w, "Failed to parse URL.")
-		return
-	}
+	input := strings.TrimSpace(r.PostForm.Get("url"))
+
+	v := validator.New()
+
+	URL, err := url.Parse(input)
+	v.Check(validator.NotBlank(input), "url", "URL must not be empty.")
+	v.Check(err == nil, "url", "Failed to parse URL.")
 
 	var (
 		tourney  tournament.Tournament
 		entrants []tournament.Entrant
 	)
 
-	switch URL.Host {
-	case "challonge.com":
-		tourney, entrants, err = challonge.FromURL(URL, s.challongeUsername, s.challongePassword)
-	case "www.start.gg", "www.smash.gg":
-		tourney, entrants, err = startgg.FromURL(URL, s.startggKey)
-	default:
-		err = convert.ErrUnrecognizedURL
-	}
-
-	if err != nil {
+	if v.Valid() {
+		tourney, entrants, err = s.fetchTournament(r.Context(), URL)
 		switch {
-		case errors.Is(err, convert.ErrUnrecognizedURL):
-			UnprocessableEntityResponse(w, fmt.Sprintf("Unrecognized host: %q", URL.Host))
+		case tournament.ErrorCode(err) == tournament.EINVALID:
+			v.AddError("url", tournament.ErrorMessage(err))
+		case err != nil:
+			ServiceErrorResponse(w, r, err)
+			return
 		default:
-			UnprocessableEntityResponse(w, fmt.Sprintf("Parsing error: %s", err))
+			tournament.ValidateTournament(v, &tourney, entrants)
 		}
+	}
+
+	if !v.Valid() {
+		s.Render(w, r, http.StatusUnprocessableEntity, "tournaments/index.go.html", "new", map[string]any{
+			"URL":    input,
+			"Errors": v.Errors,
+		})
 		return
 	}
 
-	err = s.TournamentService.CreateTournament(&tourney, entrants)
+	err = s.TournamentService.CreateTournament(r.Context(), &tourney, entrants)
 	if err != nil {
-		ServerErrorResponse(w, fmt.Sprintf("Failed to create tournament: %s", err))
+		ServiceErrorResponse(w, r, err)
 		return
 	}
 
@@ -84,6 +110,32 @@ func (s *Server) postTournamentURL(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, redirect, http.StatusCreated)
 }
 
+// fetchTournament downloads and converts the tournament found at the given URL, using the converter for the URL's host.
+// URLs of unsupported hosts and standings that disagree with the bracket are EINVALID errors.
+// Any other problem downloading or converting the tournament is an EUPSTREAM error.
+// The tournament is given the Server's DefaultTierID.
+func (s *Server) fetchTournament(ctx context.Context, URL *url.URL) (tourney tournament.Tournament, entrants []tournament.Entrant, err error) {
+	switch URL.Host {
+	case "challonge.com":
+		tourney, entrants, err = challonge.FromURL(ctx, URL, s.challongeUsername, s.challongePassword)
+	case "www.start.gg", "www.smash.gg":
+		tourney, entrants, err = startgg.FromURL(ctx, URL, s.startggKey)
+	default:
+		return tourney, nil, tournament.Errorf(tournament.EINVALID, "Unrecognized host: %q", URL.Host)
+	}
+
+	var mismatch *convert.PlacementMismatch
+	switch {
+	case errors.As(err, &mismatch):
+		err = tournament.Errorf(tournament.EINVALID, "The standings on %s do not match the bracket: %s.", URL.Host, mismatch)
+	case err != nil:
+		err = tournament.Errorf(tournament.EUPSTREAM, "Failed to import tournament from %s: %s", URL.Host, err)
+	}
+
+	tourney.Tier.ID = s.DefaultTierID
+	return
+}
+
 // getTournament will read the "id" route parameter and display the details for the given tournament.
 func (s *Server) getTournament(w http.ResponseWriter, r *http.Request) {
 	id, err := readIDParam(r)
@@ -92,21 +144,27 @@ func (s *Server) getTournament(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tourney, err := s.TournamentService.GetTournament(id)
+	tourney, err := s.TournamentService.GetTournament(r.Context(), id)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	entrants, err := s.EntrantService.GetEntrants(r.Context(), id)
 	if err != nil {
-		ServerErrorResponse(w, fmt.Sprintf("Failed to get tournament: %s", err))
+		ServiceErrorResponse(w, r, err)
 		return
 	}
 
-	entrants, err := s.EntrantService.GetEntrants(id)
+	formula, err := s.FormulaService.GetFormula(r.Context())
 	if err != nil {
-		ServerErrorResponse(w, fmt.Sprintf("Failed to get entrants: %s", err))
+		ServiceErrorResponse(w, r, err)
 		return
 	}
 
-	points := tournament.NewPointMap(tourney.BracketReset, tourney.Placements, tourney.Tier.Multiplier)
+	points := formula.NewPointMap(tourney)
 
-	s.Render(w, 200, "tournaments/view.go.html", "base", map[string]any{
+	s.Render(w, r, 200, "tournaments/view.go.html", "base", map[string]any{
 		"Tourney":  tourney,
 		"Entrants": entrants,
 		"Points":   points,
@@ -121,13 +179,13 @@ func (s *Server) getTournamentTier(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tier, err := s.TierService.GetTournamentTier(id)
+	tier, err := s.TierService.GetTournamentTier(r.Context(), id)
 	if err != nil {
-		ServerErrorResponse(w, fmt.Sprintf("Failed to get tier: %s", err))
+		ServiceErrorResponse(w, r, err)
 		return
 	}
 
-	s.Render(w, 200, "tournaments/view.go.html", "tier", map[string]any{
+	s.Render(w, r, 200, "tournaments/view.go.html", "tier", map[string]any{
 		"TournamentID": id,
 		"Tier":         tier,
 	})
@@ -141,15 +199,21 @@ func (s *Server) getTournamentTierForm(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tiers, err := s.TierService.GetTiers()
+	s.renderTierForm(w, r, http.StatusOK, id, nil)
+}
+
+// renderTierForm renders the form for selecting the Tier of the given Tournament, alongside any errors found in a previous submission.
+func (s *Server) renderTierForm(w http.ResponseWriter, r *http.Request, status int, tournamentID int64, errors map[string]string) {
+	tiers, err := s.TierService.GetTiers(r.Context())
 	if err != nil {
-		ServerErrorResponse(w, fmt.Sprintf("Failed to get tiers: %s", err))
+		ServiceErrorResponse(w, r, err)
 		return
 	}
 
-	s.Render(w, 200, "tournaments/edit.go.html", "tier", map[string]any{
-		"TournamentID": id,
+	s.Render(w, r, status, "tournaments/edit.go.html", "tier", map[string]any{
+		"TournamentID": tournamentID,
 		"Tiers":        tiers,
+		"Errors":       errors,
 	})
 }
 
@@ -170,16 +234,185 @@ func (s *Server) putTournamentTier(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	v := validator.New()
+
 	tierID, err := strconv.ParseInt(r.PostForm.Get("tier"), 10, 64)
 	if err != nil {
-		UnprocessableEntityResponse(w, "Invalid tier ID.")
+		v.AddError("tier", "Invalid tier ID.")
+	} else if err = tournament.ValidateTierID(r.Context(), v, s.TierService, "tier", tierID); err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	if !v.Valid() {
+		s.renderTierForm(w, r, http.StatusUnprocessableEntity, tournamentID, v.Errors)
 		return
 	}
 
 	// Apply new Tier to Tournament.
-	err = s.TournamentService.SetTier(tournamentID, tierID)
+	err = s.TournamentService.SetTier(r.Context(), tournamentID, tierID)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	// Refresh the page.
+	w.Header()["HX-Refresh"] = []string{"true"}
+	w.WriteHeader(http.StatusOK)
+}
+
+// getTournamentGame will respond with an element displaying the Tournament's Game, alongside a button to go to the Game editing form.
+func (s *Server) getTournamentGame(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		NotFoundResponse(w, "Invalid tournament ID.")
+		return
+	}
+
+	tourney, err := s.TournamentService.GetTournament(r.Context(), id)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	s.Render(w, r, 200, "tournaments/view.go.html", "game", tourney)
+}
+
+// getTournamentGameForm will respond with a form element that allows for changing of a Tournament's Game.
+func (s *Server) getTournamentGameForm(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		NotFoundResponse(w, "Invalid tournament ID.")
+		return
+	}
+
+	s.renderGameForm(w, r, http.StatusOK, id, nil)
+}
+
+// renderGameForm renders the form for selecting the Game of the given Tournament, alongside any errors found in a previous submission.
+func (s *Server) renderGameForm(w http.ResponseWriter, r *http.Request, status int, tournamentID int64, errors map[string]string) {
+	games, err := s.GameService.GetGames(r.Context())
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	s.Render(w, r, status, "tournaments/edit.go.html", "game", map[string]any{
+		"TournamentID": tournamentID,
+		"Games":        games,
+		"Errors":       errors,
+	})
+}
+
+// putTournamentGame accepts form data consisting of a "game" field containing the value of the new Game ID.
+// The new Game will then be applied to the Tournament, and a refresh of the Tournament page will be returned.
+func (s *Server) putTournamentGame(w http.ResponseWriter, r *http.Request) {
+	tournamentID, err := readIDParam(r)
+	if err != nil {
+		NotFoundResponse(w, "Invalid tournament ID.")
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		BadRequestResponse(w, "Failed to parse form.")
+		return
+	}
+
+	v := validator.New()
+
+	gameID, err := strconv.ParseInt(r.PostForm.Get("game"), 10, 64)
+	if err != nil {
+		v.AddError("game", "Invalid game ID.")
+	} else if err = tournament.ValidateGameID(r.Context(), v, s.GameService, "game", gameID); err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	if !v.Valid() {
+		s.renderGameForm(w, r, http.StatusUnprocessableEntity, tournamentID, v.Errors)
+		return
+	}
+
+	err = s.TournamentService.SetGame(r.Context(), tournamentID, gameID)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	// Refresh the page.
+	w.Header()["HX-Refresh"] = []string{"true"}
+	w.WriteHeader(http.StatusOK)
+}
+
+// getTournamentScoring will respond with an element displaying how a team Tournament's points are credited, alongside a button to go to the scoring editing form.
+func (s *Server) getTournamentScoring(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		NotFoundResponse(w, "Invalid tournament ID.")
+		return
+	}
+
+	tourney, err := s.TournamentService.GetTournament(r.Context(), id)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	s.Render(w, r, 200, "tournaments/view.go.html", "scoring", tourney)
+}
+
+// getTournamentScoringForm will respond with a form element that allows for changing how a team Tournament's points are credited.
+func (s *Server) getTournamentScoringForm(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		NotFoundResponse(w, "Invalid tournament ID.")
+		return
+	}
+
+	s.renderScoringForm(w, r, http.StatusOK, id, nil)
+}
+
+// renderScoringForm renders the form for selecting the TeamScoring of the given Tournament, alongside any errors found in a previous submission.
+func (s *Server) renderScoringForm(w http.ResponseWriter, r *http.Request, status int, tournamentID int64, errors map[string]string) {
+	tourney, err := s.TournamentService.GetTournament(r.Context(), tournamentID)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	s.Render(w, r, status, "tournaments/edit.go.html", "scoring", map[string]any{
+		"Tournament": tourney,
+		"Errors":     errors,
+	})
+}
+
+// putTournamentScoring accepts form data consisting of a "scoring" field containing either "credit" or "separate".
+// The new scoring will then be applied to the Tournament, and a refresh of the Tournament page will be returned.
+func (s *Server) putTournamentScoring(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		NotFoundResponse(w, "Invalid tournament ID.")
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		BadRequestResponse(w, "Failed to parse form.")
+		return
+	}
+
+	scoring := tournament.TeamScoring(r.PostForm.Get("scoring"))
+
+	v := validator.New()
+	if v.Check(tournament.ValidTeamScoring(scoring), "scoring", "Invalid team scoring."); !v.Valid() {
+		s.renderScoringForm(w, r, http.StatusUnprocessableEntity, id, v.Errors)
+		return
+	}
+
+	err = s.TournamentService.SetTeamScoring(r.Context(), id, scoring)
 	if err != nil {
-		ServerErrorResponse(w, fmt.Sprintf("Failed to update tier: %s", err))
+		ServiceErrorResponse(w, r, err)
 		return
 	}
 
@@ -188,7 +421,7 @@ func (s *Server) putTournamentTier(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
-// deleteTournament deletes the given Tournament and all of its entrants.
+// deleteTournament moves the given Tournament to the trash.
 func (s *Server) deleteTournament(w http.ResponseWriter, r *http.Request) {
 	id, err := readIDParam(r)
 	if err != nil {
@@ -196,9 +429,9 @@ func (s *Server) deleteTournament(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = s.TournamentService.DeleteTournament(id)
+	err = s.TournamentService.DeleteTournament(r.Context(), id)
 	if err != nil {
-		ServerErrorResponse(w, fmt.Sprintf("Failed to delete tournament: %s", err))
+		ServiceErrorResponse(w, r, err)
 		return
 	}
 
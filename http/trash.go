@@ -0,0 +1,68 @@
+package http
+
+import (
+	"fmt"
+	tournament "github.com/ejacobg/tourney-tracker"
+	"net/http"
+)
+
+func (s *Server) registerTrashRoutes() {
+	s.handle(http.MethodGet, "/trash", tournament.RoleOrganizer, s.getTrash)
+	s.handle(http.MethodPut, "/tournaments/:id/restore", tournament.RoleOrganizer, s.putTournamentRestore)
+	s.handle(http.MethodPut, "/players/:id/restore", tournament.RoleOrganizer, s.putPlayerRestore)
+}
+
+// getTrash renders the deleted tournaments and players that can still be restored.
+func (s *Server) getTrash(w http.ResponseWriter, r *http.Request) {
+	tournaments, err := s.TournamentService.GetDeletedTournaments(r.Context())
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	players, err := s.PlayerService.GetDeletedPlayers(r.Context())
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	s.Render(w, r, 200, "trash/index.go.html", "base", map[string]any{
+		"Tournaments": tournaments,
+		"Players":     players,
+		"Retention":   s.TrashRetention,
+	})
+}
+
+// putTournamentRestore moves the given Tournament out of the trash, then returns a redirect to it.
+func (s *Server) putTournamentRestore(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		NotFoundResponse(w, "Invalid tournament ID.")
+		return
+	}
+
+	err = s.TournamentService.RestoreTournament(r.Context(), id)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	redirect(w, r, fmt.Sprintf("/tournaments/%d", id))
+}
+
+// putPlayerRestore moves the given Player out of the trash, then returns a redirect to them.
+func (s *Server) putPlayerRestore(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		NotFoundResponse(w, "Invalid player ID.")
+		return
+	}
+
+	err = s.PlayerService.RestorePlayer(r.Context(), id)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	redirect(w, r, fmt.Sprintf("/players/%d", id))
+}
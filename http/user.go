@@ -0,0 +1,140 @@
+package http
+
+import (
+	tournament "github.com/ejacobg/tourney-tracker"
+	"github.com/ejacobg/tourney-tracker/validator"
+	"net/http"
+)
+
+func (s *Server) registerUserRoutes() {
+	s.handle(http.MethodGet, "/users", tournament.RoleAdmin, s.getUsers)
+	s.handle(http.MethodPost, "/users/new", tournament.RoleAdmin, s.postUser)
+	s.handle(http.MethodPut, "/users/:id/role", tournament.RoleAdmin, s.putUserRole)
+	s.handle(http.MethodDelete, "/users/:id", tournament.RoleAdmin, s.deleteUser)
+}
+
+// getUsers renders a table of all users and their roles, as well as a form for adding a new User.
+func (s *Server) getUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := s.UserService.GetUsers(r.Context())
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	s.Render(w, r, 200, "users/index.go.html", "base", map[string]any{
+		"Users": users,
+		"Roles": tournament.Roles,
+	})
+}
+
+// postUser accepts form data consisting of "name", "password", and "role" fields.
+// If the User is created, a refresh of the users page will be returned.
+func (s *Server) postUser(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		BadRequestResponse(w, "Failed to parse form.")
+		return
+	}
+
+	user := tournament.User{
+		Name: r.PostForm.Get("name"),
+		Role: tournament.Role(r.PostForm.Get("role")),
+	}
+
+	password := r.PostForm.Get("password")
+
+	v := validator.New()
+	tournament.ValidateUser(v, &user)
+	tournament.ValidatePassword(v, password)
+
+	if !v.Valid() {
+		s.Render(w, r, http.StatusUnprocessableEntity, "users/index.go.html", "new", map[string]any{
+			"Roles":  tournament.Roles,
+			"Name":   user.Name,
+			"Role":   user.Role,
+			"Errors": v.Errors,
+		})
+		return
+	}
+
+	err = user.SetPassword(password)
+	if err != nil {
+		ServerErrorResponse(w, "Failed to hash password.")
+		return
+	}
+
+	err = s.UserService.CreateUser(r.Context(), &user)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	w.Header()["HX-Refresh"] = []string{"true"}
+	w.WriteHeader(http.StatusCreated)
+}
+
+// putUserRole accepts form data consisting of a "role" field, which will be applied to the given User.
+// Users cannot change their own role, so that admins do not lock themselves out. The UserService keeps at least one admin.
+func (s *Server) putUserRole(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		NotFoundResponse(w, "Invalid user ID.")
+		return
+	}
+
+	if id == contextGetUser(r).ID {
+		UnprocessableEntityResponse(w, "You cannot change your own role.")
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		BadRequestResponse(w, "Failed to parse form.")
+		return
+	}
+
+	role := tournament.Role(r.PostForm.Get("role"))
+	if !role.Valid() {
+		UnprocessableEntityResponse(w, "Invalid role.")
+		return
+	}
+
+	user, err := s.UserService.GetUser(r.Context(), id)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	user.Role = role
+
+	err = s.UserService.UpdateUser(r.Context(), &user)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	w.Header()["HX-Refresh"] = []string{"true"}
+	w.WriteHeader(http.StatusOK)
+}
+
+// deleteUser deletes the given User. Users cannot delete themselves.
+func (s *Server) deleteUser(w http.ResponseWriter, r *http.Request) {
+	id, err := readIDParam(r)
+	if err != nil {
+		NotFoundResponse(w, "Invalid user ID.")
+		return
+	}
+
+	if id == contextGetUser(r).ID {
+		UnprocessableEntityResponse(w, "You cannot delete yourself.")
+		return
+	}
+
+	err = s.UserService.DeleteUser(r.Context(), id)
+	if err != nil {
+		ServiceErrorResponse(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,88 @@
+package inmem
+
+import (
+	"context"
+	tournament "github.com/ejacobg/tourney-tracker"
+	"golang.org/x/exp/slices"
+	"strings"
+	"time"
+)
+
+// AuditService represents a service for reading the changes made to the tracker.
+type AuditService struct {
+	DB *DB
+}
+
+func (as AuditService) GetEntries(_ context.Context, filter tournament.EntryFilter) (entries []tournament.Entry, err error) {
+	as.DB.mu.Lock()
+	defer as.DB.mu.Unlock()
+
+	for _, entry := range as.DB.entries {
+		if filter.Actor != "" && !strings.Contains(strings.ToLower(entry.Actor), strings.ToLower(filter.Actor)) {
+			continue
+		}
+		if (filter.Action != "" && entry.Action != filter.Action) ||
+			(filter.Subject != "" && entry.Action.Subject() != filter.Subject) ||
+			(filter.SubjectID != 0 && entry.SubjectID != filter.SubjectID) {
+			continue
+		}
+
+		entries = append(entries, cloneEntry(entry))
+	}
+
+	slices.SortStableFunc(entries, func(a, b tournament.Entry) bool {
+		return a.CreatedAt.After(b.CreatedAt) || (a.CreatedAt.Equal(b.CreatedAt) && a.ID > b.ID)
+	})
+
+	// A limit of 0 returns every entry.
+	if filter.Offset >= len(entries) {
+		return nil, nil
+	}
+	entries = entries[filter.Offset:]
+	if filter.Limit != 0 && filter.Limit < len(entries) {
+		entries = entries[:filter.Limit]
+	}
+
+	return entries, nil
+}
+
+// createEntry records the given change, made by the actor of the context.
+// It should be called once every other check has passed, but before the change is made, so that the change is only made if it is recorded.
+func (db *DB) createEntry(ctx context.Context, action tournament.Action, subjectID int64, before, after any) error {
+	if err := db.checkActor(ctx); err != nil {
+		return err
+	}
+
+	entry, err := tournament.NewEntry(ctx, action, subjectID, before, after)
+	if err != nil {
+		return err
+	}
+
+	entry.ID = db.nextID("audit_log")
+	entry.CreatedAt = time.Now()
+	db.entries = append(db.entries, entry)
+
+	return nil
+}
+
+// checkActor returns an error if the actor of the context is a User who does not exist, the same as the foreign key of the audit log would.
+func (db *DB) checkActor(ctx context.Context) error {
+	if userID, _ := tournament.ActorOf(ctx); userID != nil {
+		if _, ok := db.users[*userID]; !ok {
+			return tournament.Errorf(tournament.EINVALID, "That user does not exist.")
+		}
+	}
+
+	return nil
+}
+
+// cloneEntry returns a copy of the Entry that does not share its JSON or user ID with it.
+func cloneEntry(entry tournament.Entry) tournament.Entry {
+	entry.Before = slices.Clone(entry.Before)
+	entry.After = slices.Clone(entry.After)
+	if entry.UserID != nil {
+		userID := *entry.UserID
+		entry.UserID = &userID
+	}
+	return entry
+}
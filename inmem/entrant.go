@@ -0,0 +1,238 @@
+package inmem
+
+import (
+	"context"
+	tournament "github.com/ejacobg/tourney-tracker"
+	"golang.org/x/exp/slices"
+)
+
+// EntrantService represents a service for managing entrants.
+type EntrantService struct {
+	DB *DB
+}
+
+func (es EntrantService) GetEntrants(_ context.Context, tournamentID int64) (entrants []tournament.Entrant, err error) {
+	es.DB.mu.Lock()
+	defer es.DB.mu.Unlock()
+
+	for _, id := range sortedKeys(es.DB.entrants) {
+		if entrant := es.DB.entrants[id]; entrant.TournamentID == tournamentID {
+			entrants = append(entrants, es.DB.getEntrant(entrant))
+		}
+	}
+
+	return entrants, nil
+}
+
+// getEntrant returns a copy of the given Entrant, with its players filled in. Players in the trash are left out.
+func (db *DB) getEntrant(entrant tournament.Entrant) tournament.Entrant {
+	entrant = cloneEntrant(entrant)
+	entrant.Players = nil
+
+	for _, l := range db.links {
+		if p := db.players[l.playerID]; l.entrantID == entrant.ID && p.deletedAt == nil {
+			entrant.Players = append(entrant.Players, p.Player)
+		}
+	}
+
+	slices.SortStableFunc(entrant.Players, func(a, b tournament.Player) bool {
+		return a.Name < b.Name
+	})
+	return entrant
+}
+
+func (es EntrantService) GetEntrantWithPoints(_ context.Context, id int64) (entrant tournament.Entrant, points int, err error) {
+	es.DB.mu.Lock()
+	defer es.DB.mu.Unlock()
+
+	stored, ok := es.DB.entrants[id]
+	if !ok {
+		return entrant, 0, tournament.Errorf(tournament.ENOTFOUND, "Entrant not found.")
+	}
+	entrant = es.DB.getEntrant(stored)
+
+	tourney, err := es.DB.getTournament(entrant.TournamentID)
+	if err != nil {
+		return
+	}
+
+	// Calculate points.
+	points, ok = es.DB.formula.Points(tourney, entrant.Placement)
+	if !ok {
+		err = tournament.Errorf(tournament.EINTERNAL, "Entrant %d has placement %d, which is not one of the placements of their tournament.", entrant.ID, entrant.Placement)
+	}
+
+	return
+}
+
+func (es EntrantService) GetAttendance(_ context.Context, playerID int64) (attendance []tournament.Attendee, err error) {
+	es.DB.mu.Lock()
+	defer es.DB.mu.Unlock()
+
+	for _, l := range es.DB.links {
+		t := es.DB.tournaments[l.tournamentID]
+		if l.playerID != playerID || t.deletedAt != nil {
+			continue
+		}
+
+		var attendee tournament.Attendee
+		attendee.Tournament.ID = t.ID
+		attendee.Tournament.Name = t.Name
+		attendee.Tournament.Tier = es.DB.tiers[t.Tier.ID].Name
+		attendee.Entrant.Name = es.DB.entrants[l.entrantID].Name
+		attendee.Entrant.Placement = es.DB.entrants[l.entrantID].Placement
+
+		attendance = append(attendance, attendee)
+	}
+
+	return attendance, nil
+}
+
+func (es EntrantService) CreateEntrants(_ context.Context, entrants []tournament.Entrant, tournamentID int64) error {
+	es.DB.mu.Lock()
+	defer es.DB.mu.Unlock()
+
+	t, ok := es.DB.tournaments[tournamentID]
+	if !ok {
+		return tournament.Errorf(tournament.EINVALID, "That tournament does not exist.")
+	}
+
+	orders := make(map[int]bool)
+	for _, phase := range t.Phases {
+		orders[phase.Order] = true
+	}
+	for _, entrant := range entrants {
+		if err := checkResults(entrant.Results, orders); err != nil {
+			return err
+		}
+	}
+
+	es.DB.createEntrants(entrants, tournamentID)
+
+	return nil
+}
+
+// checkResults returns an error if an Entrant would have more than one Result for the same Phase.
+// Only results for the given phase orders are counted, since results for other phases are not kept.
+func checkResults(results []tournament.Result, orders map[int]bool) error {
+	seen := make(map[int]bool)
+	for _, result := range results {
+		if !orders[result.Phase] {
+			continue
+		}
+		if seen[result.Phase] {
+			return tournament.Errorf(tournament.ECONFLICT, "This conflicts with existing data.")
+		}
+		seen[result.Phase] = true
+	}
+	return nil
+}
+
+// createEntrants adds the given entrants to a Tournament, updating their IDs and versions. The entrants should already be checked.
+func (db *DB) createEntrants(entrants []tournament.Entrant, tournamentID int64) {
+	t := db.tournaments[tournamentID]
+
+	for i := range entrants {
+		entrants[i].ID = db.nextID("entrants")
+		entrants[i].Version = 1
+
+		stored := cloneEntrant(entrants[i])
+		stored.TournamentID = tournamentID
+		stored.Players = nil
+
+		// Results are only kept for phases of the Tournament, ordered by Phase.
+		stored.Results = nil
+		for _, phase := range t.Phases {
+			for _, result := range entrants[i].Results {
+				if result.Phase == phase.Order {
+					stored.Results = append(stored.Results, result)
+				}
+			}
+		}
+
+		db.entrants[stored.ID] = stored
+	}
+}
+
+func (es EntrantService) SetPlayers(ctx context.Context, entrantID int64, version int, playerIDs []int64) error {
+	es.DB.mu.Lock()
+	defer es.DB.mu.Unlock()
+
+	entrant, ok := es.DB.entrants[entrantID]
+	if !ok {
+		return tournament.Errorf(tournament.ENOTFOUND, "Entrant not found.")
+	}
+	if version != 0 && version != entrant.Version {
+		return tournament.Errorf(tournament.ECONFLICT, "The entrant was changed by someone else.")
+	}
+
+	// Links to trashed players are kept, since they are hidden from the caller and should come back if the Player is restored.
+	// The new links are built separately, so that nothing is changed if any of them cannot be made.
+	var links []link
+	for _, l := range es.DB.links {
+		if l.entrantID != entrantID || es.DB.players[l.playerID].deletedAt != nil {
+			links = append(links, l)
+		}
+	}
+
+	for _, playerID := range playerIDs {
+		if _, ok := es.DB.players[playerID]; !ok {
+			return tournament.Errorf(tournament.EINVALID, "That player does not exist.")
+		}
+
+		for _, l := range links {
+			if l.playerID != playerID {
+				continue
+			}
+			if l.entrantID == entrantID {
+				return tournament.Errorf(tournament.ECONFLICT, "That player is already linked to this entrant.")
+			}
+			if l.tournamentID == entrant.TournamentID {
+				return tournament.Errorf(tournament.ECONFLICT, "That player is already linked to another entrant in this tournament.")
+			}
+		}
+
+		links = append(links, link{entrantID: entrantID, playerID: playerID, tournamentID: entrant.TournamentID})
+	}
+
+	// The players of the Entrant are recorded, rather than the whole Entrant.
+	// The new links are only swapped in to read the players after the change, until the change has been recorded.
+	before := es.DB.getEntrant(entrant).Players
+	old := es.DB.links
+	es.DB.links = links
+	after := es.DB.getEntrant(entrant).Players
+	es.DB.links = old
+
+	if err := es.DB.createEntry(ctx, tournament.ActionSetPlayers, entrantID, before, after); err != nil {
+		return err
+	}
+
+	entrant.Version++
+	es.DB.entrants[entrantID] = entrant
+	es.DB.links = links
+
+	return nil
+}
+
+// Deleting a Tournament will also delete its entrants, but this will still be implemented.
+func (es EntrantService) DeleteEntrants(_ context.Context, tournamentID int64) error {
+	es.DB.mu.Lock()
+	defer es.DB.mu.Unlock()
+
+	es.DB.deleteEntrants(tournamentID)
+
+	return nil
+}
+
+// deleteEntrants deletes the entrants of the given Tournament, along with their results and links to players.
+func (db *DB) deleteEntrants(tournamentID int64) {
+	for id, entrant := range db.entrants {
+		if entrant.TournamentID == tournamentID {
+			delete(db.entrants, id)
+		}
+	}
+
+	db.deleteLinks(func(l link) bool {
+		return l.tournamentID == tournamentID
+	})
+}
@@ -0,0 +1,35 @@
+package inmem
+
+import (
+	"context"
+	tournament "github.com/ejacobg/tourney-tracker"
+)
+
+// FormulaService represents a service for managing the point formula.
+type FormulaService struct {
+	DB *DB
+}
+
+func (fs FormulaService) GetFormula(_ context.Context) (tournament.Formula, error) {
+	fs.DB.mu.Lock()
+	defer fs.DB.mu.Unlock()
+
+	return fs.DB.formula, nil
+}
+
+func (fs FormulaService) UpdateFormula(ctx context.Context, formula tournament.Formula) error {
+	fs.DB.mu.Lock()
+	defer fs.DB.mu.Unlock()
+
+	if formula.UP < 0 || formula.ATT < 0 || formula.FIRST < 0 || formula.BR < 0 {
+		return tournament.Errorf(tournament.EINVALID, "Invalid value.")
+	}
+
+	// There is only one Formula, so its entries have no subject ID.
+	if err := fs.DB.createEntry(ctx, tournament.ActionUpdateFormula, 0, fs.DB.formula, formula); err != nil {
+		return err
+	}
+
+	fs.DB.formula = formula
+	return nil
+}
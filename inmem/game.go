@@ -0,0 +1,61 @@
+package inmem
+
+import (
+	"context"
+	tournament "github.com/ejacobg/tourney-tracker"
+	"golang.org/x/exp/slices"
+)
+
+// GameService represents a service for managing games.
+type GameService struct {
+	DB *DB
+}
+
+func (gs GameService) GetGames(_ context.Context) (games []tournament.Game, err error) {
+	gs.DB.mu.Lock()
+	defer gs.DB.mu.Unlock()
+
+	for _, id := range sortedKeys(gs.DB.games) {
+		games = append(games, gs.DB.games[id])
+	}
+
+	slices.SortStableFunc(games, func(a, b tournament.Game) bool {
+		return a.Name < b.Name
+	})
+
+	return games, nil
+}
+
+func (gs GameService) GetGame(_ context.Context, id int64) (tournament.Game, error) {
+	gs.DB.mu.Lock()
+	defer gs.DB.mu.Unlock()
+
+	game, ok := gs.DB.games[id]
+	if !ok {
+		return game, tournament.Errorf(tournament.ENOTFOUND, "Game not found.")
+	}
+
+	return game, nil
+}
+
+func (gs GameService) CreateGame(_ context.Context, game *tournament.Game) error {
+	gs.DB.mu.Lock()
+	defer gs.DB.mu.Unlock()
+
+	gs.DB.createGame(game)
+
+	return nil
+}
+
+// createGame adds the given Game, or finds the ID of an existing Game with the same name.
+func (db *DB) createGame(game *tournament.Game) {
+	for _, existing := range db.games {
+		if existing.Name == game.Name {
+			game.ID = existing.ID
+			return
+		}
+	}
+
+	game.ID = db.nextID("games")
+	db.games[game.ID] = *game
+}
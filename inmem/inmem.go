@@ -0,0 +1,174 @@
+// Package inmem implements the tracker's services in memory. Nothing is saved when the program exits, which makes it useful for tests and demos.
+// The services behave the same as the postgres ones, including the cascades and constraints of its schema,
+// so that code tested against this package can be trusted to work with a real database.
+package inmem
+
+import (
+	tournament "github.com/ejacobg/tourney-tracker"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	"sync"
+	"time"
+)
+
+// DB holds all the data of the tracker. Each service of this package is given the same *DB, and locks it for the whole of each call,
+// so that every call sees and leaves the data in a consistent state, the same as a transaction would.
+type DB struct {
+	mu sync.Mutex
+
+	data
+
+	users     map[int64]tournament.User
+	sessions  map[string]tournament.Session // Keyed by the hash of the session token.
+	tokens    map[int64]token
+	entries   []tournament.Entry
+	snapshots map[int64]snapshot
+
+	// lastIDs holds the last ID given out for each table. IDs are never reused, even after a Snapshot is restored.
+	lastIDs map[string]int64
+}
+
+// data holds the rows of every table saved in a Snapshot.
+type data struct {
+	tiers       map[int64]tournament.Tier
+	games       map[int64]tournament.Game
+	players     map[int64]player
+	tournaments map[int64]tourney
+	entrants    map[int64]tournament.Entrant // Players are kept in links rather than on each Entrant.
+	links       []link
+	formula     tournament.Formula
+}
+
+// player is a Player, along with the time it was moved to the trash.
+type player struct {
+	tournament.Player
+	deletedAt *time.Time
+}
+
+// tourney is a Tournament, along with the time it was moved to the trash. Only the IDs of its Tier and Game are kept, so that changes to them are seen.
+type tourney struct {
+	tournament.Tournament
+	deletedAt *time.Time
+}
+
+// link assigns a Player to an Entrant. The Tournament is kept so that a Player can only be assigned to one Entrant per Tournament.
+type link struct {
+	entrantID, playerID, tournamentID int64
+}
+
+// NewDB returns an empty DB holding only the default tiers.
+func NewDB() *DB {
+	db := &DB{
+		data: data{
+			tiers:       make(map[int64]tournament.Tier),
+			games:       make(map[int64]tournament.Game),
+			players:     make(map[int64]player),
+			tournaments: make(map[int64]tourney),
+			entrants:    make(map[int64]tournament.Entrant),
+			formula:     tournament.DefaultFormula,
+		},
+		users:     make(map[int64]tournament.User),
+		sessions:  make(map[string]tournament.Session),
+		tokens:    make(map[int64]token),
+		snapshots: make(map[int64]snapshot),
+		lastIDs:   make(map[string]int64),
+	}
+
+	// These are the default tiers the program will start with.
+	for _, tier := range []tournament.Tier{{Name: "C", Multiplier: 75}, {Name: "B", Multiplier: 150}, {Name: "A", Multiplier: 200}, {Name: "S", Multiplier: 300}} {
+		tier.ID = db.nextID("tiers")
+		db.tiers[tier.ID] = tier
+	}
+
+	return db
+}
+
+// NewServices returns an implementation of every service, all using the given database.
+func NewServices(db *DB) tournament.Services {
+	return tournament.Services{
+		AuditService:      AuditService{DB: db},
+		EntrantService:    EntrantService{DB: db},
+		FormulaService:    FormulaService{DB: db},
+		GameService:       GameService{DB: db},
+		PlayerService:     PlayerService{DB: db},
+		SessionService:    SessionService{DB: db},
+		SnapshotService:   SnapshotService{DB: db},
+		TierService:       TierService{DB: db},
+		TokenService:      TokenService{DB: db},
+		TournamentService: TournamentService{DB: db},
+		UserService:       UserService{DB: db},
+	}
+}
+
+// nextID returns a new ID for the given table.
+func (db *DB) nextID(table string) int64 {
+	db.lastIDs[table]++
+	return db.lastIDs[table]
+}
+
+// clone returns a deep copy of the data, so that changes to one copy are not seen by the other.
+func (d data) clone() data {
+	c := data{
+		tiers:       maps.Clone(d.tiers),
+		games:       maps.Clone(d.games),
+		players:     maps.Clone(d.players),
+		tournaments: make(map[int64]tourney, len(d.tournaments)),
+		entrants:    make(map[int64]tournament.Entrant, len(d.entrants)),
+		links:       slices.Clone(d.links),
+		formula:     d.formula,
+	}
+	for id, t := range d.tournaments {
+		c.tournaments[id] = t.clone()
+	}
+	for id, e := range d.entrants {
+		c.entrants[id] = cloneEntrant(e)
+	}
+	return c
+}
+
+// clone returns a copy of the Tournament that does not share any slices with it.
+func (t tourney) clone() tourney {
+	t.Tournament = cloneTournament(t.Tournament)
+	return t
+}
+
+// cloneTournament returns a copy of the Tournament that does not share any slices with it.
+func cloneTournament(t tournament.Tournament) tournament.Tournament {
+	t.Placements = slices.Clone(t.Placements)
+	t.Phases = slices.Clone(t.Phases)
+	return t
+}
+
+// cloneEntrant returns a copy of the Entrant that does not share any slices with it.
+func cloneEntrant(e tournament.Entrant) tournament.Entrant {
+	e.Participants = slices.Clone(e.Participants)
+	e.Results = slices.Clone(e.Results)
+	e.Players = slices.Clone(e.Players)
+	return e
+}
+
+// deleteLinks removes every link that the given function returns true for.
+func (db *DB) deleteLinks(match func(l link) bool) {
+	kept := db.links[:0]
+	for _, l := range db.links {
+		if !match(l) {
+			kept = append(kept, l)
+		}
+	}
+	db.links = kept
+}
+
+// sortedKeys returns the keys of the map in ascending order. Rows are returned in the order that they were created, the same as a database would without an ORDER BY.
+func sortedKeys[V any](m map[int64]V) []int64 {
+	keys := maps.Keys(m)
+	slices.Sort(keys)
+	return keys
+}
+
+// sortTrashed sorts the trashed objects so that the most recently deleted come first.
+func sortTrashed(trashed []tournament.Trashed) []tournament.Trashed {
+	slices.SortStableFunc(trashed, func(a, b tournament.Trashed) bool {
+		return a.DeletedAt.After(b.DeletedAt)
+	})
+	return trashed
+}
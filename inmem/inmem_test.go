@@ -0,0 +1,148 @@
+package inmem
+
+import (
+	"context"
+	tournament "github.com/ejacobg/tourney-tracker"
+	"github.com/ejacobg/tourney-tracker/servicetest"
+	"testing"
+	"time"
+)
+
+// TestDB_cascades checks that the DB removes the same rows that the database's foreign keys would.
+func TestDB_cascades(t *testing.T) {
+	ctx := context.Background()
+	db := NewDB()
+	ts, es, ps := TournamentService{DB: db}, EntrantService{DB: db}, PlayerService{DB: db}
+
+	tourney := tournament.Tournament{Name: "Weekly", BracketType: tournament.DoubleElimination, Placements: []int64{2, 1}}
+	entrants := []tournament.Entrant{{Name: "A", Placement: 1}, {Name: "B", Placement: 2}}
+	if err := ts.CreateTournament(ctx, &tourney, entrants); err != nil {
+		t.Fatalf("CreateTournament() error = %v", err)
+	}
+
+	mango, armada := tournament.Player{Name: "Mango"}, tournament.Player{Name: "Armada"}
+	for _, p := range []*tournament.Player{&mango, &armada} {
+		if err := ps.CreatePlayer(ctx, p); err != nil {
+			t.Fatalf("CreatePlayer() error = %v", err)
+		}
+	}
+
+	if err := es.SetPlayers(ctx, entrants[0].ID, 0, []int64{mango.ID, armada.ID}); err != nil {
+		t.Fatalf("SetPlayers() error = %v", err)
+	}
+
+	// A Player can only be linked to one Entrant per Tournament.
+	err := es.SetPlayers(ctx, entrants[1].ID, 0, []int64{mango.ID})
+	if tournament.ErrorCode(err) != tournament.ECONFLICT {
+		t.Errorf("SetPlayers() of a linked player error = %v, want %s", err, tournament.ECONFLICT)
+	}
+
+	// Purging a Player removes it from its Entrant, without removing the Entrant.
+	if err = ps.DeletePlayer(ctx, mango.ID); err != nil {
+		t.Fatalf("DeletePlayer() error = %v", err)
+	}
+	if err = ps.PurgePlayers(ctx, time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("PurgePlayers() error = %v", err)
+	}
+
+	got, _, err := es.GetEntrantWithPoints(ctx, entrants[0].ID)
+	if err != nil {
+		t.Fatalf("GetEntrantWithPoints() error = %v", err)
+	}
+	if len(got.Players) != 1 || got.Players[0].ID != armada.ID {
+		t.Errorf("GetEntrantWithPoints() players = %+v, want only %s", got.Players, armada.Name)
+	}
+
+	// Purging a Tournament removes its entrants, and the links to their players.
+	if err = ts.DeleteTournament(ctx, tourney.ID); err != nil {
+		t.Fatalf("DeleteTournament() error = %v", err)
+	}
+	if err = ts.PurgeTournaments(ctx, time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("PurgeTournaments() error = %v", err)
+	}
+
+	if _, _, err = es.GetEntrantWithPoints(ctx, entrants[0].ID); tournament.ErrorCode(err) != tournament.ENOTFOUND {
+		t.Errorf("GetEntrantWithPoints() of a purged entrant error = %v, want %s", err, tournament.ENOTFOUND)
+	}
+	if attendance, _ := es.GetAttendance(ctx, armada.ID); len(attendance) != 0 {
+		t.Errorf("GetAttendance() = %+v, want no attendance", attendance)
+	}
+}
+
+func TestSnapshotService_RestoreSnapshot(t *testing.T) {
+	ctx := context.Background()
+	db := NewDB()
+	ss, ps := SnapshotService{DB: db}, PlayerService{DB: db}
+
+	mango := tournament.Player{Name: "Mango"}
+	if err := ps.CreatePlayer(ctx, &mango); err != nil {
+		t.Fatalf("CreatePlayer() error = %v", err)
+	}
+
+	snapshot := tournament.Snapshot{Name: "Before"}
+	if err := ss.CreateSnapshot(ctx, &snapshot); err != nil {
+		t.Fatalf("CreateSnapshot() error = %v", err)
+	}
+
+	mango.Name = "Mang0"
+	if err := ps.UpdatePlayer(ctx, &mango); err != nil {
+		t.Fatalf("UpdatePlayer() error = %v", err)
+	}
+
+	// Restoring twice checks that the Snapshot was not changed by the first restore.
+	for i := 0; i < 2; i++ {
+		if err := ss.RestoreSnapshot(ctx, snapshot.ID); err != nil {
+			t.Fatalf("RestoreSnapshot() error = %v", err)
+		}
+
+		got, err := ps.GetPlayer(ctx, mango.ID)
+		if err != nil {
+			t.Fatalf("GetPlayer() error = %v", err)
+		}
+		if got.Name != "Mango" || got.Version != 1 {
+			t.Errorf("GetPlayer() = %+v, want the player from before the change", got)
+		}
+
+		if err = ps.UpdatePlayer(ctx, &tournament.Player{ID: mango.ID, Name: "Changed"}); err != nil {
+			t.Fatalf("UpdatePlayer() error = %v", err)
+		}
+	}
+
+	// New rows do not reuse IDs from before the restore.
+	armada := tournament.Player{Name: "Armada"}
+	if err := ps.CreatePlayer(ctx, &armada); err != nil {
+		t.Fatalf("CreatePlayer() error = %v", err)
+	}
+	if armada.ID <= mango.ID {
+		t.Errorf("CreatePlayer() ID = %d, want it to be greater than %d", armada.ID, mango.ID)
+	}
+}
+
+func TestTokenService_AuthenticateToken(t *testing.T) {
+	ctx := context.Background()
+	db := NewDB()
+	us, ts := UserService{DB: db}, TokenService{DB: db}
+
+	user := tournament.User{Name: "organizer", Role: tournament.RoleOrganizer, PasswordHash: []byte("hash")}
+	if err := us.CreateUser(ctx, &user); err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	if err := ts.CreateToken(ctx, &tournament.Token{Plaintext: "secret", Label: "bot", Role: tournament.RoleOrganizer, UserID: user.ID}); err != nil {
+		t.Fatalf("CreateToken() error = %v", err)
+	}
+
+	// A demoted User's tokens lose the permissions that the User lost.
+	user.Role = tournament.RoleEditor
+	if err := us.UpdateUser(ctx, &user); err != nil {
+		t.Fatalf("UpdateUser() error = %v", err)
+	}
+	if got, err := ts.AuthenticateToken(ctx, "secret"); err != nil || got.Role != tournament.RoleEditor {
+		t.Errorf("AuthenticateToken() after demotion = %+v, %v, want the editor role", got, err)
+	}
+}
+
+func TestServices(t *testing.T) {
+	servicetest.Run(t, func(t *testing.T) tournament.Services {
+		return NewServices(NewDB())
+	})
+}
@@ -0,0 +1,242 @@
+package inmem
+
+import (
+	"context"
+	tournament "github.com/ejacobg/tourney-tracker"
+	"golang.org/x/exp/slices"
+	"time"
+)
+
+// PlayerService represents a service for managing players.
+type PlayerService struct {
+	DB *DB
+}
+
+func (ps PlayerService) GetPlayers(_ context.Context, gameID int64) (players []tournament.Player, err error) {
+	ps.DB.mu.Lock()
+	defer ps.DB.mu.Unlock()
+
+	for _, id := range sortedKeys(ps.DB.players) {
+		p := ps.DB.players[id]
+		if p.deletedAt != nil || (gameID != 0 && !ps.DB.playedGame(id, gameID)) {
+			continue
+		}
+
+		players = append(players, p.Player)
+	}
+
+	return players, nil
+}
+
+// playedGame returns true if the Player is linked to an Entrant of a Tournament of the given Game, which is not in the trash.
+func (db *DB) playedGame(playerID, gameID int64) bool {
+	for _, l := range db.links {
+		if l.playerID != playerID {
+			continue
+		}
+		if t := db.tournaments[l.tournamentID]; t.deletedAt == nil && t.Game.ID == gameID {
+			return true
+		}
+	}
+	return false
+}
+
+func (ps PlayerService) GetPlayer(_ context.Context, id int64) (tournament.Player, error) {
+	ps.DB.mu.Lock()
+	defer ps.DB.mu.Unlock()
+
+	p, ok := ps.DB.players[id]
+	if !ok || p.deletedAt != nil {
+		return tournament.Player{}, tournament.Errorf(tournament.ENOTFOUND, "Player not found.")
+	}
+
+	return p.Player, nil
+}
+
+func (ps PlayerService) GetRanks(_ context.Context, filter tournament.RankFilter) ([]tournament.Rank, error) {
+	ps.DB.mu.Lock()
+	defer ps.DB.mu.Unlock()
+
+	var ranks []tournament.Rank
+	for _, id := range sortedKeys(ps.DB.players) {
+		p := ps.DB.players[id]
+		if p.deletedAt != nil {
+			continue
+		}
+
+		rank := tournament.Rank{Player: p.Player}
+		counted := false
+
+		// Only tournaments counting towards the chosen leaderboard are counted.
+		for _, l := range ps.DB.links {
+			t := ps.DB.tournaments[l.tournamentID]
+			if l.playerID != id || t.deletedAt != nil {
+				continue
+			}
+			if (t.Teams && t.TeamScoring == tournament.SeparateLeaderboard) != filter.Doubles || (filter.GameID != 0 && t.Game.ID != filter.GameID) {
+				continue
+			}
+
+			t.Tier = ps.DB.tiers[t.Tier.ID]
+			points, _ := ps.DB.formula.Points(t.Tournament, ps.DB.entrants[l.entrantID].Placement)
+			rank.Points += points
+			counted = true
+		}
+
+		// Filtered rankings only include players who have played in a matching tournament.
+		if filter.Filtered() && !counted {
+			continue
+		}
+
+		ranks = append(ranks, rank)
+	}
+
+	// Sort our ranks in descending order.
+	slices.SortStableFunc(ranks, func(a, b tournament.Rank) bool {
+		return a.Points > b.Points
+	})
+
+	return ranks, nil
+}
+
+func (ps PlayerService) CreatePlayer(ctx context.Context, p *tournament.Player) error {
+	ps.DB.mu.Lock()
+	defer ps.DB.mu.Unlock()
+
+	if ps.DB.playerNameTaken(p.Name, 0) {
+		return tournament.Errorf(tournament.ECONFLICT, "Another player already has that name.")
+	}
+
+	p.ID = ps.DB.nextID("players")
+	p.Version = 1
+
+	if err := ps.DB.createEntry(ctx, tournament.ActionCreatePlayer, p.ID, nil, p); err != nil {
+		return err
+	}
+
+	ps.DB.players[p.ID] = player{Player: *p}
+
+	return nil
+}
+
+// playerNameTaken returns true if a Player other than the given one has the given name. Players in the trash do not keep their names.
+func (db *DB) playerNameTaken(name string, id int64) bool {
+	for _, p := range db.players {
+		if p.Name == name && p.ID != id && p.deletedAt == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (ps PlayerService) UpdatePlayer(ctx context.Context, p *tournament.Player) error {
+	ps.DB.mu.Lock()
+	defer ps.DB.mu.Unlock()
+
+	existing, ok := ps.DB.players[p.ID]
+	if !ok || existing.deletedAt != nil {
+		return tournament.Errorf(tournament.ENOTFOUND, "Player not found.")
+	}
+	if p.Version != 0 && p.Version != existing.Version {
+		return tournament.Errorf(tournament.ECONFLICT, "The player was changed by someone else.")
+	}
+	if ps.DB.playerNameTaken(p.Name, p.ID) {
+		return tournament.Errorf(tournament.ECONFLICT, "Another player already has that name.")
+	}
+
+	before := existing.Player
+	existing.Name = p.Name
+	existing.Version++
+
+	if err := ps.DB.createEntry(ctx, tournament.ActionUpdatePlayer, p.ID, before, existing.Player); err != nil {
+		return err
+	}
+
+	ps.DB.players[p.ID] = existing
+	p.Version = existing.Version
+
+	return nil
+}
+
+func (ps PlayerService) DeletePlayer(ctx context.Context, id int64) error {
+	ps.DB.mu.Lock()
+	defer ps.DB.mu.Unlock()
+
+	p, ok := ps.DB.players[id]
+	if !ok || p.deletedAt != nil {
+		return tournament.Errorf(tournament.ENOTFOUND, "Player not found.")
+	}
+
+	if err := ps.DB.createEntry(ctx, tournament.ActionDeletePlayer, id, p.Player, nil); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	p.deletedAt = &now
+	ps.DB.players[id] = p
+
+	return nil
+}
+
+func (ps PlayerService) GetDeletedPlayers(_ context.Context) (trashed []tournament.Trashed, err error) {
+	ps.DB.mu.Lock()
+	defer ps.DB.mu.Unlock()
+
+	for _, id := range sortedKeys(ps.DB.players) {
+		if p := ps.DB.players[id]; p.deletedAt != nil {
+			trashed = append(trashed, tournament.Trashed{ID: p.ID, Name: p.Name, DeletedAt: *p.deletedAt})
+		}
+	}
+
+	return sortTrashed(trashed), nil
+}
+
+func (ps PlayerService) RestorePlayer(ctx context.Context, id int64) error {
+	ps.DB.mu.Lock()
+	defer ps.DB.mu.Unlock()
+
+	p, ok := ps.DB.players[id]
+	if !ok || p.deletedAt == nil {
+		return tournament.Errorf(tournament.ENOTFOUND, "Player not found in the trash.")
+	}
+
+	// Another Player may have taken the name since this one was moved to the trash.
+	if ps.DB.playerNameTaken(p.Name, id) {
+		return tournament.Errorf(tournament.ECONFLICT, "Another player already has that name.")
+	}
+
+	if err := ps.DB.createEntry(ctx, tournament.ActionRestorePlayer, id, nil, p.Player); err != nil {
+		return err
+	}
+
+	p.deletedAt = nil
+	ps.DB.players[id] = p
+
+	return nil
+}
+
+func (ps PlayerService) PurgePlayers(ctx context.Context, before time.Time) error {
+	ps.DB.mu.Lock()
+	defer ps.DB.mu.Unlock()
+
+	for _, id := range sortedKeys(ps.DB.players) {
+		if p := ps.DB.players[id]; p.deletedAt != nil && p.deletedAt.Before(before) {
+			trashed := tournament.Trashed{ID: p.ID, Name: p.Name, DeletedAt: *p.deletedAt}
+			if err := ps.DB.createEntry(ctx, tournament.ActionPurgePlayer, id, trashed, nil); err != nil {
+				return err
+			}
+
+			ps.DB.deletePlayer(id)
+		}
+	}
+
+	return nil
+}
+
+// deletePlayer permanently deletes the given Player, and removes it from any entrants pointing to it.
+func (db *DB) deletePlayer(id int64) {
+	delete(db.players, id)
+	db.deleteLinks(func(l link) bool {
+		return l.playerID == id
+	})
+}
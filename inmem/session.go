@@ -0,0 +1,69 @@
+package inmem
+
+import (
+	"context"
+	tournament "github.com/ejacobg/tourney-tracker"
+	"time"
+)
+
+// SessionService represents a service for managing sessions.
+type SessionService struct {
+	DB *DB
+}
+
+func (ss SessionService) CreateSession(_ context.Context, session tournament.Session) error {
+	ss.DB.mu.Lock()
+	defer ss.DB.mu.Unlock()
+
+	if _, ok := ss.DB.users[session.UserID]; !ok {
+		return tournament.Errorf(tournament.EINVALID, "That user does not exist.")
+	}
+
+	// Only the hash of the token is kept, the same as the other services.
+	hash := string(tournament.HashToken(session.Token))
+	if _, ok := ss.DB.sessions[hash]; ok {
+		return tournament.Errorf(tournament.ECONFLICT, "This conflicts with existing data.")
+	}
+
+	session.Token = ""
+	ss.DB.sessions[hash] = session
+
+	return nil
+}
+
+func (ss SessionService) GetSessionUser(_ context.Context, token string) (tournament.User, error) {
+	ss.DB.mu.Lock()
+	defer ss.DB.mu.Unlock()
+
+	session, ok := ss.DB.sessions[string(tournament.HashToken(token))]
+	if ok && session.Expiry.After(time.Now()) {
+		if user, ok := ss.DB.users[session.UserID]; ok {
+			return user, nil
+		}
+	}
+
+	return tournament.User{}, tournament.Errorf(tournament.ENOTFOUND, "Session not found.")
+}
+
+func (ss SessionService) DeleteSession(_ context.Context, token string) error {
+	ss.DB.mu.Lock()
+	defer ss.DB.mu.Unlock()
+
+	delete(ss.DB.sessions, string(tournament.HashToken(token)))
+
+	return nil
+}
+
+func (ss SessionService) DeleteExpiredSessions(_ context.Context) error {
+	ss.DB.mu.Lock()
+	defer ss.DB.mu.Unlock()
+
+	now := time.Now()
+	for hash, session := range ss.DB.sessions {
+		if !session.Expiry.After(now) {
+			delete(ss.DB.sessions, hash)
+		}
+	}
+
+	return nil
+}
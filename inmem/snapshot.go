@@ -0,0 +1,126 @@
+package inmem
+
+import (
+	"context"
+	tournament "github.com/ejacobg/tourney-tracker"
+	"golang.org/x/exp/slices"
+	"time"
+)
+
+// SnapshotService represents a service for saving and restoring copies of the tracker's data.
+// Each Snapshot keeps its own copy of the data, which replaces the current data when restored.
+type SnapshotService struct {
+	DB *DB
+}
+
+// snapshot is a Snapshot, along with the data saved in it.
+type snapshot struct {
+	tournament.Snapshot
+	data data
+}
+
+func (ss SnapshotService) GetSnapshots(_ context.Context) (snapshots []tournament.Snapshot, err error) {
+	ss.DB.mu.Lock()
+	defer ss.DB.mu.Unlock()
+
+	for _, id := range sortedKeys(ss.DB.snapshots) {
+		snapshots = append(snapshots, ss.DB.snapshots[id].Snapshot)
+	}
+
+	slices.SortStableFunc(snapshots, func(a, b tournament.Snapshot) bool {
+		return a.CreatedAt.After(b.CreatedAt)
+	})
+
+	return snapshots, nil
+}
+
+func (ss SnapshotService) GetSnapshot(_ context.Context, id int64) (tournament.Snapshot, error) {
+	ss.DB.mu.Lock()
+	defer ss.DB.mu.Unlock()
+
+	s, ok := ss.DB.snapshots[id]
+	if !ok {
+		return tournament.Snapshot{}, tournament.Errorf(tournament.ENOTFOUND, "Snapshot not found.")
+	}
+
+	return s.Snapshot, nil
+}
+
+func (ss SnapshotService) CreateSnapshot(ctx context.Context, s *tournament.Snapshot) error {
+	ss.DB.mu.Lock()
+	defer ss.DB.mu.Unlock()
+
+	s.ID = ss.DB.nextID("snapshots")
+	s.CreatedAt = time.Now()
+	s.Tournaments = len(ss.DB.tournaments)
+	s.Players = len(ss.DB.players)
+
+	if err := ss.DB.createEntry(ctx, tournament.ActionCreateSnapshot, s.ID, nil, s); err != nil {
+		return err
+	}
+
+	ss.DB.snapshots[s.ID] = snapshot{Snapshot: *s, data: ss.DB.data.clone()}
+
+	return nil
+}
+
+func (ss SnapshotService) RestoreSnapshot(ctx context.Context, id int64) error {
+	ss.DB.mu.Lock()
+	defer ss.DB.mu.Unlock()
+
+	s, ok := ss.DB.snapshots[id]
+	if !ok {
+		return tournament.Errorf(tournament.ENOTFOUND, "Snapshot not found.")
+	}
+
+	if err := ss.DB.createEntry(ctx, tournament.ActionRestoreSnapshot, id, nil, s.Snapshot); err != nil {
+		return err
+	}
+
+	// The Snapshot keeps its own copy, so that it can be restored again later.
+	ss.DB.data = s.data.clone()
+
+	return nil
+}
+
+func (ss SnapshotService) DeleteSnapshot(ctx context.Context, id int64) error {
+	ss.DB.mu.Lock()
+	defer ss.DB.mu.Unlock()
+
+	s, ok := ss.DB.snapshots[id]
+	if !ok {
+		return tournament.Errorf(tournament.ENOTFOUND, "Snapshot not found.")
+	}
+
+	if err := ss.DB.createEntry(ctx, tournament.ActionDeleteSnapshot, id, s.Snapshot, nil); err != nil {
+		return err
+	}
+
+	delete(ss.DB.snapshots, id)
+
+	return nil
+}
+
+func (ss SnapshotService) PurgeSnapshots(ctx context.Context, keep int) error {
+	ss.DB.mu.Lock()
+	defer ss.DB.mu.Unlock()
+
+	// IDs are assigned in order, so the newest snapshots have the highest IDs.
+	ids := sortedKeys(ss.DB.snapshots)
+	for i := len(ids) - 1; i >= 0; i-- {
+		if !ss.DB.snapshots[ids[i]].Automatic {
+			continue
+		}
+		if keep > 0 {
+			keep--
+			continue
+		}
+
+		if err := ss.DB.createEntry(ctx, tournament.ActionPurgeSnapshot, ids[i], ss.DB.snapshots[ids[i]].Snapshot, nil); err != nil {
+			return err
+		}
+		delete(ss.DB.snapshots, ids[i])
+	}
+
+	return nil
+}
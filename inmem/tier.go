@@ -0,0 +1,83 @@
+package inmem
+
+import (
+	"context"
+	tournament "github.com/ejacobg/tourney-tracker"
+)
+
+// TierService represents a service for managing tiers.
+type TierService struct {
+	DB *DB
+}
+
+func (ts TierService) GetTiers(_ context.Context) (tiers []tournament.Tier, err error) {
+	ts.DB.mu.Lock()
+	defer ts.DB.mu.Unlock()
+
+	for _, id := range sortedKeys(ts.DB.tiers) {
+		tiers = append(tiers, ts.DB.tiers[id])
+	}
+
+	return tiers, nil
+}
+
+func (ts TierService) GetTier(_ context.Context, id int64) (tournament.Tier, error) {
+	ts.DB.mu.Lock()
+	defer ts.DB.mu.Unlock()
+
+	tier, ok := ts.DB.tiers[id]
+	if !ok {
+		return tier, tournament.Errorf(tournament.ENOTFOUND, "Tier not found.")
+	}
+
+	return tier, nil
+}
+
+func (ts TierService) GetTournamentTier(_ context.Context, tournamentID int64) (tournament.Tier, error) {
+	ts.DB.mu.Lock()
+	defer ts.DB.mu.Unlock()
+
+	t, ok := ts.DB.tournaments[tournamentID]
+	if !ok {
+		return tournament.Tier{}, tournament.Errorf(tournament.ENOTFOUND, "Tournament not found.")
+	}
+
+	return ts.DB.tiers[t.Tier.ID], nil
+}
+
+func (ts TierService) CreateTier(_ context.Context, tier *tournament.Tier) error {
+	ts.DB.mu.Lock()
+	defer ts.DB.mu.Unlock()
+
+	tier.ID = ts.DB.nextID("tiers")
+	ts.DB.tiers[tier.ID] = *tier
+
+	return nil
+}
+
+func (ts TierService) UpdateTier(_ context.Context, tier *tournament.Tier) error {
+	ts.DB.mu.Lock()
+	defer ts.DB.mu.Unlock()
+
+	if _, ok := ts.DB.tiers[tier.ID]; ok {
+		ts.DB.tiers[tier.ID] = *tier
+	}
+
+	return nil
+}
+
+func (ts TierService) DeleteTier(_ context.Context, id int64) error {
+	ts.DB.mu.Lock()
+	defer ts.DB.mu.Unlock()
+
+	// Tournaments in the trash still count, since they may be restored.
+	for _, t := range ts.DB.tournaments {
+		if t.Tier.ID == id {
+			return tournament.Errorf(tournament.ECONFLICT, "This is still in use.")
+		}
+	}
+
+	delete(ts.DB.tiers, id)
+
+	return nil
+}
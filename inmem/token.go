@@ -0,0 +1,107 @@
+package inmem
+
+import (
+	"bytes"
+	"context"
+	tournament "github.com/ejacobg/tourney-tracker"
+	"golang.org/x/exp/slices"
+	"time"
+)
+
+// TokenService represents a service for managing API tokens.
+type TokenService struct {
+	DB *DB
+}
+
+// token is a Token, along with the hash of its value. The value itself is never kept.
+type token struct {
+	tournament.Token
+	hash []byte
+}
+
+func (ts TokenService) GetTokens(_ context.Context) (tokens []tournament.Token, err error) {
+	ts.DB.mu.Lock()
+	defer ts.DB.mu.Unlock()
+
+	for _, id := range sortedKeys(ts.DB.tokens) {
+		tokens = append(tokens, ts.DB.getToken(ts.DB.tokens[id]))
+	}
+
+	// Newest first. Tokens created at the same time are ordered newest first by ID.
+	slices.SortStableFunc(tokens, func(a, b tournament.Token) bool {
+		return a.CreatedAt.After(b.CreatedAt) || (a.CreatedAt.Equal(b.CreatedAt) && a.ID > b.ID)
+	})
+
+	return tokens, nil
+}
+
+// getToken returns a copy of the given Token, with the name of its User filled in.
+func (db *DB) getToken(t token) tournament.Token {
+	t.UserName = db.users[t.UserID].Name
+	if t.LastUsedAt != nil {
+		lastUsedAt := *t.LastUsedAt
+		t.LastUsedAt = &lastUsedAt
+	}
+	return t.Token
+}
+
+func (ts TokenService) CreateToken(_ context.Context, t *tournament.Token) error {
+	ts.DB.mu.Lock()
+	defer ts.DB.mu.Unlock()
+
+	if !t.Role.Valid() {
+		return tournament.Errorf(tournament.EINVALID, "Invalid value.")
+	}
+	if _, ok := ts.DB.users[t.UserID]; !ok {
+		return tournament.Errorf(tournament.EINVALID, "That user does not exist.")
+	}
+
+	hash := tournament.HashToken(t.Plaintext)
+	for _, existing := range ts.DB.tokens {
+		if bytes.Equal(existing.hash, hash) {
+			return tournament.Errorf(tournament.ECONFLICT, "This conflicts with existing data.")
+		}
+	}
+
+	t.ID = ts.DB.nextID("tokens")
+	t.CreatedAt = time.Now()
+
+	stored := token{Token: *t, hash: hash}
+	stored.Plaintext = ""
+	stored.UserName = ""
+	stored.LastUsedAt = nil
+	ts.DB.tokens[t.ID] = stored
+
+	return nil
+}
+
+func (ts TokenService) AuthenticateToken(_ context.Context, plaintext string) (tournament.Token, error) {
+	ts.DB.mu.Lock()
+	defer ts.DB.mu.Unlock()
+
+	hash := tournament.HashToken(plaintext)
+	for id, t := range ts.DB.tokens {
+		if !bytes.Equal(t.hash, hash) {
+			continue
+		}
+
+		now := time.Now()
+		t.LastUsedAt = &now
+		ts.DB.tokens[id] = t
+
+		authenticated := ts.DB.getToken(t)
+		authenticated.Role = authenticated.Role.Limit(ts.DB.users[t.UserID].Role)
+		return authenticated, nil
+	}
+
+	return tournament.Token{}, tournament.Errorf(tournament.ENOTFOUND, "Token not found.")
+}
+
+func (ts TokenService) RevokeToken(_ context.Context, id int64) error {
+	ts.DB.mu.Lock()
+	defer ts.DB.mu.Unlock()
+
+	delete(ts.DB.tokens, id)
+
+	return nil
+}
@@ -0,0 +1,275 @@
+package inmem
+
+import (
+	"context"
+	tournament "github.com/ejacobg/tourney-tracker"
+	"golang.org/x/exp/slices"
+	"time"
+)
+
+// TournamentService represents a service for managing tournaments.
+type TournamentService struct {
+	DB *DB
+}
+
+func (ts TournamentService) GetPreviews(_ context.Context, gameID int64) (previews []tournament.Preview, err error) {
+	ts.DB.mu.Lock()
+	defer ts.DB.mu.Unlock()
+
+	for _, id := range sortedKeys(ts.DB.tournaments) {
+		t := ts.DB.tournaments[id]
+		if t.deletedAt != nil || (gameID != 0 && t.Game.ID != gameID) {
+			continue
+		}
+
+		previews = append(previews, tournament.Preview{
+			ID:   t.ID,
+			Name: t.Name,
+			Tier: ts.DB.tiers[t.Tier.ID].Name,
+			Game: ts.DB.games[t.Game.ID].Name,
+		})
+	}
+
+	return previews, nil
+}
+
+func (ts TournamentService) GetNamesByTier(_ context.Context, tierID int64) (names []tournament.Name, err error) {
+	ts.DB.mu.Lock()
+	defer ts.DB.mu.Unlock()
+
+	for _, id := range sortedKeys(ts.DB.tournaments) {
+		if t := ts.DB.tournaments[id]; t.deletedAt == nil && t.Tier.ID == tierID {
+			names = append(names, tournament.Name{ID: t.ID, Name: t.Name})
+		}
+	}
+
+	return names, nil
+}
+
+func (ts TournamentService) GetTournament(_ context.Context, id int64) (tournament.Tournament, error) {
+	ts.DB.mu.Lock()
+	defer ts.DB.mu.Unlock()
+
+	return ts.DB.getTournament(id)
+}
+
+// getTournament returns the given Tournament with its Tier and Game filled in. Tournaments in the trash are not found.
+func (db *DB) getTournament(id int64) (tournament.Tournament, error) {
+	t, ok := db.tournaments[id]
+	if !ok || t.deletedAt != nil {
+		return tournament.Tournament{}, tournament.Errorf(tournament.ENOTFOUND, "Tournament not found.")
+	}
+
+	return db.fillTournament(t), nil
+}
+
+// fillTournament returns a copy of the given Tournament with its Tier and Game filled in.
+func (db *DB) fillTournament(t tourney) tournament.Tournament {
+	t = t.clone()
+	t.Tier = db.tiers[t.Tier.ID]
+	t.Game = db.games[t.Game.ID]
+	return t.Tournament
+}
+
+func (ts TournamentService) CreateTournament(ctx context.Context, t *tournament.Tournament, entrants []tournament.Entrant) error {
+	ts.DB.mu.Lock()
+	defer ts.DB.mu.Unlock()
+
+	// Tournaments without a Tier are given the C-tier, the same as the other services.
+	tierID := t.Tier.ID
+	if tierID == 0 {
+		tierID = 1
+	}
+	tier, ok := ts.DB.tiers[tierID]
+	if !ok {
+		return tournament.Errorf(tournament.EINVALID, "That tier does not exist.")
+	}
+
+	// Everything is checked before anything is added, so that nothing is left behind if the Tournament cannot be created.
+	if _, ok = ts.DB.games[t.Game.ID]; t.Game.Name == "" && t.Game.ID != 0 && !ok {
+		return tournament.Errorf(tournament.EINVALID, "That game does not exist.")
+	}
+
+	orders := make(map[int]bool)
+	for _, phase := range t.Phases {
+		if orders[phase.Order] {
+			return tournament.Errorf(tournament.ECONFLICT, "This conflicts with existing data.")
+		}
+		orders[phase.Order] = true
+	}
+	for _, entrant := range entrants {
+		if err := checkResults(entrant.Results, orders); err != nil {
+			return err
+		}
+	}
+	if err := ts.DB.checkActor(ctx); err != nil {
+		return err
+	}
+
+	if t.Game.Name != "" {
+		ts.DB.createGame(&t.Game)
+	}
+
+	// Team tournaments get their own leaderboard unless told otherwise.
+	if t.TeamScoring == "" {
+		t.TeamScoring = tournament.SeparateLeaderboard
+	}
+
+	t.ID = ts.DB.nextID("tournaments")
+	t.Tier = tier
+	for i := range t.Phases {
+		t.Phases[i].ID = ts.DB.nextID("phases")
+	}
+
+	stored := cloneTournament(*t)
+	stored.Tier = tournament.Tier{ID: tier.ID}
+	stored.Game = tournament.Game{ID: t.Game.ID}
+	slices.SortFunc(stored.Phases, func(a, b tournament.Phase) bool {
+		return a.Order < b.Order
+	})
+	ts.DB.tournaments[t.ID] = tourney{Tournament: stored}
+
+	ts.DB.createEntrants(entrants, t.ID)
+
+	// The actor was checked along with everything else, so recording the Tournament cannot fail.
+	return ts.DB.createEntry(ctx, tournament.ActionCreateTournament, t.ID, nil, t)
+}
+
+func (ts TournamentService) SetTier(ctx context.Context, tournamentID, tierID int64) error {
+	ts.DB.mu.Lock()
+	defer ts.DB.mu.Unlock()
+
+	t, ok := ts.DB.tournaments[tournamentID]
+	if !ok || t.deletedAt != nil {
+		return tournament.Errorf(tournament.ENOTFOUND, "Tournament not found.")
+	}
+	tier, ok := ts.DB.tiers[tierID]
+	if !ok {
+		return tournament.Errorf(tournament.EINVALID, "That tier does not exist.")
+	}
+
+	if err := ts.DB.createEntry(ctx, tournament.ActionSetTier, tournamentID, ts.DB.tiers[t.Tier.ID], tier); err != nil {
+		return err
+	}
+
+	t.Tier.ID = tierID
+	ts.DB.tournaments[tournamentID] = t
+
+	return nil
+}
+
+func (ts TournamentService) SetGame(ctx context.Context, tournamentID, gameID int64) error {
+	ts.DB.mu.Lock()
+	defer ts.DB.mu.Unlock()
+
+	t, ok := ts.DB.tournaments[tournamentID]
+	if !ok || t.deletedAt != nil {
+		return tournament.Errorf(tournament.ENOTFOUND, "Tournament not found.")
+	}
+	game, ok := ts.DB.games[gameID]
+	if !ok {
+		return tournament.Errorf(tournament.EINVALID, "That game does not exist.")
+	}
+
+	if err := ts.DB.createEntry(ctx, tournament.ActionSetGame, tournamentID, ts.DB.games[t.Game.ID], game); err != nil {
+		return err
+	}
+
+	t.Game.ID = gameID
+	ts.DB.tournaments[tournamentID] = t
+
+	return nil
+}
+
+func (ts TournamentService) SetTeamScoring(ctx context.Context, tournamentID int64, scoring tournament.TeamScoring) error {
+	ts.DB.mu.Lock()
+	defer ts.DB.mu.Unlock()
+
+	t, ok := ts.DB.tournaments[tournamentID]
+	if !ok || t.deletedAt != nil {
+		return tournament.Errorf(tournament.ENOTFOUND, "Tournament not found.")
+	}
+
+	if err := ts.DB.createEntry(ctx, tournament.ActionSetTeamScoring, tournamentID, t.TeamScoring, scoring); err != nil {
+		return err
+	}
+
+	t.TeamScoring = scoring
+	ts.DB.tournaments[tournamentID] = t
+
+	return nil
+}
+
+func (ts TournamentService) DeleteTournament(ctx context.Context, id int64) error {
+	ts.DB.mu.Lock()
+	defer ts.DB.mu.Unlock()
+
+	before, err := ts.DB.getTournament(id)
+	if err != nil {
+		return err
+	}
+
+	if err = ts.DB.createEntry(ctx, tournament.ActionDeleteTournament, id, before, nil); err != nil {
+		return err
+	}
+
+	t := ts.DB.tournaments[id]
+
+	now := time.Now()
+	t.deletedAt = &now
+	ts.DB.tournaments[id] = t
+
+	return nil
+}
+
+func (ts TournamentService) GetDeletedTournaments(_ context.Context) (trashed []tournament.Trashed, err error) {
+	ts.DB.mu.Lock()
+	defer ts.DB.mu.Unlock()
+
+	for _, id := range sortedKeys(ts.DB.tournaments) {
+		if t := ts.DB.tournaments[id]; t.deletedAt != nil {
+			trashed = append(trashed, tournament.Trashed{ID: t.ID, Name: t.Name, DeletedAt: *t.deletedAt})
+		}
+	}
+
+	return sortTrashed(trashed), nil
+}
+
+func (ts TournamentService) RestoreTournament(ctx context.Context, id int64) error {
+	ts.DB.mu.Lock()
+	defer ts.DB.mu.Unlock()
+
+	t, ok := ts.DB.tournaments[id]
+	if !ok || t.deletedAt == nil {
+		return tournament.Errorf(tournament.ENOTFOUND, "Tournament not found in the trash.")
+	}
+
+	if err := ts.DB.createEntry(ctx, tournament.ActionRestoreTournament, id, nil, ts.DB.fillTournament(t)); err != nil {
+		return err
+	}
+
+	t.deletedAt = nil
+	ts.DB.tournaments[id] = t
+
+	return nil
+}
+
+func (ts TournamentService) PurgeTournaments(ctx context.Context, before time.Time) error {
+	ts.DB.mu.Lock()
+	defer ts.DB.mu.Unlock()
+
+	for _, id := range sortedKeys(ts.DB.tournaments) {
+		if t := ts.DB.tournaments[id]; t.deletedAt != nil && t.deletedAt.Before(before) {
+			trashed := tournament.Trashed{ID: t.ID, Name: t.Name, DeletedAt: *t.deletedAt}
+			if err := ts.DB.createEntry(ctx, tournament.ActionPurgeTournament, id, trashed, nil); err != nil {
+				return err
+			}
+
+			// Deleting a Tournament also deletes its phases and entrants, the same as the database's cascades.
+			delete(ts.DB.tournaments, id)
+			ts.DB.deleteEntrants(id)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,173 @@
+package inmem
+
+import (
+	"context"
+	tournament "github.com/ejacobg/tourney-tracker"
+	"golang.org/x/exp/slices"
+	"time"
+)
+
+// UserService represents a service for managing users.
+type UserService struct {
+	DB *DB
+}
+
+func (us UserService) GetUsers(_ context.Context) (users []tournament.User, err error) {
+	us.DB.mu.Lock()
+	defer us.DB.mu.Unlock()
+
+	for _, id := range sortedKeys(us.DB.users) {
+		users = append(users, us.DB.users[id])
+	}
+
+	slices.SortStableFunc(users, func(a, b tournament.User) bool {
+		return a.Name < b.Name
+	})
+
+	return users, nil
+}
+
+func (us UserService) GetUser(_ context.Context, id int64) (tournament.User, error) {
+	us.DB.mu.Lock()
+	defer us.DB.mu.Unlock()
+
+	user, ok := us.DB.users[id]
+	if !ok {
+		return user, tournament.Errorf(tournament.ENOTFOUND, "User not found.")
+	}
+
+	return user, nil
+}
+
+func (us UserService) GetUserByName(_ context.Context, name string) (tournament.User, error) {
+	us.DB.mu.Lock()
+	defer us.DB.mu.Unlock()
+
+	for _, user := range us.DB.users {
+		if user.Name == name {
+			return user, nil
+		}
+	}
+
+	return tournament.User{}, tournament.Errorf(tournament.ENOTFOUND, "User not found.")
+}
+
+func (us UserService) CreateUser(ctx context.Context, user *tournament.User) error {
+	us.DB.mu.Lock()
+	defer us.DB.mu.Unlock()
+
+	if err := us.DB.checkUser(*user); err != nil {
+		return err
+	}
+
+	user.ID = us.DB.nextID("users")
+	user.CreatedAt = time.Now()
+
+	if err := us.DB.createEntry(ctx, tournament.ActionCreateUser, user.ID, nil, user); err != nil {
+		return err
+	}
+
+	us.DB.users[user.ID] = *user
+
+	return nil
+}
+
+// checkUser returns an error if the User breaks the constraints of the users table.
+func (db *DB) checkUser(user tournament.User) error {
+	if !user.Role.Valid() {
+		return tournament.Errorf(tournament.EINVALID, "Invalid value.")
+	}
+
+	for _, existing := range db.users {
+		if existing.Name == user.Name && existing.ID != user.ID {
+			return tournament.Errorf(tournament.ECONFLICT, "Another user already has that name.")
+		}
+	}
+
+	return nil
+}
+
+func (us UserService) UpdateUser(ctx context.Context, user *tournament.User) error {
+	us.DB.mu.Lock()
+	defer us.DB.mu.Unlock()
+
+	existing, ok := us.DB.users[user.ID]
+	if !ok {
+		return tournament.Errorf(tournament.ENOTFOUND, "User not found.")
+	}
+	if err := us.DB.checkUser(*user); err != nil {
+		return err
+	}
+	if user.Role != tournament.RoleAdmin && us.DB.lastAdmin(existing) {
+		return tournament.Errorf(tournament.ECONFLICT, "There must be at least one admin.")
+	}
+
+	before := existing
+	existing.Name = user.Name
+	existing.Role = user.Role
+	existing.PasswordHash = user.PasswordHash
+
+	if err := us.DB.createEntry(ctx, tournament.ActionUpdateUser, user.ID, before, existing); err != nil {
+		return err
+	}
+
+	us.DB.users[user.ID] = existing
+
+	return nil
+}
+
+// DeleteUser deletes the given User, along with their sessions and tokens. Their audit entries are kept, but no longer point to them.
+func (us UserService) DeleteUser(ctx context.Context, id int64) error {
+	us.DB.mu.Lock()
+	defer us.DB.mu.Unlock()
+
+	existing, ok := us.DB.users[id]
+	if !ok {
+		return tournament.Errorf(tournament.ENOTFOUND, "User not found.")
+	}
+	if us.DB.lastAdmin(existing) {
+		return tournament.Errorf(tournament.ECONFLICT, "There must be at least one admin.")
+	}
+
+	// The Entry is written before the User is deleted, so that it no longer points to them if they deleted themselves.
+	if err := us.DB.createEntry(ctx, tournament.ActionDeleteUser, id, existing, nil); err != nil {
+		return err
+	}
+
+	delete(us.DB.users, id)
+
+	for hash, session := range us.DB.sessions {
+		if session.UserID == id {
+			delete(us.DB.sessions, hash)
+		}
+	}
+
+	for tokenID, t := range us.DB.tokens {
+		if t.UserID == id {
+			delete(us.DB.tokens, tokenID)
+		}
+	}
+
+	for i, entry := range us.DB.entries {
+		if entry.UserID != nil && *entry.UserID == id {
+			us.DB.entries[i].UserID = nil
+		}
+	}
+
+	return nil
+}
+
+// lastAdmin reports whether the given User is the only admin.
+func (db *DB) lastAdmin(user tournament.User) bool {
+	if user.Role != tournament.RoleAdmin {
+		return false
+	}
+
+	for _, other := range db.users {
+		if other.Role == tournament.RoleAdmin && other.ID != user.ID {
+			return false
+		}
+	}
+
+	return true
+}
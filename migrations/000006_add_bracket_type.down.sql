@@ -0,0 +1,2 @@
+ALTER TABLE tournaments
+    DROP COLUMN IF EXISTS bracket_type;
@@ -0,0 +1,3 @@
+-- Tournaments imported before bracket types were tracked are assumed to be double-elimination.
+ALTER TABLE tournaments
+    ADD COLUMN IF NOT EXISTS bracket_type text NOT NULL DEFAULT 'double elimination';
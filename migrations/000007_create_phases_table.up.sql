@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS phases
+(
+    id            bigserial PRIMARY KEY,
+    name          text    NOT NULL,
+    bracket_type  text    NOT NULL,
+    phase_order   integer NOT NULL,
+    tournament_id bigint  NOT NULL REFERENCES tournaments (id) ON DELETE CASCADE,
+    UNIQUE (tournament_id, phase_order)
+);
+
+CREATE TABLE IF NOT EXISTS results
+(
+    entrant_id bigint  NOT NULL REFERENCES entrants (id) ON DELETE CASCADE,
+    phase_id   bigint  NOT NULL REFERENCES phases (id) ON DELETE CASCADE,
+    pool       text    NOT NULL,
+    placement  integer NOT NULL,
+    PRIMARY KEY (entrant_id, phase_id)
+);
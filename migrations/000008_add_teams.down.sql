@@ -0,0 +1,16 @@
+ALTER TABLE entrants
+    ADD COLUMN IF NOT EXISTS player_id bigint REFERENCES players (id) ON DELETE SET NULL,
+    ADD UNIQUE (tournament_id, player_id);
+
+-- Only one player per entrant can be kept.
+UPDATE entrants
+SET player_id = (SELECT MIN(player_id) FROM entrant_players WHERE entrant_players.entrant_id = entrants.id);
+
+DROP TABLE IF EXISTS entrant_players;
+
+ALTER TABLE entrants
+    DROP COLUMN IF EXISTS participants;
+
+ALTER TABLE tournaments
+    DROP COLUMN IF EXISTS team_scoring,
+    DROP COLUMN IF EXISTS teams;
@@ -0,0 +1,26 @@
+-- Team events may credit each teammate in the singles rankings, or keep a separate doubles leaderboard.
+ALTER TABLE tournaments
+    ADD COLUMN IF NOT EXISTS teams        boolean NOT NULL DEFAULT false,
+    ADD COLUMN IF NOT EXISTS team_scoring text    NOT NULL DEFAULT 'separate';
+
+-- The names of each team member, as reported by the tournament platform.
+ALTER TABLE entrants
+    ADD COLUMN IF NOT EXISTS participants text[] NOT NULL DEFAULT '{}';
+
+-- Entrants may now represent multiple players. A player may still only be assigned to one entrant per tournament.
+CREATE TABLE IF NOT EXISTS entrant_players
+(
+    entrant_id    bigint NOT NULL REFERENCES entrants (id) ON DELETE CASCADE,
+    player_id     bigint NOT NULL REFERENCES players (id) ON DELETE CASCADE,
+    tournament_id bigint NOT NULL REFERENCES tournaments (id) ON DELETE CASCADE,
+    PRIMARY KEY (entrant_id, player_id),
+    UNIQUE (tournament_id, player_id)
+);
+
+INSERT INTO entrant_players (entrant_id, player_id, tournament_id)
+SELECT id, player_id, tournament_id
+FROM entrants
+WHERE player_id IS NOT NULL;
+
+ALTER TABLE entrants
+    DROP COLUMN IF EXISTS player_id;
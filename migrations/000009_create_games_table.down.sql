@@ -0,0 +1,4 @@
+ALTER TABLE tournaments
+    DROP COLUMN IF EXISTS game_id;
+
+DROP TABLE IF EXISTS games;
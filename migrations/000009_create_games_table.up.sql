@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS games
+(
+    id   bigserial PRIMARY KEY,
+    name text UNIQUE NOT NULL
+);
+
+-- Tournaments imported before games were tracked will not have a game.
+ALTER TABLE tournaments
+    ADD COLUMN IF NOT EXISTS game_id bigint REFERENCES games (id);
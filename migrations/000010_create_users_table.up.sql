@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS users
+(
+    id            bigserial PRIMARY KEY,
+    name          text UNIQUE NOT NULL,
+    password_hash bytea       NOT NULL,
+    created_at    timestamptz NOT NULL DEFAULT now()
+);
+
+-- Only the SHA-256 hash of each session token is stored.
+CREATE TABLE IF NOT EXISTS sessions
+(
+    token_hash bytea PRIMARY KEY,
+    user_id    bigint      NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    expiry     timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS sessions_expiry_idx ON sessions (expiry);
@@ -0,0 +1,7 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS role text NOT NULL DEFAULT 'viewer'
+        CHECK (role IN ('viewer', 'editor', 'organizer', 'admin'));
+
+-- Users created before roles existed could make any change.
+UPDATE users
+SET role = 'admin';
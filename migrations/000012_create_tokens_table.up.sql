@@ -0,0 +1,11 @@
+-- Only the SHA-256 hash of each token is stored. Tokens are revoked by deleting them.
+CREATE TABLE IF NOT EXISTS tokens
+(
+    id           bigserial PRIMARY KEY,
+    token_hash   bytea UNIQUE NOT NULL,
+    label        text         NOT NULL,
+    role         text         NOT NULL CHECK (role IN ('viewer', 'editor', 'organizer', 'admin')),
+    user_id      bigint       NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at   timestamptz  NOT NULL DEFAULT now(),
+    last_used_at timestamptz
+);
@@ -0,0 +1,15 @@
+-- Entries keep the name of the user who made each change, so that the log is still readable after users are deleted.
+CREATE TABLE IF NOT EXISTS audit_log
+(
+    id         bigserial PRIMARY KEY,
+    user_id    bigint      REFERENCES users (id) ON DELETE SET NULL,
+    actor      text        NOT NULL,
+    action     text        NOT NULL,
+    subject    text        NOT NULL,
+    subject_id bigint      NOT NULL,
+    before     jsonb,
+    after      jsonb,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_subject_idx ON audit_log (subject, subject_id);
@@ -0,0 +1,14 @@
+-- Anything still in the trash is deleted for good, since it would otherwise reappear.
+DELETE
+FROM tournaments
+WHERE deleted_at IS NOT NULL;
+
+DELETE
+FROM players
+WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE tournaments
+    DROP COLUMN IF EXISTS deleted_at;
+
+ALTER TABLE players
+    DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,6 @@
+-- Deleted tournaments and players are kept in the trash until they are purged. Rows without a deleted_at time have not been deleted.
+ALTER TABLE tournaments
+    ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+
+ALTER TABLE players
+    ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
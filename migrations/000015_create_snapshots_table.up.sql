@@ -0,0 +1,10 @@
+-- The data of each snapshot is stored as a single JSON object, holding an array of rows for each table. See postgres.SnapshotService.
+CREATE TABLE IF NOT EXISTS snapshots
+(
+    id         bigserial PRIMARY KEY,
+    name       text        NOT NULL,
+    automatic  boolean     NOT NULL DEFAULT false,
+    created_by text        NOT NULL DEFAULT '',
+    created_at timestamptz NOT NULL DEFAULT now(),
+    data       jsonb       NOT NULL
+);
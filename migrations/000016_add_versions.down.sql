@@ -0,0 +1,5 @@
+ALTER TABLE players
+    DROP COLUMN IF EXISTS version;
+
+ALTER TABLE entrants
+    DROP COLUMN IF EXISTS version;
@@ -0,0 +1,6 @@
+-- Each change to a row increments its version. Changes are only made if the row still has the version that the user last saw.
+ALTER TABLE players
+    ADD COLUMN IF NOT EXISTS version integer NOT NULL DEFAULT 1;
+
+ALTER TABLE entrants
+    ADD COLUMN IF NOT EXISTS version integer NOT NULL DEFAULT 1;
@@ -0,0 +1 @@
+-- Moving the sequence back would let new tiers collide with existing ones, so there is nothing to undo.
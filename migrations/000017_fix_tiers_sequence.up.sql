@@ -0,0 +1,2 @@
+-- The default tiers were inserted with explicit IDs, which left the sequence behind them. New tiers would then reuse their IDs.
+SELECT setval('tiers_id_seq', (SELECT COALESCE(MAX(id), 1) FROM tiers));
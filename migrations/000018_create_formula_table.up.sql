@@ -0,0 +1,9 @@
+-- The formula table holds at most one row. Until an admin changes the formula, it is empty and tournament.DefaultFormula is used.
+CREATE TABLE IF NOT EXISTS formula
+(
+    id           boolean PRIMARY KEY DEFAULT true CHECK (id),
+    up_points    integer NOT NULL CHECK (up_points >= 0),
+    att_points   integer NOT NULL CHECK (att_points >= 0),
+    first_points integer NOT NULL CHECK (first_points >= 0),
+    br_points    integer NOT NULL CHECK (br_points >= 0)
+);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS players_name_idx;
+
+ALTER TABLE players
+    ADD CONSTRAINT players_name_key UNIQUE (name);
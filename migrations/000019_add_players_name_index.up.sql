@@ -0,0 +1,6 @@
+-- Players in the trash no longer keep their names, so that a deleted player's name can be given to a new player.
+-- Restoring a player whose name has since been taken fails instead.
+ALTER TABLE players
+    DROP CONSTRAINT IF EXISTS players_name_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS players_name_idx ON players (name) WHERE deleted_at IS NULL;
@@ -0,0 +1,8 @@
+// Package migrations embeds the PostgreSQL migrations, so that the server can apply them without any other tools.
+// Each version has an up and a down script, named in the format used by golang-migrate (eg. "000001_create_tiers_table.up.sql").
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
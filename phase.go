@@ -0,0 +1,25 @@
+package tourney_tracker
+
+// Phase represents a single stage of a Tournament, such as the pools that feed into a top-8 bracket.
+// Only tournaments with more than one stage will have phases. The final placements of a Tournament always come from its last Phase.
+type Phase struct {
+	ID          int64       `json:"id"`
+	Name        string      `json:"name"`
+	BracketType BracketType `json:"bracketType"`
+
+	// Order is the position of the Phase within its Tournament, starting from 1.
+	Order int `json:"order"`
+}
+
+// Result represents how an Entrant placed within a single Phase.
+// These are kept for statistics only, and are not used by the point formula.
+type Result struct {
+	// Phase is the Order of the Phase that this Result belongs to.
+	Phase int `json:"phase"`
+
+	// Group is the name of the pool that the Entrant played in, eg. "A1".
+	Group string `json:"group"`
+
+	// Placement is the Entrant's placement within their Group.
+	Placement int64 `json:"placement"`
+}
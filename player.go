@@ -1,34 +1,98 @@
 package tourney_tracker
 
+import (
+	"context"
+	"fmt"
+	"github.com/ejacobg/tourney-tracker/validator"
+	"strings"
+	"time"
+)
+
 // Player represents a person whose tournament record we wish to track.
 type Player struct {
 	ID   int64  `json:"id"`
 	Name string `json:"name"`
+
+	// Version is incremented each time the Player is updated. See PlayerService.UpdatePlayer().
+	Version int `json:"version"`
+}
+
+// MaxNameLength is the longest name that a Player, Tier, User, or Token label may have.
+const MaxNameLength = 100
+
+// ValidatePlayer trims the Player's name, then checks that it is not empty or too long.
+func ValidatePlayer(v *validator.Validator, player *Player) {
+	player.Name = strings.TrimSpace(player.Name)
+	v.Check(validator.NotBlank(player.Name), "name", "Name must not be empty.")
+	v.Check(validator.MaxChars(player.Name, MaxNameLength), "name", fmt.Sprintf("Name must not be longer than %d characters.", MaxNameLength))
+}
+
+// ValidatePlayerIDs checks that every given Player exists. Errors are added under the given key.
+// An error is only returned if the players could not be checked.
+func ValidatePlayerIDs(ctx context.Context, v *validator.Validator, ps PlayerService, key string, ids []int64) error {
+	for _, id := range ids {
+		_, err := ps.GetPlayer(ctx, id)
+		if err = checkFound(v, key, fmt.Sprintf("Player %d does not exist.", id), err); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // PlayerService represents a service for managing players.
 type PlayerService interface {
-	// GetPlayers returns all players.
-	GetPlayers() ([]Player, error)
+	// GetPlayers returns all players who have attended a tournament of the given Game. A gameID of 0 returns every player.
+	GetPlayers(ctx context.Context, gameID int64) ([]Player, error)
 
-	// GetPlayer returns a single Player by ID.
-	GetPlayer(id int64) (Player, error)
+	// GetPlayer returns a single Player by ID. Players in the trash are not found.
+	GetPlayer(ctx context.Context, id int64) (Player, error)
 
-	// GetRanks returns an ordered slice of players and their associated points.
-	GetRanks() ([]Rank, error)
+	// GetRanks returns an ordered slice of players and their associated points, using the given filter.
+	GetRanks(ctx context.Context, filter RankFilter) ([]Rank, error)
 
 	// CreatePlayer adds the given Player to the database.
-	CreatePlayer(player *Player) error
+	CreatePlayer(ctx context.Context, player *Player) error
+
+	// UpdatePlayer updates the given Player, and increments its Version.
+	// If the Version of the given Player is not 0, the Player is only updated if its Version has not changed since it was read.
+	// An ECONFLICT error is returned if the Version has changed, or if another Player already has the same name.
+	// An ENOTFOUND error is returned if the Player does not exist, or is in the trash.
+	UpdatePlayer(ctx context.Context, player *Player) error
 
-	// UpdatePlayer updates the given Player.
-	UpdatePlayer(player *Player) error
+	// DeletePlayer moves the given Player to the trash. Trashed players are excluded from player lists and rankings,
+	// and are hidden from the entrants they are linked to. The links are kept until the Player is purged, so that it can be restored.
+	// An ENOTFOUND error is returned if the Player does not exist, or is already in the trash.
+	DeletePlayer(ctx context.Context, id int64) error
 
-	// DeletePlayer deletes the given Player.
-	// Deleting a Player should nullify any entrants pointing to it.
-	DeletePlayer(id int64) error
+	// GetDeletedPlayers returns all players in the trash, most recently deleted first.
+	GetDeletedPlayers(ctx context.Context) ([]Trashed, error)
+
+	// RestorePlayer moves a Player out of the trash. An ENOTFOUND error is returned if the Player is not in the trash.
+	// An ECONFLICT error is returned if another Player has taken its name since it was moved to the trash.
+	RestorePlayer(ctx context.Context, id int64) error
+
+	// PurgePlayers permanently deletes every Player that was moved to the trash before the given time.
+	// Purging a Player should nullify any entrants pointing to it.
+	PurgePlayers(ctx context.Context, before time.Time) error
 }
 
 type Rank struct {
-	Player Player
-	Points int
+	Player Player `json:"player"`
+	Points int    `json:"points"`
+}
+
+// RankFilter decides which tournaments count towards a ranking.
+type RankFilter struct {
+	// Doubles selects the doubles leaderboard, which only counts team tournaments with a SeparateLeaderboard.
+	// Otherwise, the singles rankings are returned, which count every other tournament.
+	Doubles bool
+
+	// GameID selects the rankings for a single Game. A GameID of 0 counts tournaments from every game.
+	GameID int64
+}
+
+// Filtered returns true if the rankings should only include players who have played in a matching tournament.
+// The unfiltered singles rankings include every player, even those with no points.
+func (f RankFilter) Filtered() bool {
+	return f.Doubles || f.GameID != 0
 }
@@ -0,0 +1,35 @@
+package tourney_tracker
+
+import (
+	"github.com/ejacobg/tourney-tracker/validator"
+	"strings"
+	"testing"
+)
+
+func TestValidatePlayer(t *testing.T) {
+	tests := []struct {
+		name     string
+		player   string
+		wantName string
+		valid    bool
+	}{
+		{"trimmed", "  Ean  ", "Ean", true},
+		{"empty", "", "", false},
+		{"blank", " \t ", "", false},
+		{"too long", strings.Repeat("a", MaxNameLength+1), strings.Repeat("a", MaxNameLength+1), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := validator.New()
+			player := Player{Name: tt.player}
+			ValidatePlayer(v, &player)
+
+			if player.Name != tt.wantName {
+				t.Errorf("ValidatePlayer() name = %q, want %q", player.Name, tt.wantName)
+			}
+			if v.Valid() != tt.valid {
+				t.Errorf("ValidatePlayer() errors = %v, want valid = %v", v.Errors, tt.valid)
+			}
+		})
+	}
+}
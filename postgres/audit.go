@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	tournament "github.com/ejacobg/tourney-tracker"
+)
+
+// AuditService represents a service for reading the changes made to the tracker.
+type AuditService struct {
+	DB *sql.DB
+}
+
+func (as AuditService) GetEntries(ctx context.Context, filter tournament.EntryFilter) (entries []tournament.Entry, err error) {
+	// A limit of NULL returns every row.
+	query := `
+SELECT id, user_id, actor, action, subject_id, before, after, created_at
+FROM audit_log
+WHERE ($1 = '' OR actor ILIKE '%' || $1 || '%')
+  AND ($2 = '' OR action = $2)
+  AND ($3 = '' OR subject = $3)
+  AND ($4::bigint = 0 OR subject_id = $4)
+ORDER BY created_at DESC, id DESC
+LIMIT NULLIF($5, 0) OFFSET $6`
+
+	rows, err := as.DB.QueryContext(ctx, query, filter.Actor, filter.Action, filter.Subject, filter.SubjectID, filter.Limit, filter.Offset)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			entry         tournament.Entry
+			before, after []byte
+		)
+
+		// NULL values can only be scanned into a plain []byte, not a json.RawMessage.
+		err = rows.Scan(&entry.ID, &entry.UserID, &entry.Actor, &entry.Action, &entry.SubjectID, &before, &after, &entry.CreatedAt)
+		if err != nil {
+			return
+		}
+		entry.Before, entry.After = before, after
+
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// createEntry records the given change, made by the actor of the context. It should be called in the same transaction as the change,
+// so that the change is only saved if it is recorded.
+func createEntry(ctx context.Context, q queryer, action tournament.Action, subjectID int64, before, after any) error {
+	entry, err := tournament.NewEntry(ctx, action, subjectID, before, after)
+	if err != nil {
+		return err
+	}
+
+	query := `
+INSERT INTO audit_log (user_id, actor, action, subject, subject_id, before, after)
+VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err = q.ExecContext(ctx, query, entry.UserID, entry.Actor, entry.Action, entry.Action.Subject(), entry.SubjectID, jsonb(entry.Before), jsonb(entry.After))
+	return err
+}
+
+// lockRow locks the row with the given ID until the end of the transaction, so that the value read before a change is the one it replaces.
+// Nothing happens if the row does not exist.
+func lockRow(ctx context.Context, tx *sql.Tx, table string, id int64) error {
+	_, err := tx.ExecContext(ctx, fmt.Sprintf(`SELECT id FROM %s WHERE id = $1 FOR UPDATE`, table), id)
+	return err
+}
+
+// jsonb converts the given JSON into a query argument. The driver would otherwise send it as bytea, which cannot be stored in a jsonb column.
+func jsonb(raw json.RawMessage) any {
+	if raw == nil {
+		return nil
+	}
+	return string(raw)
+}
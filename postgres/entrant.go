@@ -1,10 +1,11 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	tournament "github.com/ejacobg/tourney-tracker"
-	"golang.org/x/exp/slices"
+	"github.com/lib/pq"
 )
 
 // EntrantService represents a service for managing entrants.
@@ -12,16 +13,17 @@ type EntrantService struct {
 	DB *sql.DB
 }
 
-func (es EntrantService) GetEntrants(tournamentID int64) (entrants []tournament.Entrant, err error) {
+func (es EntrantService) GetEntrants(ctx context.Context, tournamentID int64) (entrants []tournament.Entrant, err error) {
 	query := `
-SELECT entrants.id, entrants.name, placement, tournament_id, players.name
-FROM entrants LEFT OUTER JOIN players ON entrants.player_id = players.id
+SELECT id, name, placement, tournament_id, participants, version
+FROM entrants
 WHERE tournament_id = $1`
 
-	rows, err := es.DB.Query(query, tournamentID)
+	rows, err := es.DB.QueryContext(ctx, query, tournamentID)
 	if err != nil {
 		return
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		var entrant tournament.Entrant
@@ -31,7 +33,8 @@ WHERE tournament_id = $1`
 			&entrant.Name,
 			&entrant.Placement,
 			&entrant.TournamentID,
-			&entrant.PlayerName,
+			pq.Array(&entrant.Participants),
+			&entrant.Version,
 		)
 
 		if err != nil {
@@ -41,61 +44,80 @@ WHERE tournament_id = $1`
 		entrants = append(entrants, entrant)
 	}
 
-	return entrants, rows.Err()
+	if err = rows.Err(); err != nil {
+		return
+	}
+
+	players, err := getTournamentPlayers(ctx, es.DB, tournamentID)
+	if err != nil {
+		return
+	}
+
+	results, err := getResults(ctx, es.DB, tournamentID)
+	if err != nil {
+		return
+	}
+
+	for i := range entrants {
+		entrants[i].Players = players[entrants[i].ID]
+		entrants[i].Results = results[entrants[i].ID]
+	}
+
+	return entrants, nil
 }
 
-func (es EntrantService) GetEntrantWithPoints(id int64) (entrant tournament.Entrant, points int, err error) {
-	// Get Entrant and Tournament.
-	tx, err := es.DB.Begin()
+func (es EntrantService) GetEntrantWithPoints(ctx context.Context, id int64) (entrant tournament.Entrant, points int, err error) {
+	// Get Entrant, Tournament, and Formula.
+	tx, err := es.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return
 	}
 	defer tx.Rollback()
 
-	entrant, err = getEntrant(tx, id)
+	entrant, err = getEntrant(ctx, tx, id)
 	if err != nil {
 		return
 	}
 
-	tourney, err := getTournament(tx, entrant.TournamentID)
+	tourney, err := getTournament(ctx, tx, entrant.TournamentID)
 	if err != nil {
 		return
 	}
 
-	err = tx.Commit()
+	formula, err := getFormula(ctx, tx)
 	if err != nil {
 		return
 	}
 
-	// Calculate points.
-	PV := slices.Index(tourney.Placements, entrant.Placement)
-	if PV == -1 {
-		panic("could not find entrant placement")
+	err = tx.Commit()
+	if err != nil {
+		return
 	}
 
-	points = tournament.UP*PV + tournament.ATT
-	if entrant.Placement == 1 {
-		points += tournament.FIRST
-	} else if entrant.Placement == 2 && tourney.BracketReset {
-		points += tournament.BR
+	// Calculate points.
+	points, ok := formula.Points(tourney, entrant.Placement)
+	if !ok {
+		err = tournament.Errorf(tournament.EINTERNAL, "Entrant %d has placement %d, which is not one of the placements of their tournament.", entrant.ID, entrant.Placement)
 	}
-	points *= tourney.Tier.Multiplier
 
 	return
 }
 
-func (es EntrantService) GetAttendance(playerID int64) (attendance []tournament.Attendee, err error) {
+func (es EntrantService) GetAttendance(ctx context.Context, playerID int64) (attendance []tournament.Attendee, err error) {
 	query := `
 SELECT tournaments.id, tournaments.name, tiers.name, entrants.name, entrants.placement
-FROM entrants
+FROM entrant_players
+         INNER JOIN entrants on entrant_players.entrant_id = entrants.id
          LEFT OUTER JOIN tournaments on entrants.tournament_id = tournaments.id
          LEFT OUTER JOIN tiers on tournaments.tier_id = tiers.id
-WHERE entrants.player_id = $1`
+WHERE entrant_players.player_id = $1
+  AND tournaments.deleted_at IS NULL`
 
-	rows, err := es.DB.Query(query, playerID)
+	rows, err := es.DB.QueryContext(ctx, query, playerID)
 	if err != nil {
 		return
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		var attendee tournament.Attendee
@@ -118,14 +140,14 @@ WHERE entrants.player_id = $1`
 	return attendance, rows.Err()
 }
 
-func (es EntrantService) CreateEntrants(entrants []tournament.Entrant, tournamentID int64) error {
-	tx, err := es.DB.Begin()
+func (es EntrantService) CreateEntrants(ctx context.Context, entrants []tournament.Entrant, tournamentID int64) error {
+	tx, err := es.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	err = createEntrants(tx, entrants, tournamentID)
+	err = createEntrants(ctx, tx, entrants, tournamentID)
 	if err != nil {
 		return err
 	}
@@ -133,37 +155,120 @@ func (es EntrantService) CreateEntrants(entrants []tournament.Entrant, tournamen
 	return tx.Commit()
 }
 
-func (es EntrantService) SetPlayer(entrantID int64, playerID sql.NullInt64) error {
+func (es EntrantService) SetPlayers(ctx context.Context, entrantID int64, version int, playerIDs []int64) error {
+	tx, err := es.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Updating the version locks the Entrant, so that concurrent changes to it are made one at a time.
+	// A change waiting on the lock will find that the version has changed, and will be rejected.
 	query := `
 UPDATE entrants
-SET player_id = $2
+SET version = version + 1
+WHERE id = $1
+  AND ($2::integer = 0 OR version = $2)`
+
+	result, err := tx.ExecContext(ctx, query, entrantID, version)
+	if err != nil {
+		return err
+	}
+	if rows, err := result.RowsAffected(); err != nil {
+		return err
+	} else if rows == 0 {
+		return versionError(ctx, tx, "entrants", "Entrant", entrantID)
+	}
+
+	// The players of the Entrant are recorded, rather than the whole Entrant.
+	before, err := getEntrant(ctx, tx, entrantID)
+	if err != nil {
+		return err
+	}
+
+	// Links to trashed players are kept, since they are hidden from the caller and should come back if the Player is restored.
+	query = `
+DELETE FROM entrant_players
+WHERE entrant_id = $1
+  AND player_id IN (SELECT id FROM players WHERE deleted_at IS NULL)`
+
+	_, err = tx.ExecContext(ctx, query, entrantID)
+	if err != nil {
+		return err
+	}
+
+	// The tournament ID is copied over so that the database can enforce one entrant per player in each tournament.
+	query = `
+INSERT INTO entrant_players (entrant_id, player_id, tournament_id)
+SELECT id, $2, tournament_id
+FROM entrants
 WHERE id = $1`
 
-	_, err := es.DB.Exec(query, entrantID, playerID)
+	for _, playerID := range playerIDs {
+		_, err = tx.ExecContext(ctx, query, entrantID, playerID)
+		if err != nil {
+			// Unique violations mean that the Player was linked to another Entrant, possibly by someone else while this change was being made.
+			return translateError(err)
+		}
+	}
+
+	after, err := getEntrant(ctx, tx, entrantID)
+	if err != nil {
+		return err
+	}
 
-	return err
+	err = createEntry(ctx, tx, tournament.ActionSetPlayers, entrantID, before.Players, after.Players)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 // Due to the way the database is set up, deleting a Tournament will also delete its entrants, but this will still be implemented.
-func (es EntrantService) DeleteEntrants(tournamentID int64) error {
+func (es EntrantService) DeleteEntrants(ctx context.Context, tournamentID int64) error {
 	query := `
 DELETE FROM entrants
 WHERE tournament_id = $1`
 
-	_, err := es.DB.Exec(query, tournamentID)
+	_, err := es.DB.ExecContext(ctx, query, tournamentID)
 
 	return err
 }
 
-func createEntrants(tx *sql.Tx, entrants []tournament.Entrant, tournamentID int64) error {
+func createEntrants(ctx context.Context, tx *sql.Tx, entrants []tournament.Entrant, tournamentID int64) error {
 	query := `
-INSERT INTO entrants (name, placement, tournament_id)
-VALUES ($1, $2, $3)
-RETURNING id;`
+INSERT INTO entrants (name, placement, tournament_id, participants)
+VALUES ($1, $2, $3, $4)
+RETURNING id, version;`
 
 	for i, entrant := range entrants {
 		// This will update the entrant IDs as it goes along. If any errors occur, any written IDs will be invalidated.
-		err := tx.QueryRow(query, entrant.Name, entrant.Placement, tournamentID).Scan(&entrants[i].ID)
+		err := tx.QueryRowContext(ctx, query, entrant.Name, entrant.Placement, tournamentID, pq.Array(entrant.Participants)).Scan(&entrants[i].ID, &entrants[i].Version)
+		if err != nil {
+			return err
+		}
+
+		err = createResults(ctx, tx, entrant.Results, entrants[i].ID, tournamentID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// createResults adds the given results to an Entrant. The phases of the Tournament should already exist.
+func createResults(ctx context.Context, tx *sql.Tx, results []tournament.Result, entrantID, tournamentID int64) error {
+	query := `
+INSERT INTO results (entrant_id, phase_id, pool, placement)
+SELECT $1, id, $4, $5
+FROM phases
+WHERE tournament_id = $2
+  AND phase_order = $3`
+
+	for _, result := range results {
+		_, err := tx.ExecContext(ctx, query, entrantID, tournamentID, result.Phase, result.Group, result.Placement)
 		if err != nil {
 			return err
 		}
@@ -172,28 +277,100 @@ RETURNING id;`
 	return nil
 }
 
-func getEntrant(tx *sql.Tx, id int64) (entrant tournament.Entrant, err error) {
+// getResults returns the results of all entrants in the given Tournament, mapped by Entrant ID.
+func getResults(ctx context.Context, q queryer, tournamentID int64) (map[int64][]tournament.Result, error) {
+	query := `
+SELECT results.entrant_id, phases.phase_order, results.pool, results.placement
+FROM results
+         INNER JOIN phases ON results.phase_id = phases.id
+WHERE phases.tournament_id = $1
+ORDER BY phases.phase_order`
+
+	rows, err := q.QueryContext(ctx, query, tournamentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make(map[int64][]tournament.Result)
+	for rows.Next() {
+		var (
+			entrantID int64
+			result    tournament.Result
+		)
+
+		err = rows.Scan(&entrantID, &result.Phase, &result.Group, &result.Placement)
+		if err != nil {
+			return nil, err
+		}
+
+		results[entrantID] = append(results[entrantID], result)
+	}
+
+	return results, rows.Err()
+}
+
+func getEntrant(ctx context.Context, q queryer, id int64) (entrant tournament.Entrant, err error) {
 	if id < 1 {
-		return entrant, ErrRecordNotFound
+		return entrant, tournament.Errorf(tournament.ENOTFOUND, "Entrant not found.")
 	}
 
 	query := `
-SELECT entrants.id, entrants.name, placement, tournament_id, players.name
+SELECT id, name, placement, tournament_id, participants, version
 FROM entrants
-         LEFT OUTER JOIN players ON entrants.player_id = players.id
-WHERE entrants.id = $1`
+WHERE id = $1`
 
-	err = tx.QueryRow(query, id).Scan(
+	err = q.QueryRowContext(ctx, query, id).Scan(
 		&entrant.ID,
 		&entrant.Name,
 		&entrant.Placement,
 		&entrant.TournamentID,
-		&entrant.PlayerName,
+		pq.Array(&entrant.Participants),
+		&entrant.Version,
 	)
 
-	if err != nil && errors.Is(err, sql.ErrNoRows) {
-		err = ErrRecordNotFound
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = tournament.Errorf(tournament.ENOTFOUND, "Entrant not found.")
+		}
+		return
 	}
 
+	players, err := getTournamentPlayers(ctx, q, entrant.TournamentID)
+	entrant.Players = players[entrant.ID]
 	return
 }
+
+// getTournamentPlayers returns the players of every Entrant in the given Tournament, mapped by Entrant ID.
+func getTournamentPlayers(ctx context.Context, q queryer, tournamentID int64) (map[int64][]tournament.Player, error) {
+	query := `
+SELECT entrant_players.entrant_id, players.id, players.name, players.version
+FROM entrant_players
+         INNER JOIN players ON entrant_players.player_id = players.id
+WHERE entrant_players.tournament_id = $1
+  AND players.deleted_at IS NULL
+ORDER BY players.name`
+
+	rows, err := q.QueryContext(ctx, query, tournamentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	players := make(map[int64][]tournament.Player)
+	for rows.Next() {
+		var (
+			entrantID int64
+			player    tournament.Player
+		)
+
+		err = rows.Scan(&entrantID, &player.ID, &player.Name, &player.Version)
+		if err != nil {
+			return nil, err
+		}
+
+		players[entrantID] = append(players[entrantID], player)
+	}
+
+	return players, rows.Err()
+}
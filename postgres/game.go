@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	tournament "github.com/ejacobg/tourney-tracker"
+)
+
+// GameService represents a service for managing games.
+type GameService struct {
+	DB *sql.DB
+}
+
+func (gs GameService) GetGames(ctx context.Context) (games []tournament.Game, err error) {
+	query := `
+SELECT id, name
+FROM games
+ORDER BY name`
+
+	rows, err := gs.DB.QueryContext(ctx, query)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var game tournament.Game
+
+		err = rows.Scan(&game.ID, &game.Name)
+		if err != nil {
+			return
+		}
+
+		games = append(games, game)
+	}
+
+	return games, rows.Err()
+}
+
+func (gs GameService) GetGame(ctx context.Context, id int64) (game tournament.Game, err error) {
+	query := `
+SELECT id, name
+FROM games
+WHERE id = $1`
+
+	err = gs.DB.QueryRowContext(ctx, query, id).Scan(&game.ID, &game.Name)
+
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		err = tournament.Errorf(tournament.ENOTFOUND, "Game not found.")
+	}
+
+	return
+}
+
+func (gs GameService) CreateGame(ctx context.Context, game *tournament.Game) error {
+	return createGame(ctx, gs.DB, game)
+}
+
+// createGame adds the given Game, or finds the ID of an existing Game with the same name.
+func createGame(ctx context.Context, q queryer, game *tournament.Game) error {
+	// The update is a no-op, but allows the ID of an existing row to be returned.
+	query := `
+INSERT INTO games (name)
+VALUES ($1)
+ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+RETURNING id`
+
+	return translateError(q.QueryRowContext(ctx, query, game.Name).Scan(&game.ID))
+}
@@ -1,11 +1,14 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	tournament "github.com/ejacobg/tourney-tracker"
+	"github.com/lib/pq"
 	"golang.org/x/exp/maps"
 	"golang.org/x/exp/slices"
+	"time"
 )
 
 // PlayerService represents a service for managing players.
@@ -13,12 +16,20 @@ type PlayerService struct {
 	DB *sql.DB
 }
 
-func (ps PlayerService) GetPlayers() (players []tournament.Player, err error) {
+func (ps PlayerService) GetPlayers(ctx context.Context, gameID int64) (players []tournament.Player, err error) {
 	query := `
-SELECT id, name
-FROM players`
-
-	rows, err := ps.DB.Query(query)
+SELECT id, name, version
+FROM players
+WHERE deleted_at IS NULL
+  AND ($1::bigint = 0
+    OR EXISTS(SELECT 1
+              FROM entrant_players
+                       INNER JOIN tournaments ON tournaments.id = entrant_players.tournament_id
+              WHERE entrant_players.player_id = players.id
+                AND tournaments.deleted_at IS NULL
+                AND tournaments.game_id = $1))`
+
+	rows, err := ps.DB.QueryContext(ctx, query, gameID)
 	if err != nil {
 		return
 	}
@@ -27,7 +38,7 @@ FROM players`
 	for rows.Next() {
 		var player tournament.Player
 
-		err = rows.Scan(&player.ID, &player.Name)
+		err = rows.Scan(&player.ID, &player.Name, &player.Version)
 		if err != nil {
 			return
 		}
@@ -38,60 +49,77 @@ FROM players`
 	return players, rows.Err()
 }
 
-func (ps PlayerService) GetPlayer(id int64) (player tournament.Player, err error) {
-	query := `
-SELECT id, name
-FROM players
-WHERE id = $1`
-
-	err = ps.DB.QueryRow(query, id).Scan(&player.ID, &player.Name)
-
-	if err != nil && errors.Is(err, sql.ErrNoRows) {
-		err = ErrRecordNotFound
-	}
-
-	return
+func (ps PlayerService) GetPlayer(ctx context.Context, id int64) (tournament.Player, error) {
+	return getPlayer(ctx, ps.DB, id)
 }
 
-func (ps PlayerService) GetRanks() ([]tournament.Rank, error) {
+func (ps PlayerService) GetRanks(ctx context.Context, filter tournament.RankFilter) ([]tournament.Rank, error) {
+	// Only tournaments counting towards the chosen leaderboard are joined. Players without any of these tournaments will still be returned.
 	query := `
 SELECT players.id,
        players.name,
-       entrants.placement,
-       idx(tournaments.placements, entrants.placement),
-       tournaments.bracket_reset,
-       tiers.multiplier
+       players.version,
+       scores.placement,
+       scores.bracket_type,
+       scores.bracket_reset,
+       scores.placements,
+       scores.multiplier
 FROM players
-         LEFT OUTER JOIN entrants on entrants.player_id = players.id
-         LEFT OUTER JOIN tournaments on tournaments.id = entrants.tournament_id
-         LEFT OUTER JOIN tiers on tiers.id = tournaments.tier_id`
+         LEFT OUTER JOIN (SELECT entrant_players.player_id,
+                                 entrants.placement,
+                                 tournaments.bracket_type,
+                                 tournaments.bracket_reset,
+                                 tournaments.placements,
+                                 tiers.multiplier
+                          FROM entrant_players
+                                   INNER JOIN entrants on entrants.id = entrant_players.entrant_id
+                                   INNER JOIN tournaments on tournaments.id = entrants.tournament_id
+                                   INNER JOIN tiers on tiers.id = tournaments.tier_id
+                          WHERE tournaments.deleted_at IS NULL
+                            AND (tournaments.teams AND tournaments.team_scoring = 'separate') = $1
+                            AND ($2::bigint = 0 OR tournaments.game_id = $2)) AS scores
+                         ON scores.player_id = players.id
+WHERE players.deleted_at IS NULL`
 
 	var (
 		placement    sql.NullInt64
-		PV           sql.NullInt64
+		bracketType  sql.NullString
 		bracketReset sql.NullBool
 		multiplier   sql.NullInt64
 	)
 
+	formula, err := getFormula(ctx, ps.DB)
+	if err != nil {
+		return nil, err
+	}
+
 	// Map player IDs to their rank.
 	ranks := make(map[int64]tournament.Rank)
 
-	rows, err := ps.DB.Query(query)
+	rows, err := ps.DB.QueryContext(ctx, query, filter.Doubles, filter.GameID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var rank tournament.Rank
+		var (
+			rank    tournament.Rank
+			tourney tournament.Tournament
+		)
 
-		err = rows.Scan(&rank.Player.ID, &rank.Player.Name, &placement, &PV, &bracketReset, &multiplier)
+		err = rows.Scan(&rank.Player.ID, &rank.Player.Name, &rank.Player.Version, &placement, &bracketType, &bracketReset, pq.Array(&tourney.Placements), &multiplier)
 		if err != nil {
 			return nil, err
 		}
 
 		// If the placement value isn't valid, then we can't calculate any points.
 		if !placement.Valid {
+			// Filtered rankings only include players who have played in a matching tournament.
+			if filter.Filtered() {
+				continue
+			}
+
 			// If we haven't seen this player before, give them 0 points.
 			if _, ok := ranks[rank.Player.ID]; !ok {
 				ranks[rank.Player.ID] = rank
@@ -100,14 +128,10 @@ FROM players
 		}
 
 		// Calculate the points earned.
-		// Note: PostgreSQL uses 1-based indices, so we have to subtract the PV by 1.
-		rank.Points = tournament.UP*int(PV.Int64-1) + tournament.ATT
-		if placement.Int64 == 1 {
-			rank.Points += tournament.FIRST
-		} else if placement.Int64 == 2 && bracketReset.Bool {
-			rank.Points += tournament.BR
-		}
-		rank.Points *= int(multiplier.Int64)
+		tourney.BracketType = tournament.BracketType(bracketType.String)
+		tourney.BracketReset = bracketReset.Bool
+		tourney.Tier.Multiplier = int(multiplier.Int64)
+		rank.Points, _ = formula.Points(tourney, placement.Int64)
 
 		// Add the calculated points to the appropriate player.
 		rank.Points += ranks[rank.Player.ID].Points
@@ -123,34 +147,179 @@ FROM players
 	return unsorted, nil
 }
 
-func (ps PlayerService) CreatePlayer(player *tournament.Player) error {
+func (ps PlayerService) CreatePlayer(ctx context.Context, player *tournament.Player) error {
+	tx, err := ps.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	query := `
 INSERT INTO players (name)
 VALUES ($1)
-RETURNING id`
+RETURNING id, version`
 
-	err := ps.DB.QueryRow(query, player.Name).Scan(&player.ID)
+	err = tx.QueryRowContext(ctx, query, player.Name).Scan(&player.ID, &player.Version)
+	if err != nil {
+		return translateError(err)
+	}
 
-	return err
+	err = createEntry(ctx, tx, tournament.ActionCreatePlayer, player.ID, nil, player)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-func (ps PlayerService) UpdatePlayer(player *tournament.Player) error {
+func (ps PlayerService) UpdatePlayer(ctx context.Context, player *tournament.Player) error {
+	tx, err := ps.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = lockRow(ctx, tx, "players", player.ID)
+	if err != nil {
+		return err
+	}
+
+	before, err := getPlayer(ctx, tx, player.ID)
+	if err != nil {
+		return err
+	}
+
 	query := `UPDATE players
-SET name = $2
-WHERE id = $1`
+SET name    = $2,
+    version = version + 1
+WHERE id = $1
+  AND deleted_at IS NULL
+  AND ($3::integer = 0 OR version = $3)
+RETURNING version`
+
+	err = tx.QueryRowContext(ctx, query, player.ID, player.Name, player.Version).Scan(&player.Version)
+
+	// No rows are returned if the version has changed, or the Player does not exist. Unique violations mean that another Player already has the name.
+	if errors.Is(err, sql.ErrNoRows) {
+		err = versionError(ctx, tx, "players", "Player", player.ID)
+	}
+	if err != nil {
+		return translateError(err)
+	}
 
-	_, err := ps.DB.Exec(query, player.ID, player.Name)
+	err = createEntry(ctx, tx, tournament.ActionUpdatePlayer, player.ID, before, player)
+	if err != nil {
+		return err
+	}
 
-	return err
+	return tx.Commit()
 }
 
-func (ps PlayerService) DeletePlayer(id int64) error {
+func (ps PlayerService) DeletePlayer(ctx context.Context, id int64) error {
+	tx, err := ps.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = lockRow(ctx, tx, "players", id)
+	if err != nil {
+		return err
+	}
+
+	// Players in the trash cannot be read, so a missing or trashed Player is reported here.
+	before, err := getPlayer(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	query := `
+UPDATE players
+SET deleted_at = now()
+WHERE id = $1
+  AND deleted_at IS NULL`
+
+	_, err = tx.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	err = createEntry(ctx, tx, tournament.ActionDeletePlayer, id, before, nil)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (ps PlayerService) GetDeletedPlayers(ctx context.Context) ([]tournament.Trashed, error) {
+	query := `
+SELECT id, name, deleted_at
+FROM players
+WHERE deleted_at IS NOT NULL
+ORDER BY deleted_at DESC`
+
+	return getTrashed(ctx, ps.DB, query)
+}
+
+func (ps PlayerService) RestorePlayer(ctx context.Context, id int64) error {
+	tx, err := ps.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+UPDATE players
+SET deleted_at = NULL
+WHERE id = $1
+  AND deleted_at IS NOT NULL`
+
+	result, err := tx.ExecContext(ctx, query, id)
+	if err != nil {
+		// Unique violations mean that another Player has taken the name since this one was moved to the trash.
+		return translateError(err)
+	}
+	if err = trashError(result, "Player not found in the trash."); err != nil {
+		return err
+	}
+
+	// The Player can only be read once it is out of the trash.
+	after, err := getPlayer(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	err = createEntry(ctx, tx, tournament.ActionRestorePlayer, id, nil, after)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (ps PlayerService) PurgePlayers(ctx context.Context, before time.Time) error {
 	query := `
 DELETE FROM players
-WHERE id = $1`
+WHERE deleted_at < $1
+RETURNING id, name, deleted_at`
+
+	// Due to the way the database is set up, deleting a Player will automatically remove it from any entrants pointing to it.
+	return purge(ctx, ps.DB, tournament.ActionPurgePlayer, query, before)
+}
 
-	_, err := ps.DB.Exec(query, id)
+func getPlayer(ctx context.Context, q queryer, id int64) (player tournament.Player, err error) {
+	query := `
+SELECT id, name, version
+FROM players
+WHERE id = $1
+  AND deleted_at IS NULL`
 
-	// Due to the way the database is set up, deleting a Player will automatically nullify any entrants pointing to it.
-	return err
+	err = q.QueryRowContext(ctx, query, id).Scan(&player.ID, &player.Name, &player.Version)
+
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		err = tournament.Errorf(tournament.ENOTFOUND, "Player not found.")
+	}
+
+	return
 }
@@ -1,5 +1,235 @@
 package postgres
 
-import "errors"
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	tournament "github.com/ejacobg/tourney-tracker"
+	"github.com/ejacobg/tourney-tracker/migrations"
+	"github.com/lib/pq"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+)
 
-var ErrRecordNotFound = errors.New("record not found")
+// NewServices returns an implementation of every service, all using the given database.
+func NewServices(db *sql.DB) tournament.Services {
+	return tournament.Services{
+		AuditService:      AuditService{DB: db},
+		EntrantService:    EntrantService{DB: db},
+		FormulaService:    FormulaService{DB: db},
+		GameService:       GameService{DB: db},
+		PlayerService:     PlayerService{DB: db},
+		SessionService:    SessionService{DB: db},
+		SnapshotService:   SnapshotService{DB: db},
+		TierService:       TierService{DB: db},
+		TokenService:      TokenService{DB: db},
+		TournamentService: TournamentService{DB: db},
+		UserService:       UserService{DB: db},
+	}
+}
+
+// queryer is implemented by both *sql.DB and *sql.Tx, allowing helper functions to be used inside and outside of transactions.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// migrationLock is the key of the advisory lock held while a migration is applied, so that servers starting at the same time do not apply it twice.
+const migrationLock = 4000
+
+// Version returns the version of the last migration applied to the database, and the version of the last migration in the migrations package.
+// Versions are kept in the schema_migrations table used by golang-migrate, so databases set up with its CLI carry on from where they were.
+// An error is returned if a migration previously failed partway, since the schema has to be fixed by hand.
+func Version(db *sql.DB) (current, latest int, err error) {
+	versions, err := migrationVersions()
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(versions) > 0 {
+		latest = versions[len(versions)-1].version
+	}
+
+	current, err = schemaVersion(context.Background(), db)
+	return current, latest, err
+}
+
+// Migrate applies any migrations in the migrations package that have not been applied yet.
+// Each migration is applied in its own transaction, so a failed migration leaves the database at the previous version.
+func Migrate(db *sql.DB) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (version bigint NOT NULL PRIMARY KEY, dirty boolean NOT NULL)`)
+	if err != nil {
+		return err
+	}
+
+	versions, err := migrationVersions()
+	if err != nil {
+		return err
+	}
+
+	for _, v := range versions {
+		if err = migrate(db, v.name, v.version); err != nil {
+			return fmt.Errorf("migration %s: %w", v.name, err)
+		}
+	}
+
+	return nil
+}
+
+// migration names the up script of a single version.
+type migration struct {
+	name    string
+	version int
+}
+
+// migrationVersions returns the up migrations in the migrations package, in order.
+func migrationVersions() ([]migration, error) {
+	names, err := fs.Glob(migrations.FS, "*.up.sql")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	versions := make([]migration, len(names))
+	for i, name := range names {
+		version, err := strconv.Atoi(strings.SplitN(name, "_", 2)[0])
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: invalid version: %w", name, err)
+		}
+		versions[i] = migration{name, version}
+	}
+
+	return versions, nil
+}
+
+// schemaVersion returns the version of the last migration applied, or 0 if none have been.
+func schemaVersion(ctx context.Context, q queryer) (version int, err error) {
+	var exists bool
+	if err = q.QueryRowContext(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists); err != nil || !exists {
+		return 0, err
+	}
+
+	var dirty bool
+	err = q.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err == nil && dirty {
+		err = fmt.Errorf("migration %d did not finish; fix the schema by hand, then set schema_migrations.dirty to false", version)
+	}
+
+	return version, err
+}
+
+// migrate applies a single migration, then records its version. Nothing is changed if the migration fails, or if it has already been applied.
+func migrate(db *sql.DB, name string, version int) error {
+	script, err := migrations.FS.ReadFile(name)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// The version is checked again once the lock is held, since another server may have applied the migration while this one waited.
+	if _, err = tx.Exec(`SELECT pg_advisory_xact_lock($1)`, migrationLock); err != nil {
+		return err
+	}
+
+	current, err := schemaVersion(context.Background(), tx)
+	if err != nil || current >= version {
+		return err
+	}
+
+	if _, err = tx.Exec(string(script)); err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec(`TRUNCATE schema_migrations`); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(`INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)`, version); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// trashTables holds the tables whose rows can be moved to the trash, using their deleted_at column.
+var trashTables = map[string]bool{"players": true, "tournaments": true}
+
+// versionError returns the error for an update of the given row that changed nothing because of its version.
+// The update may also have changed nothing because the row does not exist, so an ENOTFOUND error is returned in that case instead of an ECONFLICT.
+// The name of the row's type is used in the error message, eg. "Player". Rows in the trash are treated as if they do not exist.
+func versionError(ctx context.Context, q queryer, table, name string, id int64) error {
+	query := `SELECT EXISTS(SELECT 1 FROM %s WHERE id = $1)`
+	if trashTables[table] {
+		query = `SELECT EXISTS(SELECT 1 FROM %s WHERE id = $1 AND deleted_at IS NULL)`
+	}
+
+	var exists bool
+	err := q.QueryRowContext(ctx, fmt.Sprintf(query, table), id).Scan(&exists)
+
+	switch {
+	case err != nil:
+		return err
+	case !exists:
+		return tournament.Errorf(tournament.ENOTFOUND, "%s not found.", name)
+	default:
+		return tournament.Errorf(tournament.ECONFLICT, "The %s was changed by someone else.", strings.ToLower(name))
+	}
+}
+
+// constraintMessages holds the message shown to users for each constraint that their changes may violate.
+var constraintMessages = map[string]string{
+	"entrant_players_pkey":                        "That player is already linked to this entrant.",
+	"entrant_players_player_id_fkey":              "That player does not exist.",
+	"entrant_players_tournament_id_player_id_key": "That player is already linked to another entrant in this tournament.",
+	"games_name_key":                              "Another game already has that name.",
+	"players_name_idx":                            "Another player already has that name.",
+	"tokens_user_id_fkey":                         "That user does not exist.",
+	"tournaments_game_id_fkey":                    "That game does not exist.",
+	"tournaments_tier_id_fkey":                    "That tier does not exist.",
+	"users_name_key":                              "Another user already has that name.",
+}
+
+// translateError converts errors caused by invalid data into *tournament.Error values, so that their messages can be shown to users.
+// Unique violations become ECONFLICT errors, and other constraint violations become EINVALID errors. Other errors are returned as-is.
+func translateError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+
+	message, ok := constraintMessages[pqErr.Constraint]
+
+	switch pqErr.Code {
+	case "23505": // unique_violation
+		if !ok {
+			message = "This conflicts with existing data."
+		}
+		return &tournament.Error{Code: tournament.ECONFLICT, Message: message}
+	case "23503": // foreign_key_violation
+		// The same constraint is violated when deleting a row that is still referenced, which is a conflict rather than a bad reference.
+		if strings.HasPrefix(pqErr.Message, "update or delete") {
+			return tournament.Errorf(tournament.ECONFLICT, "This is still in use.")
+		}
+		if !ok {
+			message = "This refers to something that does not exist."
+		}
+		return &tournament.Error{Code: tournament.EINVALID, Message: message}
+	case "23502", "23514", "22P02", "22003": // not_null_violation, check_violation, invalid_text_representation, numeric_value_out_of_range
+		if !ok {
+			message = "Invalid value."
+		}
+		return &tournament.Error{Code: tournament.EINVALID, Message: message}
+	}
+
+	return err
+}
@@ -0,0 +1,121 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	tournament "github.com/ejacobg/tourney-tracker"
+	"github.com/ejacobg/tourney-tracker/servicetest"
+	"os"
+	"testing"
+)
+
+// openTestDB opens the database named by TOURNEYTRACKER_TEST_DSN, and empties it so that only the default tiers remain.
+// The test is skipped if the variable is not set. The database is migrated and then wiped, so never point it at real data.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TOURNEYTRACKER_TEST_DSN")
+	if dsn == "" {
+		t.Skip("TOURNEYTRACKER_TEST_DSN is not set")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err = Migrate(db); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+
+	query := `
+TRUNCATE tiers, tournaments, players, entrants, phases, results, entrant_players, games, users, sessions, tokens, audit_log, snapshots, formula
+RESTART IDENTITY CASCADE;
+
+INSERT INTO tiers (name, multiplier)
+VALUES ('C', 75),
+       ('B', 150),
+       ('A', 200),
+       ('S', 300);`
+
+	if _, err = db.Exec(query); err != nil {
+		t.Fatalf("resetting the test database: %v", err)
+	}
+	return db
+}
+
+// TestSnapshotService_RestoreSnapshot_older checks that snapshots taken before a column or table was added can still be restored.
+func TestSnapshotService_RestoreSnapshot_older(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	// This snapshot has no player versions, and no formula.
+	data := `{"tiers": [{"id": 1, "name": "C", "multiplier": 75}], "games": [], "players": [{"id": 1, "name": "Mango", "deleted_at": null}],
+"tournaments": [], "phases": [], "entrants": [], "results": [], "entrant_players": []}`
+	var id int64
+	err := db.QueryRow(`INSERT INTO snapshots (name, data) VALUES ('older', $1) RETURNING id`, data).Scan(&id)
+	if err != nil {
+		t.Fatalf("inserting the snapshot: %v", err)
+	}
+
+	if err = (FormulaService{DB: db}).UpdateFormula(ctx, tournament.Formula{UP: 1, ATT: 2, FIRST: 3, BR: 4}); err != nil {
+		t.Fatalf("UpdateFormula() error = %v", err)
+	}
+
+	if err = (SnapshotService{DB: db}).RestoreSnapshot(ctx, id); err != nil {
+		t.Fatalf("RestoreSnapshot() error = %v", err)
+	}
+
+	player, err := PlayerService{DB: db}.GetPlayer(ctx, 1)
+	if err != nil || player.Name != "Mango" || player.Version != 1 {
+		t.Errorf("GetPlayer() = %+v, %v, want Mango at version 1", player, err)
+	}
+	if formula, err := (FormulaService{DB: db}).GetFormula(ctx); err != nil || formula != tournament.DefaultFormula {
+		t.Errorf("GetFormula() = %+v, %v, want the default formula", formula, err)
+	}
+}
+
+func TestTokenService_AuthenticateToken(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	us := UserService{DB: db}
+	user := tournament.User{Name: "organizer", Role: tournament.RoleOrganizer, PasswordHash: []byte("hash")}
+	if err := us.CreateUser(ctx, &user); err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+
+	ts := TokenService{DB: db}
+	token := tournament.Token{Plaintext: "secret", Label: "bot", Role: tournament.RoleOrganizer, UserID: user.ID}
+	if err := ts.CreateToken(ctx, &token); err != nil {
+		t.Fatalf("CreateToken() error = %v", err)
+	}
+
+	got, err := ts.AuthenticateToken(ctx, "secret")
+	if err != nil {
+		t.Fatalf("AuthenticateToken() error = %v", err)
+	}
+	if got.ID != token.ID || got.UserName != "organizer" || got.Role != tournament.RoleOrganizer || got.LastUsedAt == nil {
+		t.Errorf("AuthenticateToken() = %+v, want organizer token %d of organizer with a last use", got, token.ID)
+	}
+
+	// A demoted User's tokens lose the permissions that the User lost.
+	user.Role = tournament.RoleEditor
+	if err = us.UpdateUser(ctx, &user); err != nil {
+		t.Fatalf("UpdateUser() error = %v", err)
+	}
+	if got, err = ts.AuthenticateToken(ctx, "secret"); err != nil || got.Role != tournament.RoleEditor {
+		t.Errorf("AuthenticateToken() after demotion = %+v, %v, want the editor role", got, err)
+	}
+
+	if _, err = ts.AuthenticateToken(ctx, "wrong"); tournament.ErrorCode(err) != tournament.ENOTFOUND {
+		t.Errorf("AuthenticateToken() with a wrong token error = %v, want %s", err, tournament.ENOTFOUND)
+	}
+}
+
+func TestServices(t *testing.T) {
+	servicetest.Run(t, func(t *testing.T) tournament.Services {
+		return NewServices(openTestDB(t))
+	})
+}
@@ -0,0 +1,59 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	tournament "github.com/ejacobg/tourney-tracker"
+)
+
+// SessionService represents a service for managing sessions.
+type SessionService struct {
+	DB *sql.DB
+}
+
+func (ss SessionService) CreateSession(ctx context.Context, session tournament.Session) error {
+	query := `
+INSERT INTO sessions (token_hash, user_id, expiry)
+VALUES ($1, $2, $3)`
+
+	_, err := ss.DB.ExecContext(ctx, query, tournament.HashToken(session.Token), session.UserID, session.Expiry)
+	return err
+}
+
+func (ss SessionService) GetSessionUser(ctx context.Context, token string) (user tournament.User, err error) {
+	query := `
+SELECT users.id, users.name, users.role, users.password_hash, users.created_at
+FROM sessions
+         INNER JOIN users ON users.id = sessions.user_id
+WHERE sessions.token_hash = $1
+  AND sessions.expiry > now()`
+
+	err = ss.DB.QueryRowContext(ctx, query, tournament.HashToken(token)).Scan(&user.ID, &user.Name, &user.Role, &user.PasswordHash, &user.CreatedAt)
+
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		err = tournament.Errorf(tournament.ENOTFOUND, "Session not found.")
+	}
+
+	return
+}
+
+func (ss SessionService) DeleteSession(ctx context.Context, token string) error {
+	query := `
+DELETE
+FROM sessions
+WHERE token_hash = $1`
+
+	_, err := ss.DB.ExecContext(ctx, query, tournament.HashToken(token))
+	return err
+}
+
+func (ss SessionService) DeleteExpiredSessions(ctx context.Context) error {
+	query := `
+DELETE
+FROM sessions
+WHERE expiry <= now()`
+
+	_, err := ss.DB.ExecContext(ctx, query)
+	return err
+}
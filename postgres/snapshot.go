@@ -0,0 +1,272 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	tournament "github.com/ejacobg/tourney-tracker"
+	"strings"
+)
+
+// snapshotTables holds every table saved in a Snapshot, ordered so that each table comes after the tables it references.
+var snapshotTables = []string{"tiers", "games", "players", "tournaments", "phases", "entrants", "results", "entrant_players", "formula"}
+
+// snapshotDefaults holds the values of the NOT NULL columns that were added after snapshots were first taken, as a JSON object for each table.
+// Older snapshots do not have these columns, so their rows are restored using these values instead.
+var snapshotDefaults = map[string]string{
+	"players":  `{"version": 1}`,
+	"entrants": `{"version": 1}`,
+}
+
+// serialTables holds the tables in snapshotTables with a bigserial ID. Their sequences need to be moved past the restored IDs.
+var serialTables = []string{"tiers", "games", "players", "tournaments", "phases", "entrants"}
+
+// SnapshotService represents a service for saving and restoring copies of the tracker's data.
+// Each Snapshot stores the rows of every table in snapshotTables as JSON, which are converted back into rows when restored.
+// Tables and columns missing from a Snapshot are restored empty, or using their snapshotDefaults.
+type SnapshotService struct {
+	DB *sql.DB
+}
+
+func (ss SnapshotService) GetSnapshots(ctx context.Context) (snapshots []tournament.Snapshot, err error) {
+	query := `
+SELECT id, name, automatic, created_by, created_at, jsonb_array_length(data -> 'tournaments'), jsonb_array_length(data -> 'players')
+FROM snapshots
+ORDER BY created_at DESC`
+
+	rows, err := ss.DB.QueryContext(ctx, query)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var snapshot tournament.Snapshot
+
+		err = rows.Scan(&snapshot.ID, &snapshot.Name, &snapshot.Automatic, &snapshot.CreatedBy, &snapshot.CreatedAt, &snapshot.Tournaments, &snapshot.Players)
+		if err != nil {
+			return
+		}
+
+		snapshots = append(snapshots, snapshot)
+	}
+
+	return snapshots, rows.Err()
+}
+
+func (ss SnapshotService) GetSnapshot(ctx context.Context, id int64) (tournament.Snapshot, error) {
+	return getSnapshot(ctx, ss.DB, id)
+}
+
+func (ss SnapshotService) CreateSnapshot(ctx context.Context, snapshot *tournament.Snapshot) error {
+	// The data is read in a single statement, so every table is copied from the same point in time.
+	query := `
+INSERT INTO snapshots (name, automatic, created_by, data)
+SELECT $1,
+       $2,
+       $3,
+       jsonb_build_object(
+               'tiers', (SELECT COALESCE(jsonb_agg(t), '[]') FROM tiers t),
+               'games', (SELECT COALESCE(jsonb_agg(t), '[]') FROM games t),
+               'players', (SELECT COALESCE(jsonb_agg(t), '[]') FROM players t),
+               'tournaments', (SELECT COALESCE(jsonb_agg(t), '[]') FROM tournaments t),
+               'phases', (SELECT COALESCE(jsonb_agg(t), '[]') FROM phases t),
+               'entrants', (SELECT COALESCE(jsonb_agg(t), '[]') FROM entrants t),
+               'results', (SELECT COALESCE(jsonb_agg(t), '[]') FROM results t),
+               'entrant_players', (SELECT COALESCE(jsonb_agg(t), '[]') FROM entrant_players t),
+               'formula', (SELECT COALESCE(jsonb_agg(t), '[]') FROM formula t)
+           )
+RETURNING id, created_at, jsonb_array_length(data -> 'tournaments'), jsonb_array_length(data -> 'players')`
+
+	tx, err := ss.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, query, snapshot.Name, snapshot.Automatic, snapshot.CreatedBy).
+		Scan(&snapshot.ID, &snapshot.CreatedAt, &snapshot.Tournaments, &snapshot.Players)
+	if err != nil {
+		return err
+	}
+
+	err = createEntry(ctx, tx, tournament.ActionCreateSnapshot, snapshot.ID, nil, snapshot)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (ss SnapshotService) RestoreSnapshot(ctx context.Context, id int64) error {
+	tx, err := ss.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// The data is read first, so that a missing Snapshot cannot clear every table.
+	var data string
+	err = tx.QueryRowContext(ctx, `SELECT data FROM snapshots WHERE id = $1`, id).Scan(&data)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = tournament.Errorf(tournament.ENOTFOUND, "Snapshot not found.")
+		}
+		return err
+	}
+
+	// The restored Snapshot is recorded as the value after the change.
+	after, err := getSnapshot(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	// No other changes may be made while the tables are being replaced.
+	_, err = tx.ExecContext(ctx, fmt.Sprintf(`LOCK TABLE %s IN ACCESS EXCLUSIVE MODE`, strings.Join(snapshotTables, ", ")))
+	if err != nil {
+		return err
+	}
+
+	// Tables are cleared in reverse order, so that no row is deleted while another row still references it.
+	for i := len(snapshotTables) - 1; i >= 0; i-- {
+		_, err = tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s`, snapshotTables[i]))
+		if err != nil {
+			return err
+		}
+	}
+
+	// Converting the JSON using the table's own row type puts each value back into the right column.
+	// Each row is merged over the table's defaults first, so that the values of any missing columns are filled in.
+	for _, table := range snapshotTables {
+		defaults, ok := snapshotDefaults[table]
+		if !ok {
+			defaults = "{}"
+		}
+
+		query := fmt.Sprintf(`
+INSERT INTO %[1]s
+SELECT *
+FROM jsonb_populate_recordset(NULL::%[1]s, (SELECT COALESCE(jsonb_agg($2::jsonb || element), '[]')
+                                            FROM jsonb_array_elements($1::jsonb -> '%[1]s') AS elements(element)))`, table)
+
+		_, err = tx.ExecContext(ctx, query, data, defaults)
+		if err != nil {
+			return err
+		}
+	}
+
+	// New rows should not reuse the restored IDs.
+	for _, table := range serialTables {
+		query := fmt.Sprintf(`
+SELECT setval(pg_get_serial_sequence('%[1]s', 'id'), COALESCE(MAX(id), 0) + 1, false)
+FROM %[1]s`, table)
+
+		_, err = tx.ExecContext(ctx, query)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = createEntry(ctx, tx, tournament.ActionRestoreSnapshot, id, nil, after)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (ss SnapshotService) DeleteSnapshot(ctx context.Context, id int64) error {
+	tx, err := ss.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = lockRow(ctx, tx, "snapshots", id)
+	if err != nil {
+		return err
+	}
+
+	before, err := getSnapshot(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM snapshots WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	err = createEntry(ctx, tx, tournament.ActionDeleteSnapshot, id, before, nil)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (ss SnapshotService) PurgeSnapshots(ctx context.Context, keep int) error {
+	tx, err := ss.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// IDs are assigned in order, so the newest snapshots have the highest IDs, even if they were taken at the same time.
+	query := `
+DELETE
+FROM snapshots
+WHERE automatic
+  AND id NOT IN (SELECT id
+                 FROM snapshots
+                 WHERE automatic
+                 ORDER BY id DESC
+                 LIMIT $1)
+RETURNING id, name, automatic, created_by, created_at, jsonb_array_length(data -> 'tournaments'), jsonb_array_length(data -> 'players')`
+
+	rows, err := tx.QueryContext(ctx, query, keep)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var purged []tournament.Snapshot
+	for rows.Next() {
+		var snapshot tournament.Snapshot
+
+		err = rows.Scan(&snapshot.ID, &snapshot.Name, &snapshot.Automatic, &snapshot.CreatedBy, &snapshot.CreatedAt, &snapshot.Tournaments, &snapshot.Players)
+		if err != nil {
+			return err
+		}
+
+		purged = append(purged, snapshot)
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for _, snapshot := range purged {
+		err = createEntry(ctx, tx, tournament.ActionPurgeSnapshot, snapshot.ID, snapshot, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func getSnapshot(ctx context.Context, q queryer, id int64) (snapshot tournament.Snapshot, err error) {
+	query := `
+SELECT id, name, automatic, created_by, created_at, jsonb_array_length(data -> 'tournaments'), jsonb_array_length(data -> 'players')
+FROM snapshots
+WHERE id = $1`
+
+	err = q.QueryRowContext(ctx, query, id).Scan(&snapshot.ID, &snapshot.Name, &snapshot.Automatic, &snapshot.CreatedBy, &snapshot.CreatedAt, &snapshot.Tournaments, &snapshot.Players)
+
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		err = tournament.Errorf(tournament.ENOTFOUND, "Snapshot not found.")
+	}
+
+	return
+}
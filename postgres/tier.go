@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	tournament "github.com/ejacobg/tourney-tracker"
@@ -11,12 +12,12 @@ type TierService struct {
 	DB *sql.DB
 }
 
-func (ts TierService) GetTiers() (tiers []tournament.Tier, err error) {
+func (ts TierService) GetTiers(ctx context.Context) (tiers []tournament.Tier, err error) {
 	query := `
 SELECT id, name, multiplier
 FROM tiers`
 
-	rows, err := ts.DB.Query(query)
+	rows, err := ts.DB.QueryContext(ctx, query)
 	if err != nil {
 		return
 	}
@@ -36,58 +37,64 @@ FROM tiers`
 	return tiers, rows.Err()
 }
 
-func (ts TierService) GetTier(id int64) (tier tournament.Tier, err error) {
+func (ts TierService) GetTier(ctx context.Context, id int64) (tier tournament.Tier, err error) {
 	query := `
 SELECT id, name, multiplier
 FROM tiers
 WHERE id = $1`
 
-	err = ts.DB.QueryRow(query, id).Scan(&tier.ID, &tier.Name, &tier.Multiplier)
+	err = ts.DB.QueryRowContext(ctx, query, id).Scan(&tier.ID, &tier.Name, &tier.Multiplier)
 
 	if err != nil && errors.Is(err, sql.ErrNoRows) {
-		err = ErrRecordNotFound
+		err = tournament.Errorf(tournament.ENOTFOUND, "Tier not found.")
 	}
 
 	return
 }
 
-func (ts TierService) GetTournamentTier(tournamentID int64) (tier tournament.Tier, _ error) {
+func (ts TierService) GetTournamentTier(ctx context.Context, tournamentID int64) (tier tournament.Tier, err error) {
 	query := `
 SELECT tiers.id, tiers.name, multiplier
 FROM tournaments
 INNER JOIN tiers on tournaments.tier_id = tiers.id
 WHERE tournaments.id = $1`
 
-	return tier, ts.DB.QueryRow(query, tournamentID).Scan(&tier.ID, &tier.Name, &tier.Multiplier)
+	err = ts.DB.QueryRowContext(ctx, query, tournamentID).Scan(&tier.ID, &tier.Name, &tier.Multiplier)
+
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		err = tournament.Errorf(tournament.ENOTFOUND, "Tournament not found.")
+	}
+
+	return
 }
 
-func (ts TierService) CreateTier(tier *tournament.Tier) error {
+func (ts TierService) CreateTier(ctx context.Context, tier *tournament.Tier) error {
 	query := `
 INSERT INTO tiers (name, multiplier)
 VALUES ($1, $2)
 RETURNING id`
 
-	err := ts.DB.QueryRow(query, tier.Name, tier.Multiplier).Scan(&tier.ID)
+	err := ts.DB.QueryRowContext(ctx, query, tier.Name, tier.Multiplier).Scan(&tier.ID)
 
-	return err
+	return translateError(err)
 }
 
-func (ts TierService) UpdateTier(tier *tournament.Tier) error {
+func (ts TierService) UpdateTier(ctx context.Context, tier *tournament.Tier) error {
 	query := `UPDATE tiers
 SET name = $2, multiplier = $3
 WHERE id = $1`
 
-	_, err := ts.DB.Exec(query, tier.ID, tier.Name, tier.Multiplier)
+	_, err := ts.DB.ExecContext(ctx, query, tier.ID, tier.Name, tier.Multiplier)
 
-	return err
+	return translateError(err)
 }
 
-func (ts TierService) DeleteTier(id int64) error {
+func (ts TierService) DeleteTier(ctx context.Context, id int64) error {
 	query := `
 DELETE FROM tiers
 WHERE id = $1`
 
-	_, err := ts.DB.Exec(query, id)
+	_, err := ts.DB.ExecContext(ctx, query, id)
 
-	return err
+	return translateError(err)
 }
@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	tournament "github.com/ejacobg/tourney-tracker"
+)
+
+// TokenService represents a service for managing API tokens.
+type TokenService struct {
+	DB *sql.DB
+}
+
+func (ts TokenService) GetTokens(ctx context.Context) (tokens []tournament.Token, err error) {
+	query := `
+SELECT tokens.id, label, tokens.role, user_id, users.name, tokens.created_at, last_used_at
+FROM tokens
+         INNER JOIN users ON users.id = tokens.user_id
+ORDER BY tokens.created_at DESC`
+
+	rows, err := ts.DB.QueryContext(ctx, query)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var token tournament.Token
+
+		err = rows.Scan(&token.ID, &token.Label, &token.Role, &token.UserID, &token.UserName, &token.CreatedAt, &token.LastUsedAt)
+		if err != nil {
+			return
+		}
+
+		tokens = append(tokens, token)
+	}
+
+	return tokens, rows.Err()
+}
+
+func (ts TokenService) CreateToken(ctx context.Context, token *tournament.Token) error {
+	query := `
+INSERT INTO tokens (token_hash, label, role, user_id)
+VALUES ($1, $2, $3, $4)
+RETURNING id, created_at`
+
+	err := ts.DB.QueryRowContext(ctx, query, tournament.HashToken(token.Plaintext), token.Label, token.Role, token.UserID).Scan(&token.ID, &token.CreatedAt)
+	return translateError(err)
+}
+
+// AuthenticateToken finds the Token and updates its last use in a single statement.
+func (ts TokenService) AuthenticateToken(ctx context.Context, plaintext string) (token tournament.Token, err error) {
+	query := `
+UPDATE tokens
+SET last_used_at = now()
+FROM users
+WHERE tokens.token_hash = $1
+  AND users.id = tokens.user_id
+RETURNING tokens.id, tokens.label, tokens.role, tokens.user_id, users.name, users.role, tokens.created_at, tokens.last_used_at`
+
+	var userRole tournament.Role
+	err = ts.DB.QueryRowContext(ctx, query, tournament.HashToken(plaintext)).Scan(&token.ID, &token.Label, &token.Role, &token.UserID, &token.UserName, &userRole, &token.CreatedAt, &token.LastUsedAt)
+	token.Role = token.Role.Limit(userRole)
+
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		err = tournament.Errorf(tournament.ENOTFOUND, "Token not found.")
+	}
+
+	return
+}
+
+func (ts TokenService) RevokeToken(ctx context.Context, id int64) error {
+	query := `
+DELETE
+FROM tokens
+WHERE id = $1`
+
+	_, err := ts.DB.ExecContext(ctx, query, id)
+	return err
+}
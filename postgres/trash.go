@@ -0,0 +1,67 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	tournament "github.com/ejacobg/tourney-tracker"
+	"time"
+)
+
+// getTrashed runs the given query, which should select the ID, name, and deletion time of trashed objects.
+func getTrashed(ctx context.Context, q queryer, query string, args ...any) (trashed []tournament.Trashed, err error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var t tournament.Trashed
+
+		err = rows.Scan(&t.ID, &t.Name, &t.DeletedAt)
+		if err != nil {
+			return
+		}
+
+		trashed = append(trashed, t)
+	}
+
+	return trashed, rows.Err()
+}
+
+// trashError returns an ENOTFOUND error with the given message if the given statement changed nothing.
+// It is used for statements that move an object into or out of the trash, and for changes that skip objects in the trash.
+func trashError(result sql.Result, message string) error {
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return &tournament.Error{Code: tournament.ENOTFOUND, Message: message}
+	}
+	return nil
+}
+
+// purge runs the given query, which should permanently delete the objects trashed before the given time, returning their ID, name, and deletion time.
+// Each deleted object is recorded using the given action.
+func purge(ctx context.Context, db *sql.DB, action tournament.Action, query string, before time.Time) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	purged, err := getTrashed(ctx, tx, query, before)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range purged {
+		err = createEntry(ctx, tx, action, t.ID, t, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,221 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	tournament "github.com/ejacobg/tourney-tracker"
+)
+
+// UserService represents a service for managing users.
+type UserService struct {
+	DB *sql.DB
+}
+
+func (us UserService) GetUsers(ctx context.Context) (users []tournament.User, err error) {
+	query := `
+SELECT id, name, role, password_hash, created_at
+FROM users
+ORDER BY name`
+
+	rows, err := us.DB.QueryContext(ctx, query)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var user tournament.User
+
+		err = rows.Scan(&user.ID, &user.Name, &user.Role, &user.PasswordHash, &user.CreatedAt)
+		if err != nil {
+			return
+		}
+
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+func (us UserService) GetUser(ctx context.Context, id int64) (tournament.User, error) {
+	return getUser(ctx, us.DB, id)
+}
+
+func (us UserService) GetUserByName(ctx context.Context, name string) (user tournament.User, err error) {
+	query := `
+SELECT id, name, role, password_hash, created_at
+FROM users
+WHERE name = $1`
+
+	err = us.DB.QueryRowContext(ctx, query, name).Scan(&user.ID, &user.Name, &user.Role, &user.PasswordHash, &user.CreatedAt)
+
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		err = tournament.Errorf(tournament.ENOTFOUND, "User not found.")
+	}
+
+	return
+}
+
+func (us UserService) CreateUser(ctx context.Context, user *tournament.User) error {
+	query := `
+INSERT INTO users (name, role, password_hash)
+VALUES ($1, $2, $3)
+RETURNING id, created_at`
+
+	tx, err := us.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, query, user.Name, user.Role, user.PasswordHash).Scan(&user.ID, &user.CreatedAt)
+	if err != nil {
+		return translateError(err)
+	}
+
+	err = createEntry(ctx, tx, tournament.ActionCreateUser, user.ID, nil, user)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpdateUser only updates the User if they are not the last admin, or will still be an admin afterwards.
+func (us UserService) UpdateUser(ctx context.Context, user *tournament.User) error {
+	tx, err := us.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Every admin is locked first, so that concurrent changes to admins are made one at a time.
+	// A change waiting on the lock will see the result of the change before it, and cannot also remove an admin.
+	_, err = tx.ExecContext(ctx, `SELECT id FROM users WHERE role = 'admin' ORDER BY id FOR UPDATE`)
+	if err != nil {
+		return err
+	}
+
+	err = lockRow(ctx, tx, "users", user.ID)
+	if err != nil {
+		return err
+	}
+
+	before, err := getUser(ctx, tx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	query := `
+UPDATE users
+SET name          = $2,
+    role          = $3,
+    password_hash = $4
+WHERE id = $1
+  AND ($3::text = 'admin' OR role <> 'admin' OR EXISTS(SELECT 1 FROM users WHERE role = 'admin' AND id <> $1))`
+
+	result, err := tx.ExecContext(ctx, query, user.ID, user.Name, user.Role, user.PasswordHash)
+	if err != nil {
+		return translateError(err)
+	}
+	if err = lastAdminError(ctx, tx, result, user.ID); err != nil {
+		return err
+	}
+
+	after, err := getUser(ctx, tx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	err = createEntry(ctx, tx, tournament.ActionUpdateUser, user.ID, before, after)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteUser deletes the given User, unless they are the last admin. Their sessions are deleted by the foreign key cascade.
+func (us UserService) DeleteUser(ctx context.Context, id int64) error {
+	tx, err := us.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Every admin is locked first, so that concurrent changes to admins are made one at a time.
+	// A change waiting on the lock will see the result of the change before it, and cannot also remove an admin.
+	_, err = tx.ExecContext(ctx, `SELECT id FROM users WHERE role = 'admin' ORDER BY id FOR UPDATE`)
+	if err != nil {
+		return err
+	}
+
+	err = lockRow(ctx, tx, "users", id)
+	if err != nil {
+		return err
+	}
+
+	before, err := getUser(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	// The Entry is written before the User is deleted, since a User deleting themselves would otherwise be recorded with a missing user ID.
+	err = createEntry(ctx, tx, tournament.ActionDeleteUser, id, before, nil)
+	if err != nil {
+		return err
+	}
+
+	query := `
+DELETE
+FROM users
+WHERE id = $1
+  AND (role <> 'admin' OR EXISTS(SELECT 1 FROM users WHERE role = 'admin' AND id <> $1))`
+
+	result, err := tx.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if err = lastAdminError(ctx, tx, result, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// lastAdminError returns the error for a change to the given User, if the change did nothing.
+// Changes do nothing if the User does not exist, or if they are the last admin and would no longer be one.
+func lastAdminError(ctx context.Context, q queryer, result sql.Result, id int64) error {
+	rows, err := result.RowsAffected()
+	if err != nil || rows > 0 {
+		return err
+	}
+
+	var exists bool
+	err = q.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)`, id).Scan(&exists)
+
+	switch {
+	case err != nil:
+		return err
+	case !exists:
+		return tournament.Errorf(tournament.ENOTFOUND, "User not found.")
+	default:
+		return tournament.Errorf(tournament.ECONFLICT, "There must be at least one admin.")
+	}
+}
+
+func getUser(ctx context.Context, q queryer, id int64) (user tournament.User, err error) {
+	query := `
+SELECT id, name, role, password_hash, created_at
+FROM users
+WHERE id = $1`
+
+	err = q.QueryRowContext(ctx, query, id).Scan(&user.ID, &user.Name, &user.Role, &user.PasswordHash, &user.CreatedAt)
+
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		err = tournament.Errorf(tournament.ENOTFOUND, "User not found.")
+	}
+
+	return
+}
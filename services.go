@@ -0,0 +1,16 @@
+package tourney_tracker
+
+// Services holds an implementation of every service of the tracker. Each backend's NewServices function returns a Services whose implementations share the same database.
+type Services struct {
+	AuditService      AuditService
+	EntrantService    EntrantService
+	FormulaService    FormulaService
+	GameService       GameService
+	PlayerService     PlayerService
+	SessionService    SessionService
+	SnapshotService   SnapshotService
+	TierService       TierService
+	TokenService      TokenService
+	TournamentService TournamentService
+	UserService       UserService
+}
@@ -0,0 +1,792 @@
+// Package servicetest implements a conformance test suite for implementations of the tracker's services.
+// Each backend runs the suite against its own services, so that they all agree on the semantics documented on the service interfaces.
+package servicetest
+
+import (
+	"context"
+	"encoding/json"
+	tournament "github.com/ejacobg/tourney-tracker"
+	"golang.org/x/exp/slices"
+	"testing"
+	"time"
+)
+
+// Run runs the suite. newServices is called once for each test, and should return services backed by a new database
+// that holds nothing but the default tiers. The services for sessions and tokens are not covered, users are only covered by
+// the rule that one admin must remain and by the audit log, and snapshots are only covered by their purge.
+func Run(t *testing.T, newServices func(t *testing.T) tournament.Services) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, s tournament.Services)
+	}{
+		{"Tiers", testTiers},
+		{"Games", testGames},
+		{"Players", testPlayers},
+		{"PlayerTrash", testPlayerTrash},
+		{"Ranks", testRanks},
+		{"Tournaments", testTournaments},
+		{"TournamentTrash", testTournamentTrash},
+		{"Entrants", testEntrants},
+		{"Formula", testFormula},
+		{"SnapshotRetention", testSnapshotRetention},
+		{"LastAdmin", testLastAdmin},
+		{"Audit", testAudit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newServices(t))
+		})
+	}
+}
+
+// wantCode fails the test if err does not have the given code.
+func wantCode(t *testing.T, op string, err error, code string) {
+	t.Helper()
+	if got := tournament.ErrorCode(err); got != code {
+		t.Errorf("%s error = %v, want %s", op, err, code)
+	}
+}
+
+// createTournament adds a double-elimination Tournament with the given entrants, failing the test if it cannot be created.
+// The entrants are placed in the order given.
+func createTournament(t *testing.T, s tournament.Services, name string, entrants ...string) (tournament.Tournament, []tournament.Entrant) {
+	t.Helper()
+	ctx := context.Background()
+
+	tourney := tournament.Tournament{Name: name, BracketType: tournament.DoubleElimination}
+	for i := len(entrants); i > 0; i-- {
+		tourney.Placements = append(tourney.Placements, int64(i))
+	}
+
+	created := make([]tournament.Entrant, len(entrants))
+	for i, name := range entrants {
+		created[i] = tournament.Entrant{Name: name, Placement: int64(i + 1)}
+	}
+
+	if err := s.TournamentService.CreateTournament(ctx, &tourney, created); err != nil {
+		t.Fatalf("CreateTournament() error = %v", err)
+	}
+	return tourney, created
+}
+
+// createPlayers adds a Player for each of the given names, failing the test if any cannot be created.
+func createPlayers(t *testing.T, s tournament.Services, names ...string) []tournament.Player {
+	t.Helper()
+	ctx := context.Background()
+
+	players := make([]tournament.Player, len(names))
+	for i, name := range names {
+		players[i].Name = name
+		if err := s.PlayerService.CreatePlayer(ctx, &players[i]); err != nil {
+			t.Fatalf("CreatePlayer(%q) error = %v", name, err)
+		}
+	}
+	return players
+}
+
+// wantTrashed fails the test if trashed does not hold the objects with the given names, most recently deleted first.
+// Objects may be deleted within the same instant, so only the order of their deletion times is checked, not the order of the names.
+func wantTrashed(t *testing.T, op string, trashed []tournament.Trashed, names ...string) {
+	t.Helper()
+
+	got := make([]string, len(trashed))
+	for i, trash := range trashed {
+		got[i] = trash.Name
+	}
+	want := slices.Clone(names)
+	slices.Sort(got)
+	slices.Sort(want)
+	if !slices.Equal(got, want) {
+		t.Errorf("%s = %v, want %v", op, got, want)
+	}
+
+	if !slices.IsSortedFunc(trashed, func(a, b tournament.Trashed) bool { return a.DeletedAt.After(b.DeletedAt) }) {
+		t.Errorf("%s = %+v, want the most recently deleted first", op, trashed)
+	}
+}
+
+func testTiers(t *testing.T, s tournament.Services) {
+	ctx := context.Background()
+
+	tiers, err := s.TierService.GetTiers(ctx)
+	if err != nil {
+		t.Fatalf("GetTiers() error = %v", err)
+	}
+	if len(tiers) == 0 {
+		t.Fatalf("GetTiers() returned no tiers, want the default tiers")
+	}
+
+	tier := tournament.Tier{Name: "Major", Multiplier: 500}
+	if err = s.TierService.CreateTier(ctx, &tier); err != nil {
+		t.Fatalf("CreateTier() error = %v", err)
+	}
+
+	tier.Multiplier = 600
+	if err = s.TierService.UpdateTier(ctx, &tier); err != nil {
+		t.Fatalf("UpdateTier() error = %v", err)
+	}
+	if got, err := s.TierService.GetTier(ctx, tier.ID); err != nil || got != tier {
+		t.Errorf("GetTier() = %+v, %v, want %+v", got, err, tier)
+	}
+
+	_, err = s.TierService.GetTier(ctx, tier.ID+100)
+	wantCode(t, "GetTier() of a missing tier", err, tournament.ENOTFOUND)
+	_, err = s.TierService.GetTournamentTier(ctx, 100)
+	wantCode(t, "GetTournamentTier() of a missing tournament", err, tournament.ENOTFOUND)
+
+	// Deleting a Tier that is used by a Tournament fails, even if the Tournament is in the trash.
+	tourney, _ := createTournament(t, s, "Major")
+	if err = s.TournamentService.SetTier(ctx, tourney.ID, tier.ID); err != nil {
+		t.Fatalf("SetTier() error = %v", err)
+	}
+	if got, err := s.TierService.GetTournamentTier(ctx, tourney.ID); err != nil || got != tier {
+		t.Errorf("GetTournamentTier() = %+v, %v, want %+v", got, err, tier)
+	}
+
+	wantCode(t, "SetTier() to a missing tier", s.TournamentService.SetTier(ctx, tourney.ID, tier.ID+100), tournament.EINVALID)
+
+	wantCode(t, "DeleteTier() of a used tier", s.TierService.DeleteTier(ctx, tier.ID), tournament.ECONFLICT)
+	if err = s.TournamentService.DeleteTournament(ctx, tourney.ID); err != nil {
+		t.Fatalf("DeleteTournament() error = %v", err)
+	}
+	wantCode(t, "DeleteTier() of a tier used by a trashed tournament", s.TierService.DeleteTier(ctx, tier.ID), tournament.ECONFLICT)
+
+	// Once no Tournament uses it, the Tier can be deleted.
+	if err = s.TournamentService.PurgeTournaments(ctx, time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("PurgeTournaments() error = %v", err)
+	}
+	if err = s.TierService.DeleteTier(ctx, tier.ID); err != nil {
+		t.Fatalf("DeleteTier() error = %v", err)
+	}
+	_, err = s.TierService.GetTier(ctx, tier.ID)
+	wantCode(t, "GetTier() of a deleted tier", err, tournament.ENOTFOUND)
+}
+
+func testGames(t *testing.T, s tournament.Services) {
+	ctx := context.Background()
+
+	for _, name := range []string{"Super Smash Bros. Ultimate", "Super Smash Bros. Melee"} {
+		if err := s.GameService.CreateGame(ctx, &tournament.Game{Name: name}); err != nil {
+			t.Fatalf("CreateGame(%q) error = %v", name, err)
+		}
+	}
+
+	// Creating a Game with an existing name reuses the existing Game.
+	melee := tournament.Game{Name: "Super Smash Bros. Melee"}
+	if err := s.GameService.CreateGame(ctx, &melee); err != nil {
+		t.Fatalf("CreateGame() of an existing game error = %v", err)
+	}
+
+	games, err := s.GameService.GetGames(ctx)
+	if err != nil {
+		t.Fatalf("GetGames() error = %v", err)
+	}
+	if len(games) != 2 || games[0] != melee || games[1].Name != "Super Smash Bros. Ultimate" {
+		t.Errorf("GetGames() = %+v, want Melee then Ultimate", games)
+	}
+
+	if got, err := s.GameService.GetGame(ctx, melee.ID); err != nil || got != melee {
+		t.Errorf("GetGame() = %+v, %v, want %+v", got, err, melee)
+	}
+	_, err = s.GameService.GetGame(ctx, melee.ID+100)
+	wantCode(t, "GetGame() of a missing game", err, tournament.ENOTFOUND)
+
+	// A Tournament whose Game has a name uses that Game, creating it if needed.
+	tourney := tournament.Tournament{Name: "Weekly", BracketType: tournament.DoubleElimination, Game: tournament.Game{Name: "Super Smash Bros. Melee"}}
+	if err = s.TournamentService.CreateTournament(ctx, &tourney, nil); err != nil {
+		t.Fatalf("CreateTournament() error = %v", err)
+	}
+	if tourney.Game.ID != melee.ID {
+		t.Errorf("CreateTournament() game ID = %d, want %d", tourney.Game.ID, melee.ID)
+	}
+
+	rivals := tournament.Tournament{Name: "Rivals Weekly", BracketType: tournament.DoubleElimination, Game: tournament.Game{Name: "Rivals of Aether"}}
+	if err = s.TournamentService.CreateTournament(ctx, &rivals, nil); err != nil {
+		t.Fatalf("CreateTournament() with a new game error = %v", err)
+	}
+	if got, err := s.GameService.GetGame(ctx, rivals.Game.ID); err != nil || got.Name != "Rivals of Aether" {
+		t.Errorf("GetGame() of a created game = %+v, %v, want Rivals of Aether", got, err)
+	}
+
+	wantCode(t, "SetGame() to a missing game", s.TournamentService.SetGame(ctx, tourney.ID, rivals.Game.ID+100), tournament.EINVALID)
+}
+
+func testPlayers(t *testing.T, s tournament.Services) {
+	ctx := context.Background()
+
+	players := createPlayers(t, s, "Mango", "Armada")
+	mango, armada := players[0], players[1]
+
+	got, err := s.PlayerService.GetPlayer(ctx, mango.ID)
+	if err != nil {
+		t.Fatalf("GetPlayer() error = %v", err)
+	}
+	if got.Name != "Mango" || got.Version != 1 {
+		t.Errorf("GetPlayer() = %+v, want Mango at version 1", got)
+	}
+	_, err = s.PlayerService.GetPlayer(ctx, armada.ID+100)
+	wantCode(t, "GetPlayer() of a missing player", err, tournament.ENOTFOUND)
+
+	wantCode(t, "CreatePlayer() with a taken name", s.PlayerService.CreatePlayer(ctx, &tournament.Player{Name: "Mango"}), tournament.ECONFLICT)
+	wantCode(t, "UpdatePlayer() to a taken name", s.PlayerService.UpdatePlayer(ctx, &tournament.Player{ID: armada.ID, Name: "Mango"}), tournament.ECONFLICT)
+
+	// Updating increments the Version, and stale versions are rejected.
+	got.Name = "Mang0"
+	if err = s.PlayerService.UpdatePlayer(ctx, &got); err != nil {
+		t.Fatalf("UpdatePlayer() error = %v", err)
+	}
+	if got.Version != 2 {
+		t.Errorf("UpdatePlayer() version = %d, want 2", got.Version)
+	}
+
+	stale := tournament.Player{ID: mango.ID, Name: "Mango", Version: 1}
+	wantCode(t, "UpdatePlayer() of a stale version", s.PlayerService.UpdatePlayer(ctx, &stale), tournament.ECONFLICT)
+	wantCode(t, "UpdatePlayer() of a missing player", s.PlayerService.UpdatePlayer(ctx, &tournament.Player{ID: armada.ID + 100, Name: "Leffen", Version: 1}), tournament.ENOTFOUND)
+	if got, _ = s.PlayerService.GetPlayer(ctx, mango.ID); got.Name != "Mang0" {
+		t.Errorf("GetPlayer() after a stale update = %+v, want the name to be unchanged", got)
+	}
+
+	// A Version of 0 skips the check.
+	if err = s.PlayerService.UpdatePlayer(ctx, &tournament.Player{ID: mango.ID, Name: "Mango"}); err != nil {
+		t.Errorf("UpdatePlayer() without a version error = %v", err)
+	}
+}
+
+func testPlayerTrash(t *testing.T, s tournament.Services) {
+	ctx := context.Background()
+
+	players := createPlayers(t, s, "Mango", "Armada", "Hungrybox")
+	mango, armada, hbox := players[0], players[1], players[2]
+
+	for _, p := range []tournament.Player{armada, mango} {
+		if err := s.PlayerService.DeletePlayer(ctx, p.ID); err != nil {
+			t.Fatalf("DeletePlayer() error = %v", err)
+		}
+	}
+
+	list, err := s.PlayerService.GetPlayers(ctx, 0)
+	if err != nil {
+		t.Fatalf("GetPlayers() error = %v", err)
+	}
+	if len(list) != 1 || list[0].ID != hbox.ID {
+		t.Errorf("GetPlayers() = %+v, want only %s", list, hbox.Name)
+	}
+
+	// Trashed players cannot be read or changed, or trashed again. Only trashed players can be restored.
+	_, err = s.PlayerService.GetPlayer(ctx, mango.ID)
+	wantCode(t, "GetPlayer() of a trashed player", err, tournament.ENOTFOUND)
+	wantCode(t, "UpdatePlayer() of a trashed player", s.PlayerService.UpdatePlayer(ctx, &tournament.Player{ID: mango.ID, Name: "Mang0"}), tournament.ENOTFOUND)
+	wantCode(t, "DeletePlayer() of a trashed player", s.PlayerService.DeletePlayer(ctx, mango.ID), tournament.ENOTFOUND)
+	wantCode(t, "DeletePlayer() of a missing player", s.PlayerService.DeletePlayer(ctx, hbox.ID+100), tournament.ENOTFOUND)
+	wantCode(t, "RestorePlayer() of a player not in the trash", s.PlayerService.RestorePlayer(ctx, hbox.ID), tournament.ENOTFOUND)
+	wantCode(t, "RestorePlayer() of a missing player", s.PlayerService.RestorePlayer(ctx, hbox.ID+100), tournament.ENOTFOUND)
+
+	trashed, err := s.PlayerService.GetDeletedPlayers(ctx)
+	if err != nil {
+		t.Fatalf("GetDeletedPlayers() error = %v", err)
+	}
+	wantTrashed(t, "GetDeletedPlayers()", trashed, "Mango", "Armada")
+
+	// Trashed players do not keep their names. A trashed player cannot be restored while its name is taken.
+	newMango := tournament.Player{Name: "Mango"}
+	if err = s.PlayerService.CreatePlayer(ctx, &newMango); err != nil {
+		t.Fatalf("CreatePlayer() with the name of a trashed player error = %v", err)
+	}
+	wantCode(t, "RestorePlayer() of a player whose name is taken", s.PlayerService.RestorePlayer(ctx, mango.ID), tournament.ECONFLICT)
+
+	newMango.Name = "Mango 2"
+	if err = s.PlayerService.UpdatePlayer(ctx, &newMango); err != nil {
+		t.Fatalf("UpdatePlayer() error = %v", err)
+	}
+	if err = s.PlayerService.RestorePlayer(ctx, mango.ID); err != nil {
+		t.Fatalf("RestorePlayer() error = %v", err)
+	}
+	if list, _ = s.PlayerService.GetPlayers(ctx, 0); len(list) != 3 {
+		t.Errorf("GetPlayers() after a restore = %+v, want 3 players", list)
+	}
+
+	// Only players trashed before the given time are purged.
+	if err = s.PlayerService.PurgePlayers(ctx, time.Now().Add(-time.Hour)); err != nil {
+		t.Fatalf("PurgePlayers() error = %v", err)
+	}
+	if trashed, _ = s.PlayerService.GetDeletedPlayers(ctx); len(trashed) != 1 {
+		t.Errorf("GetDeletedPlayers() after an early purge = %+v, want 1 player", trashed)
+	}
+
+	if err = s.PlayerService.PurgePlayers(ctx, time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("PurgePlayers() error = %v", err)
+	}
+	if trashed, _ = s.PlayerService.GetDeletedPlayers(ctx); len(trashed) != 0 {
+		t.Errorf("GetDeletedPlayers() after a purge = %+v, want none", trashed)
+	}
+	_, err = s.PlayerService.GetPlayer(ctx, armada.ID)
+	wantCode(t, "GetPlayer() of a purged player", err, tournament.ENOTFOUND)
+}
+
+func testRanks(t *testing.T, s tournament.Services) {
+	ctx := context.Background()
+
+	_, entrants := createTournament(t, s, "Weekly", "A", "B", "C")
+	players := createPlayers(t, s, "Third", "First", "Second", "Absent")
+
+	for i, entrant := range entrants {
+		if err := s.EntrantService.SetPlayers(ctx, entrant.ID, 0, []int64{players[(i+1)%3].ID}); err != nil {
+			t.Fatalf("SetPlayers() error = %v", err)
+		}
+	}
+
+	ranks, err := s.PlayerService.GetRanks(ctx, tournament.RankFilter{})
+	if err != nil {
+		t.Fatalf("GetRanks() error = %v", err)
+	}
+
+	// Players without any tournaments are still ranked, with no points.
+	var names []string
+	for _, rank := range ranks {
+		names = append(names, rank.Player.Name)
+	}
+	if want := []string{"First", "Second", "Third", "Absent"}; !slices.Equal(names, want) {
+		t.Errorf("GetRanks() = %v, want %v", names, want)
+	}
+	if len(ranks) == 4 && (ranks[0].Points <= ranks[1].Points || ranks[3].Points != 0) {
+		t.Errorf("GetRanks() = %+v, want points in descending order", ranks)
+	}
+}
+
+func testTournaments(t *testing.T, s tournament.Services) {
+	ctx := context.Background()
+
+	tourney := tournament.Tournament{
+		Name:        "Genesis",
+		BracketType: tournament.DoubleElimination,
+		Placements:  []int64{2, 1},
+		Phases: []tournament.Phase{
+			{Name: "Top 8", BracketType: tournament.DoubleElimination, Order: 2},
+			{Name: "Pools", BracketType: tournament.DoubleElimination, Order: 1},
+		},
+	}
+	entrants := []tournament.Entrant{{Name: "A", Placement: 1, Results: []tournament.Result{{Phase: 2, Placement: 1}, {Phase: 1, Group: "A1", Placement: 1}}}}
+	if err := s.TournamentService.CreateTournament(ctx, &tourney, entrants); err != nil {
+		t.Fatalf("CreateTournament() error = %v", err)
+	}
+	if tourney.Tier.ID == 0 || tourney.Tier.Name == "" {
+		t.Errorf("CreateTournament() tier = %+v, want the default tier", tourney.Tier)
+	}
+
+	// Phases and results are returned in order, however they were given.
+	got, err := s.TournamentService.GetTournament(ctx, tourney.ID)
+	if err != nil {
+		t.Fatalf("GetTournament() error = %v", err)
+	}
+	if len(got.Phases) != 2 || got.Phases[0].Name != "Pools" || got.Phases[1].Name != "Top 8" {
+		t.Errorf("GetTournament() phases = %+v, want Pools then Top 8", got.Phases)
+	}
+
+	stored, err := s.EntrantService.GetEntrants(ctx, tourney.ID)
+	if err != nil {
+		t.Fatalf("GetEntrants() error = %v", err)
+	}
+	if len(stored) != 1 || len(stored[0].Results) != 2 || stored[0].Results[0].Group != "A1" {
+		t.Errorf("GetEntrants() = %+v, want one entrant with its pools result first", stored)
+	}
+
+	_, err = s.TournamentService.GetTournament(ctx, tourney.ID+100)
+	wantCode(t, "GetTournament() of a missing tournament", err, tournament.ENOTFOUND)
+
+	// A Tournament may not have two phases in the same position.
+	duplicate := tournament.Tournament{Name: "Duplicate", BracketType: tournament.DoubleElimination, Phases: []tournament.Phase{{Name: "Pools", Order: 1}, {Name: "Top 8", Order: 1}}}
+	wantCode(t, "CreateTournament() with duplicate phases", s.TournamentService.CreateTournament(ctx, &duplicate, nil), tournament.ECONFLICT)
+	if previews, _ := s.TournamentService.GetPreviews(ctx, 0); len(previews) != 1 {
+		t.Errorf("GetPreviews() after a failed create = %+v, want only %s", previews, tourney.Name)
+	}
+
+	if err = s.TournamentService.SetTier(ctx, tourney.ID, 3); err != nil {
+		t.Fatalf("SetTier() error = %v", err)
+	}
+	names, err := s.TournamentService.GetNamesByTier(ctx, 3)
+	if err != nil {
+		t.Fatalf("GetNamesByTier() error = %v", err)
+	}
+	if len(names) != 1 || names[0].ID != tourney.ID {
+		t.Errorf("GetNamesByTier() = %+v, want only %s", names, tourney.Name)
+	}
+
+	// A Tournament may be created with a Tier other than the default.
+	tiered := tournament.Tournament{Name: "Tiered", BracketType: tournament.DoubleElimination, Tier: tournament.Tier{ID: 2}}
+	if err = s.TournamentService.CreateTournament(ctx, &tiered, nil); err != nil {
+		t.Fatalf("CreateTournament() with a tier error = %v", err)
+	}
+	if tiered.Tier.ID != 2 || tiered.Tier.Name != "B" {
+		t.Errorf("CreateTournament() tier = %+v, want B", tiered.Tier)
+	}
+
+	missing := tournament.Tournament{Name: "Missing", BracketType: tournament.DoubleElimination, Tier: tournament.Tier{ID: 100}}
+	wantCode(t, "CreateTournament() with a missing tier", s.TournamentService.CreateTournament(ctx, &missing, nil), tournament.EINVALID)
+}
+
+func testTournamentTrash(t *testing.T, s tournament.Services) {
+	ctx := context.Background()
+
+	first, entrants := createTournament(t, s, "First", "A", "B")
+	second, _ := createTournament(t, s, "Second", "A")
+	third, _ := createTournament(t, s, "Third", "A")
+
+	players := createPlayers(t, s, "Mango")
+	if err := s.EntrantService.SetPlayers(ctx, entrants[0].ID, 0, []int64{players[0].ID}); err != nil {
+		t.Fatalf("SetPlayers() error = %v", err)
+	}
+
+	for _, tourney := range []tournament.Tournament{first, second} {
+		if err := s.TournamentService.DeleteTournament(ctx, tourney.ID); err != nil {
+			t.Fatalf("DeleteTournament() error = %v", err)
+		}
+	}
+
+	// Trashed tournaments are left out of previews, names and attendance.
+	previews, err := s.TournamentService.GetPreviews(ctx, 0)
+	if err != nil {
+		t.Fatalf("GetPreviews() error = %v", err)
+	}
+	if len(previews) != 1 || previews[0].ID != third.ID {
+		t.Errorf("GetPreviews() = %+v, want only %s", previews, third.Name)
+	}
+	if names, _ := s.TournamentService.GetNamesByTier(ctx, first.Tier.ID); len(names) != 1 {
+		t.Errorf("GetNamesByTier() = %+v, want only %s", names, third.Name)
+	}
+	if attendance, _ := s.EntrantService.GetAttendance(ctx, players[0].ID); len(attendance) != 0 {
+		t.Errorf("GetAttendance() = %+v, want no attendance", attendance)
+	}
+
+	trashed, err := s.TournamentService.GetDeletedTournaments(ctx)
+	if err != nil {
+		t.Fatalf("GetDeletedTournaments() error = %v", err)
+	}
+	wantTrashed(t, "GetDeletedTournaments()", trashed, "Second", "First")
+
+	// Trashed tournaments cannot be viewed or changed until they are restored.
+	_, err = s.TournamentService.GetTournament(ctx, second.ID)
+	wantCode(t, "GetTournament() of a trashed tournament", err, tournament.ENOTFOUND)
+	wantCode(t, "SetTier() of a trashed tournament", s.TournamentService.SetTier(ctx, second.ID, 2), tournament.ENOTFOUND)
+	wantCode(t, "SetTeamScoring() of a trashed tournament", s.TournamentService.SetTeamScoring(ctx, second.ID, tournament.CreditTeammates), tournament.ENOTFOUND)
+	wantCode(t, "DeleteTournament() of a trashed tournament", s.TournamentService.DeleteTournament(ctx, second.ID), tournament.ENOTFOUND)
+	wantCode(t, "RestoreTournament() of a live tournament", s.TournamentService.RestoreTournament(ctx, third.ID), tournament.ENOTFOUND)
+
+	const missing = 1000
+	wantCode(t, "SetTier() of a missing tournament", s.TournamentService.SetTier(ctx, missing, 2), tournament.ENOTFOUND)
+	wantCode(t, "SetGame() of a missing tournament", s.TournamentService.SetGame(ctx, missing, 1), tournament.ENOTFOUND)
+	wantCode(t, "SetTeamScoring() of a missing tournament", s.TournamentService.SetTeamScoring(ctx, missing, tournament.CreditTeammates), tournament.ENOTFOUND)
+	wantCode(t, "DeleteTournament() of a missing tournament", s.TournamentService.DeleteTournament(ctx, missing), tournament.ENOTFOUND)
+	wantCode(t, "RestoreTournament() of a missing tournament", s.TournamentService.RestoreTournament(ctx, missing), tournament.ENOTFOUND)
+
+	// Restoring a Tournament brings back its entrants and their players.
+	if err = s.TournamentService.RestoreTournament(ctx, first.ID); err != nil {
+		t.Fatalf("RestoreTournament() error = %v", err)
+	}
+	if attendance, _ := s.EntrantService.GetAttendance(ctx, players[0].ID); len(attendance) != 1 || attendance[0].Tournament.ID != first.ID {
+		t.Errorf("GetAttendance() after a restore = %+v, want %s", attendance, first.Name)
+	}
+
+	// Purging a Tournament deletes its entrants, and frees its players to be linked again.
+	if err = s.TournamentService.DeleteTournament(ctx, first.ID); err != nil {
+		t.Fatalf("DeleteTournament() error = %v", err)
+	}
+	if err = s.TournamentService.PurgeTournaments(ctx, time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("PurgeTournaments() error = %v", err)
+	}
+
+	if trashed, _ = s.TournamentService.GetDeletedTournaments(ctx); len(trashed) != 0 {
+		t.Errorf("GetDeletedTournaments() after a purge = %+v, want none", trashed)
+	}
+	_, err = s.TournamentService.GetTournament(ctx, first.ID)
+	wantCode(t, "GetTournament() of a purged tournament", err, tournament.ENOTFOUND)
+	_, _, err = s.EntrantService.GetEntrantWithPoints(ctx, entrants[0].ID)
+	wantCode(t, "GetEntrantWithPoints() of a purged entrant", err, tournament.ENOTFOUND)
+	if stored, _ := s.EntrantService.GetEntrants(ctx, first.ID); len(stored) != 0 {
+		t.Errorf("GetEntrants() of a purged tournament = %+v, want none", stored)
+	}
+}
+
+func testEntrants(t *testing.T, s tournament.Services) {
+	ctx := context.Background()
+
+	tourney, entrants := createTournament(t, s, "Weekly", "A", "B")
+	players := createPlayers(t, s, "Mango", "Armada", "Hungrybox")
+	mango, armada, hbox := players[0], players[1], players[2]
+
+	_, _, err := s.EntrantService.GetEntrantWithPoints(ctx, entrants[1].ID+100)
+	wantCode(t, "GetEntrantWithPoints() of a missing entrant", err, tournament.ENOTFOUND)
+
+	if err = s.EntrantService.SetPlayers(ctx, entrants[0].ID, 1, []int64{mango.ID, armada.ID}); err != nil {
+		t.Fatalf("SetPlayers() error = %v", err)
+	}
+
+	// Players are returned by name, and the Version is incremented.
+	got, points, err := s.EntrantService.GetEntrantWithPoints(ctx, entrants[0].ID)
+	if err != nil {
+		t.Fatalf("GetEntrantWithPoints() error = %v", err)
+	}
+	if len(got.Players) != 2 || got.Players[0].ID != armada.ID || got.Players[1].ID != mango.ID {
+		t.Errorf("GetEntrantWithPoints() players = %+v, want Armada then Mango", got.Players)
+	}
+	if got.Version != 2 {
+		t.Errorf("GetEntrantWithPoints() version = %d, want 2", got.Version)
+	}
+	if want, _ := tournament.DefaultFormula.Points(tourney, got.Placement); points != want {
+		t.Errorf("GetEntrantWithPoints() points = %d, want %d", points, want)
+	}
+
+	wantCode(t, "SetPlayers() of a stale version", s.EntrantService.SetPlayers(ctx, entrants[0].ID, 1, nil), tournament.ECONFLICT)
+	wantCode(t, "SetPlayers() of a missing entrant", s.EntrantService.SetPlayers(ctx, entrants[1].ID+100, 1, nil), tournament.ENOTFOUND)
+	wantCode(t, "SetPlayers() of a player linked to another entrant", s.EntrantService.SetPlayers(ctx, entrants[1].ID, 0, []int64{mango.ID}), tournament.ECONFLICT)
+	wantCode(t, "SetPlayers() of a missing player", s.EntrantService.SetPlayers(ctx, entrants[1].ID, 0, []int64{hbox.ID + 100}), tournament.EINVALID)
+
+	// Failed changes leave the players as they were.
+	if got, _, _ = s.EntrantService.GetEntrantWithPoints(ctx, entrants[1].ID); len(got.Players) != 0 || got.Version != 1 {
+		t.Errorf("GetEntrantWithPoints() after failed changes = %+v, want no players at version 1", got)
+	}
+
+	// Trashed players are hidden from their Entrant until they are restored. Purging them unlinks them.
+	if err = s.PlayerService.DeletePlayer(ctx, mango.ID); err != nil {
+		t.Fatalf("DeletePlayer() error = %v", err)
+	}
+	if got, _, _ = s.EntrantService.GetEntrantWithPoints(ctx, entrants[0].ID); len(got.Players) != 1 || got.Players[0].ID != armada.ID {
+		t.Errorf("GetEntrantWithPoints() players = %+v, want only Armada", got.Players)
+	}
+	if err = s.PlayerService.PurgePlayers(ctx, time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("PurgePlayers() error = %v", err)
+	}
+	if attendance, _ := s.EntrantService.GetAttendance(ctx, armada.ID); len(attendance) != 1 || attendance[0].Entrant.Name != "A" {
+		t.Errorf("GetAttendance() = %+v, want entrant A", attendance)
+	}
+
+	// An empty slice removes every Player.
+	if err = s.EntrantService.SetPlayers(ctx, entrants[0].ID, 0, []int64{}); err != nil {
+		t.Fatalf("SetPlayers() to no players error = %v", err)
+	}
+	if got, _, _ = s.EntrantService.GetEntrantWithPoints(ctx, entrants[0].ID); len(got.Players) != 0 {
+		t.Errorf("GetEntrantWithPoints() players = %+v, want none", got.Players)
+	}
+
+	if err = s.EntrantService.DeleteEntrants(ctx, tourney.ID); err != nil {
+		t.Fatalf("DeleteEntrants() error = %v", err)
+	}
+	if stored, _ := s.EntrantService.GetEntrants(ctx, tourney.ID); len(stored) != 0 {
+		t.Errorf("GetEntrants() after DeleteEntrants() = %+v, want none", stored)
+	}
+}
+
+func testFormula(t *testing.T, s tournament.Services) {
+	ctx := context.Background()
+
+	formula, err := s.FormulaService.GetFormula(ctx)
+	if err != nil {
+		t.Fatalf("GetFormula() error = %v", err)
+	}
+	if formula != tournament.DefaultFormula {
+		t.Errorf("GetFormula() = %+v, want the default formula %+v", formula, tournament.DefaultFormula)
+	}
+
+	tourney, entrants := createTournament(t, s, "Weekly", "A", "B")
+	players := createPlayers(t, s, "Mango")
+	if err = s.EntrantService.SetPlayers(ctx, entrants[0].ID, 0, []int64{players[0].ID}); err != nil {
+		t.Fatalf("SetPlayers() error = %v", err)
+	}
+
+	// The Formula can be changed more than once, and points are calculated using the latest one.
+	for _, formula := range []tournament.Formula{{UP: 1, ATT: 2, FIRST: 3, BR: 4}, {UP: 100, ATT: 0, FIRST: 50, BR: 0}} {
+		if err = s.FormulaService.UpdateFormula(ctx, formula); err != nil {
+			t.Fatalf("UpdateFormula() error = %v", err)
+		}
+		if got, err := s.FormulaService.GetFormula(ctx); err != nil || got != formula {
+			t.Errorf("GetFormula() = %+v, %v, want %+v", got, err, formula)
+		}
+
+		want, _ := formula.Points(tourney, entrants[0].Placement)
+		if _, points, err := s.EntrantService.GetEntrantWithPoints(ctx, entrants[0].ID); err != nil || points != want {
+			t.Errorf("GetEntrantWithPoints() points = %d, %v, want %d", points, err, want)
+		}
+		if ranks, err := s.PlayerService.GetRanks(ctx, tournament.RankFilter{}); err != nil || len(ranks) != 1 || ranks[0].Points != want {
+			t.Errorf("GetRanks() = %+v, %v, want Mango with %d points", ranks, err, want)
+		}
+	}
+
+	wantCode(t, "UpdateFormula() of a negative variable", s.FormulaService.UpdateFormula(ctx, tournament.Formula{UP: -1}), tournament.EINVALID)
+}
+
+func testSnapshotRetention(t *testing.T, s tournament.Services) {
+	ctx := context.Background()
+
+	snapshots := []tournament.Snapshot{
+		{Name: "Manual"},
+		{Name: "Auto 1", Automatic: true},
+		{Name: "Auto 2", Automatic: true},
+		{Name: "Auto 3", Automatic: true},
+		{Name: "Manual 2"},
+	}
+	for i := range snapshots {
+		if err := s.SnapshotService.CreateSnapshot(ctx, &snapshots[i]); err != nil {
+			t.Fatalf("CreateSnapshot(%q) error = %v", snapshots[i].Name, err)
+		}
+	}
+
+	// Only the oldest automatic snapshots are purged. Snapshots taken by users are always kept.
+	wantSnapshots := func(op string, names ...string) {
+		t.Helper()
+
+		list, err := s.SnapshotService.GetSnapshots(ctx)
+		if err != nil {
+			t.Fatalf("GetSnapshots() error = %v", err)
+		}
+
+		got := make([]string, len(list))
+		for i, snapshot := range list {
+			got[i] = snapshot.Name
+		}
+		slices.Sort(got)
+		if !slices.Equal(got, names) {
+			t.Errorf("GetSnapshots() after %s = %v, want %v", op, got, names)
+		}
+	}
+
+	if err := s.SnapshotService.PurgeSnapshots(ctx, 2); err != nil {
+		t.Fatalf("PurgeSnapshots() error = %v", err)
+	}
+	wantSnapshots("keeping 2", "Auto 2", "Auto 3", "Manual", "Manual 2")
+
+	if err := s.SnapshotService.PurgeSnapshots(ctx, 5); err != nil {
+		t.Fatalf("PurgeSnapshots() error = %v", err)
+	}
+	wantSnapshots("keeping more than there are", "Auto 2", "Auto 3", "Manual", "Manual 2")
+
+	if err := s.SnapshotService.PurgeSnapshots(ctx, 1); err != nil {
+		t.Fatalf("PurgeSnapshots() error = %v", err)
+	}
+	wantSnapshots("keeping 1", "Auto 3", "Manual", "Manual 2")
+}
+
+func testLastAdmin(t *testing.T, s tournament.Services) {
+	ctx := context.Background()
+
+	users := []tournament.User{
+		{Name: "first", Role: tournament.RoleAdmin, PasswordHash: []byte("hash")},
+		{Name: "second", Role: tournament.RoleAdmin, PasswordHash: []byte("hash")},
+		{Name: "editor", Role: tournament.RoleEditor, PasswordHash: []byte("hash")},
+	}
+	for i := range users {
+		if err := s.UserService.CreateUser(ctx, &users[i]); err != nil {
+			t.Fatalf("CreateUser(%q) error = %v", users[i].Name, err)
+		}
+	}
+	first, second, editor := users[0], users[1], users[2]
+
+	// Either admin can be demoted while the other remains, but not both.
+	second.Role = tournament.RoleOrganizer
+	if err := s.UserService.UpdateUser(ctx, &second); err != nil {
+		t.Fatalf("UpdateUser() demoting one of two admins error = %v", err)
+	}
+
+	first.Role = tournament.RoleEditor
+	wantCode(t, "UpdateUser() demoting the last admin", s.UserService.UpdateUser(ctx, &first), tournament.ECONFLICT)
+	wantCode(t, "DeleteUser() of the last admin", s.UserService.DeleteUser(ctx, first.ID), tournament.ECONFLICT)
+
+	if got, err := s.UserService.GetUser(ctx, first.ID); err != nil || got.Role != tournament.RoleAdmin {
+		t.Errorf("GetUser() of the last admin = %+v, %v, want them to still be an admin", got, err)
+	}
+
+	// The last admin can still be changed in other ways.
+	first.Name, first.Role = "renamed", tournament.RoleAdmin
+	if err := s.UserService.UpdateUser(ctx, &first); err != nil {
+		t.Errorf("UpdateUser() renaming the last admin error = %v", err)
+	}
+
+	if err := s.UserService.DeleteUser(ctx, editor.ID); err != nil {
+		t.Errorf("DeleteUser() of an editor error = %v", err)
+	}
+
+	wantCode(t, "UpdateUser() of a missing user", s.UserService.UpdateUser(ctx, &editor), tournament.ENOTFOUND)
+	wantCode(t, "DeleteUser() of a missing user", s.UserService.DeleteUser(ctx, editor.ID), tournament.ENOTFOUND)
+}
+
+func testAudit(t *testing.T, s tournament.Services) {
+	admin := tournament.User{Name: "admin", Role: tournament.RoleAdmin, PasswordHash: []byte("hash")}
+	if err := s.UserService.CreateUser(context.Background(), &admin); err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+
+	// Changes are recorded as made by the actor of their context.
+	ctx := tournament.WithActor(context.Background(), &admin.ID, admin.Name)
+
+	player := tournament.Player{Name: "Mango"}
+	if err := s.PlayerService.CreatePlayer(ctx, &player); err != nil {
+		t.Fatalf("CreatePlayer() error = %v", err)
+	}
+	before := player
+	player.Name = "Armada"
+	if err := s.PlayerService.UpdatePlayer(ctx, &player); err != nil {
+		t.Fatalf("UpdatePlayer() error = %v", err)
+	}
+
+	// Changes that fail are not recorded.
+	stale := before
+	wantCode(t, "UpdatePlayer() with an old version", s.PlayerService.UpdatePlayer(ctx, &stale), tournament.ECONFLICT)
+
+	if err := s.PlayerService.DeletePlayer(ctx, player.ID); err != nil {
+		t.Fatalf("DeletePlayer() error = %v", err)
+	}
+
+	// Changes made by the server are recorded without a User.
+	server := tournament.WithActor(context.Background(), nil, "server")
+	if err := s.PlayerService.PurgePlayers(server, time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("PurgePlayers() error = %v", err)
+	}
+
+	// A change is not made if it cannot be recorded.
+	missingID := admin.ID + 100
+	missing := tournament.WithActor(context.Background(), &missingID, "missing")
+	if err := s.PlayerService.CreatePlayer(missing, &tournament.Player{Name: "Hbox"}); err == nil {
+		t.Error("CreatePlayer() by a missing user error = nil, want an error")
+	}
+	if players, err := s.PlayerService.GetPlayers(ctx, 0); err != nil || len(players) != 0 {
+		t.Errorf("GetPlayers() = %+v, %v, want no players", players, err)
+	}
+
+	entries, err := s.AuditService.GetEntries(ctx, tournament.EntryFilter{})
+	if err != nil {
+		t.Fatalf("GetEntries() error = %v", err)
+	}
+
+	want := []struct {
+		action tournament.Action
+		actor  string
+	}{
+		{tournament.ActionPurgePlayer, "server"},
+		{tournament.ActionDeletePlayer, admin.Name},
+		{tournament.ActionUpdatePlayer, admin.Name},
+		{tournament.ActionCreatePlayer, admin.Name},
+		{tournament.ActionCreateUser, ""},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("GetEntries() = %+v, want %d entries", entries, len(want))
+	}
+	for i, entry := range entries {
+		if entry.Action != want[i].action || entry.Actor != want[i].actor {
+			t.Errorf("GetEntries()[%d] = %q by %q, want %q by %q", i, entry.Action, entry.Actor, want[i].action, want[i].actor)
+		}
+		if wantUser := want[i].actor == admin.Name; (entry.UserID != nil && *entry.UserID == admin.ID) != wantUser {
+			t.Errorf("GetEntries()[%d] user ID = %v, want it to be %d: %t", i, entry.UserID, admin.ID, wantUser)
+		}
+	}
+
+	// The values are compared after decoding, since databases may store JSON in their own format.
+	var got [2]tournament.Player
+	update := entries[2]
+	if err = json.Unmarshal(update.Before, &got[0]); err != nil {
+		t.Fatalf("GetEntries() update before = %s: %v", update.Before, err)
+	}
+	if err = json.Unmarshal(update.After, &got[1]); err != nil {
+		t.Fatalf("GetEntries() update after = %s: %v", update.After, err)
+	}
+	if update.SubjectID != player.ID || got != [2]tournament.Player{before, player} {
+		t.Errorf("GetEntries() update = %d from %+v to %+v, want %d from %+v to %+v", update.SubjectID, got[0], got[1], player.ID, before, player)
+	}
+}
@@ -0,0 +1,64 @@
+package tourney_tracker
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"time"
+)
+
+// Session represents a logged-in User. The Token is given to the User's browser as a cookie, and must be sent with every request.
+type Session struct {
+	// Token is the plaintext session token. It is only known when the Session is created, as only its hash is stored.
+	Token  string
+	UserID int64
+	Expiry time.Time
+}
+
+// NewSession creates a Session for the given User with a random Token, which will expire after the given duration.
+func NewSession(userID int64, ttl time.Duration) (Session, error) {
+	token, err := NewToken()
+	if err != nil {
+		return Session{}, err
+	}
+
+	return Session{
+		Token:  token,
+		UserID: userID,
+		Expiry: time.Now().Add(ttl),
+	}, nil
+}
+
+// NewToken returns a random, URL-safe token with 256 bits of entropy.
+func NewToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken returns the SHA-256 hash of the given token. Tokens are stored hashed, so that a leaked database cannot be used to log in.
+// Unlike passwords, tokens are random enough that a fast hash is sufficient.
+func HashToken(token string) []byte {
+	hash := sha256.Sum256([]byte(token))
+	return hash[:]
+}
+
+// SessionService represents a service for managing sessions.
+type SessionService interface {
+	// CreateSession stores the given Session.
+	CreateSession(ctx context.Context, session Session) error
+
+	// GetSessionUser returns the User that owns the Session with the given Token.
+	// Expired sessions are treated as if they do not exist.
+	GetSessionUser(ctx context.Context, token string) (User, error)
+
+	// DeleteSession deletes the Session with the given Token. This is used to log out.
+	DeleteSession(ctx context.Context, token string) error
+
+	// DeleteExpiredSessions deletes every Session that has expired.
+	DeleteExpiredSessions(ctx context.Context) error
+}
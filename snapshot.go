@@ -0,0 +1,51 @@
+package tourney_tracker
+
+import (
+	"context"
+	"time"
+)
+
+// Snapshot is a saved copy of the tracker's data: its tiers, games, tournaments (including their phases and entrants), players, the links between entrants and players, and the point formula.
+// Users, tokens, and the audit log are not included, so restoring a Snapshot does not undo who can log in, or hide who made changes.
+type Snapshot struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+
+	// Automatic is true if the Snapshot was taken by the tracker before a risky change, rather than by a User.
+	Automatic bool `json:"automatic"`
+
+	// CreatedBy is the name of the User who took the Snapshot, or an empty string if it was taken automatically outside a request.
+	CreatedBy string `json:"createdBy"`
+
+	CreatedAt time.Time `json:"createdAt"`
+
+	// Tournaments and Players are the number of each saved in the Snapshot, including those in the trash.
+	Tournaments int `json:"tournaments"`
+	Players     int `json:"players"`
+}
+
+// DefaultSnapshotRetention is how many automatic snapshots are kept, unless configured otherwise.
+const DefaultSnapshotRetention = 50
+
+// SnapshotService represents a service for saving and restoring copies of the tracker's data.
+type SnapshotService interface {
+	// GetSnapshots returns all snapshots, newest first.
+	GetSnapshots(ctx context.Context) ([]Snapshot, error)
+
+	// GetSnapshot returns a single Snapshot by ID.
+	GetSnapshot(ctx context.Context, id int64) (Snapshot, error)
+
+	// CreateSnapshot saves a copy of the current data under the given Snapshot.
+	CreateSnapshot(ctx context.Context, snapshot *Snapshot) error
+
+	// RestoreSnapshot replaces the current data with the data saved in the given Snapshot.
+	// The data should be replaced in a single transaction, so that a failed restore leaves the current data untouched.
+	// Snapshots taken before a column or table was added are restored using its default values.
+	RestoreSnapshot(ctx context.Context, id int64) error
+
+	// DeleteSnapshot deletes the given Snapshot. The current data is not affected.
+	DeleteSnapshot(ctx context.Context, id int64) error
+
+	// PurgeSnapshots permanently deletes every automatic Snapshot except for the newest keep. Snapshots taken by users are never purged.
+	PurgeSnapshots(ctx context.Context, keep int) error
+}
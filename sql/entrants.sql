@@ -1,24 +1,36 @@
 -- This is the query used by Model.Insert().
-INSERT INTO entrants (name, placement, tournament_id)
-VALUES ('test', 1, 1)
+INSERT INTO entrants (name, placement, tournament_id, participants)
+VALUES ('test', 1, 1, '{}')
 RETURNING id;
 
 -- This is the query used by postgres.EntrantService.GetEntrants().
-SELECT entrants.id, entrants.name, placement, tournament_id, players.name
+SELECT id, name, placement, tournament_id, participants
 FROM entrants
-         LEFT OUTER JOIN players ON entrants.player_id = players.id
 WHERE tournament_id = 1;
 
+-- This is the query used to get the players of each entrant in a tournament.
+SELECT entrant_players.entrant_id, players.id, players.name
+FROM entrant_players
+         INNER JOIN players ON entrant_players.player_id = players.id
+WHERE entrant_players.tournament_id = 1
+ORDER BY players.name;
+
 -- This is the query used by postgres.EntrantService.GetAttendance().
 SELECT tournaments.id, tournaments.name, tiers.name, entrants.name, entrants.placement
-FROM entrants
+FROM entrant_players
+         INNER JOIN entrants on entrant_players.entrant_id = entrants.id
          LEFT OUTER JOIN tournaments on entrants.tournament_id = tournaments.id
          LEFT OUTER JOIN tiers on tournaments.tier_id = tiers.id
-WHERE entrants.player_id = 1;
+WHERE entrant_players.player_id = 1;
 
--- This is the query used by postgres.EntrantService.SetPlayer().
-UPDATE entrants
-SET player_id = 1
+-- These are the queries used by postgres.EntrantService.SetPlayers().
+DELETE
+FROM entrant_players
+WHERE entrant_id = 1;
+
+INSERT INTO entrant_players (entrant_id, player_id, tournament_id)
+SELECT id, 1, tournament_id
+FROM entrants
 WHERE id = 1;
 
 -- This is the query used by postgres.EntrantService.DeleteEntrants().
@@ -1 +0,0 @@
-CREATE EXTENSION IF NOT EXISTS intarray;
\ No newline at end of file
@@ -4,15 +4,28 @@ SELECT entrants.placement, idx(tournaments.placements, entrants.placement)
 FROM entrants LEFT OUTER JOIN tournaments on tournaments.id = entrants.tournament_id
 WHERE entrants.tournament_id = 14;
 
--- This query is used by postgres.PlayerService.GetRanks().
+-- This query is used by postgres.PlayerService.GetRanks(). $1 is true for the doubles leaderboard, and $2 is a game ID to limit the rankings to that game, or 0 for every game.
 SELECT players.id,
        players.name,
-       entrants.placement,
-       idx(tournaments.placements, entrants.placement),
-       tournaments.bracket_reset,
-       tiers.multiplier
+       players.version,
+       scores.placement,
+       scores.bracket_type,
+       scores.bracket_reset,
+       scores.placements,
+       scores.multiplier
 FROM players
-         LEFT OUTER JOIN entrants on entrants.player_id = players.id
-         LEFT OUTER JOIN tournaments on tournaments.id = entrants.tournament_id
-         LEFT OUTER JOIN tiers on tiers.id = tournaments.tier_id;
-
+         LEFT OUTER JOIN (SELECT entrant_players.player_id,
+                                 entrants.placement,
+                                 tournaments.bracket_type,
+                                 tournaments.bracket_reset,
+                                 tournaments.placements,
+                                 tiers.multiplier
+                          FROM entrant_players
+                                   INNER JOIN entrants on entrants.id = entrant_players.entrant_id
+                                   INNER JOIN tournaments on tournaments.id = entrants.tournament_id
+                                   INNER JOIN tiers on tiers.id = tournaments.tier_id
+                          WHERE tournaments.deleted_at IS NULL
+                            AND (tournaments.teams AND tournaments.team_scoring = 'separate') = $1
+                            AND ($2::bigint = 0 OR tournaments.game_id = $2)) AS scores
+                         ON scores.player_id = players.id
+WHERE players.deleted_at IS NULL;
@@ -13,8 +13,8 @@ VALUES ('(SSC C TIER) Gator Grind #9', 'https://challonge.com/kpqlgghc', false,
 
 -- This is the query used by postgres.TournamentService.CreateTournament().
 WITH tourney AS (
-    INSERT INTO tournaments (name, url, bracket_reset, placements, tier_id)
-        VALUES ('', '', false, '{}', 1)
+    INSERT INTO tournaments (name, url, bracket_type, bracket_reset, placements, tier_id)
+        VALUES ('', '', 'double elimination', false, '{}', 1)
         RETURNING id, tier_id)
 SELECT tourney.id, tourney.tier_id, tiers.name, tiers.multiplier
 FROM tourney
@@ -24,6 +24,7 @@ FROM tourney
 SELECT tournaments.id,
        tournaments.name,
        url,
+       bracket_type,
        bracket_reset,
        placements,
        tier_id,
@@ -43,7 +44,18 @@ UPDATE tournaments
 SET tier_id = 1
 WHERE id = 1;
 
+-- This is the query used by postgres.TournamentService.SetGame().
+UPDATE tournaments
+SET game_id = 1
+WHERE id = 1;
+
 -- This is the query used by postgres.TournamentService.DeleteTournament().
+UPDATE tournaments
+SET deleted_at = now()
+WHERE id = 1
+  AND deleted_at IS NULL;
+
+-- This is the query used by postgres.TournamentService.PurgeTournaments(). Tournaments are purged 30 days after being deleted by default.
 DELETE
 FROM tournaments
-WHERE id = 1;
\ No newline at end of file
+WHERE deleted_at < now() - interval '30 days';
\ No newline at end of file
@@ -0,0 +1,374 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	tournament "github.com/ejacobg/tourney-tracker"
+)
+
+// EntrantService represents a service for managing entrants.
+type EntrantService struct {
+	DB *sql.DB
+}
+
+func (es EntrantService) GetEntrants(ctx context.Context, tournamentID int64) (entrants []tournament.Entrant, err error) {
+	query := `
+SELECT id, name, placement, tournament_id, participants, version
+FROM entrants
+WHERE tournament_id = ?1`
+
+	rows, err := es.DB.QueryContext(ctx, query, tournamentID)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entrant tournament.Entrant
+
+		err = rows.Scan(
+			&entrant.ID,
+			&entrant.Name,
+			&entrant.Placement,
+			&entrant.TournamentID,
+			jsonArray{&entrant.Participants},
+			&entrant.Version,
+		)
+
+		if err != nil {
+			return
+		}
+
+		entrants = append(entrants, entrant)
+	}
+
+	if err = rows.Err(); err != nil {
+		return
+	}
+
+	players, err := getTournamentPlayers(ctx, es.DB, tournamentID)
+	if err != nil {
+		return
+	}
+
+	results, err := getResults(ctx, es.DB, tournamentID)
+	if err != nil {
+		return
+	}
+
+	for i := range entrants {
+		entrants[i].Players = players[entrants[i].ID]
+		entrants[i].Results = results[entrants[i].ID]
+	}
+
+	return entrants, nil
+}
+
+func (es EntrantService) GetEntrantWithPoints(ctx context.Context, id int64) (entrant tournament.Entrant, points int, err error) {
+	// Get Entrant, Tournament, and Formula.
+	tx, err := es.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	entrant, err = getEntrant(ctx, tx, id)
+	if err != nil {
+		return
+	}
+
+	tourney, err := getTournament(ctx, tx, entrant.TournamentID)
+	if err != nil {
+		return
+	}
+
+	formula, err := getFormula(ctx, tx)
+	if err != nil {
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return
+	}
+
+	// Calculate points.
+	points, ok := formula.Points(tourney, entrant.Placement)
+	if !ok {
+		err = tournament.Errorf(tournament.EINTERNAL, "Entrant %d has placement %d, which is not one of the placements of their tournament.", entrant.ID, entrant.Placement)
+	}
+
+	return
+}
+
+func (es EntrantService) GetAttendance(ctx context.Context, playerID int64) (attendance []tournament.Attendee, err error) {
+	query := `
+SELECT tournaments.id, tournaments.name, tiers.name, entrants.name, entrants.placement
+FROM entrant_players
+         INNER JOIN entrants on entrant_players.entrant_id = entrants.id
+         LEFT OUTER JOIN tournaments on entrants.tournament_id = tournaments.id
+         LEFT OUTER JOIN tiers on tournaments.tier_id = tiers.id
+WHERE entrant_players.player_id = ?1
+  AND tournaments.deleted_at IS NULL`
+
+	rows, err := es.DB.QueryContext(ctx, query, playerID)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var attendee tournament.Attendee
+
+		err = rows.Scan(
+			&attendee.Tournament.ID,
+			&attendee.Tournament.Name,
+			&attendee.Tournament.Tier,
+			&attendee.Entrant.Name,
+			&attendee.Entrant.Placement,
+		)
+
+		if err != nil {
+			return
+		}
+
+		attendance = append(attendance, attendee)
+	}
+
+	return attendance, rows.Err()
+}
+
+func (es EntrantService) CreateEntrants(ctx context.Context, entrants []tournament.Entrant, tournamentID int64) error {
+	tx, err := es.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = createEntrants(ctx, tx, entrants, tournamentID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (es EntrantService) SetPlayers(ctx context.Context, entrantID int64, version int, playerIDs []int64) error {
+	tx, err := es.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// SQLite makes one change at a time, so a change made after this one will find that the version has changed, and will be rejected.
+	query := `
+UPDATE entrants
+SET version = version + 1
+WHERE id = ?1
+  AND (?2 = 0 OR version = ?2)`
+
+	result, err := tx.ExecContext(ctx, query, entrantID, version)
+	if err != nil {
+		return err
+	}
+	if rows, err := result.RowsAffected(); err != nil {
+		return err
+	} else if rows == 0 {
+		return versionError(ctx, tx, "entrants", "Entrant", entrantID)
+	}
+
+	// The players of the Entrant are recorded, rather than the whole Entrant.
+	before, err := getEntrant(ctx, tx, entrantID)
+	if err != nil {
+		return err
+	}
+
+	// Links to trashed players are kept, since they are hidden from the caller and should come back if the Player is restored.
+	query = `
+DELETE FROM entrant_players
+WHERE entrant_id = ?1
+  AND player_id IN (SELECT id FROM players WHERE deleted_at IS NULL)`
+
+	_, err = tx.ExecContext(ctx, query, entrantID)
+	if err != nil {
+		return err
+	}
+
+	// The tournament ID is copied over so that the database can enforce one entrant per player in each tournament.
+	query = `
+INSERT INTO entrant_players (entrant_id, player_id, tournament_id)
+SELECT id, ?2, tournament_id
+FROM entrants
+WHERE id = ?1`
+
+	for _, playerID := range playerIDs {
+		_, err = tx.ExecContext(ctx, query, entrantID, playerID)
+		if err != nil {
+			// Unique violations mean that the Player was linked to another Entrant, possibly by someone else while this change was being made.
+			return translateError(err)
+		}
+	}
+
+	after, err := getEntrant(ctx, tx, entrantID)
+	if err != nil {
+		return err
+	}
+
+	err = createEntry(ctx, tx, tournament.ActionSetPlayers, entrantID, before.Players, after.Players)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Due to the way the database is set up, deleting a Tournament will also delete its entrants, but this will still be implemented.
+func (es EntrantService) DeleteEntrants(ctx context.Context, tournamentID int64) error {
+	query := `
+DELETE FROM entrants
+WHERE tournament_id = ?1`
+
+	_, err := es.DB.ExecContext(ctx, query, tournamentID)
+
+	return err
+}
+
+func createEntrants(ctx context.Context, tx *sql.Tx, entrants []tournament.Entrant, tournamentID int64) error {
+	query := `
+INSERT INTO entrants (name, placement, tournament_id, participants)
+VALUES (?1, ?2, ?3, ?4)
+RETURNING id, version;`
+
+	for i, entrant := range entrants {
+		// This will update the entrant IDs as it goes along. If any errors occur, any written IDs will be invalidated.
+		err := tx.QueryRowContext(ctx, query, entrant.Name, entrant.Placement, tournamentID, jsonArray{entrant.Participants}).Scan(&entrants[i].ID, &entrants[i].Version)
+		if err != nil {
+			return err
+		}
+
+		err = createResults(ctx, tx, entrant.Results, entrants[i].ID, tournamentID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// createResults adds the given results to an Entrant. The phases of the Tournament should already exist.
+func createResults(ctx context.Context, tx *sql.Tx, results []tournament.Result, entrantID, tournamentID int64) error {
+	query := `
+INSERT INTO results (entrant_id, phase_id, pool, placement)
+SELECT ?1, id, ?4, ?5
+FROM phases
+WHERE tournament_id = ?2
+  AND phase_order = ?3`
+
+	for _, result := range results {
+		_, err := tx.ExecContext(ctx, query, entrantID, tournamentID, result.Phase, result.Group, result.Placement)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getResults returns the results of all entrants in the given Tournament, mapped by Entrant ID.
+func getResults(ctx context.Context, q queryer, tournamentID int64) (map[int64][]tournament.Result, error) {
+	query := `
+SELECT results.entrant_id, phases.phase_order, results.pool, results.placement
+FROM results
+         INNER JOIN phases ON results.phase_id = phases.id
+WHERE phases.tournament_id = ?1
+ORDER BY phases.phase_order`
+
+	rows, err := q.QueryContext(ctx, query, tournamentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make(map[int64][]tournament.Result)
+	for rows.Next() {
+		var (
+			entrantID int64
+			result    tournament.Result
+		)
+
+		err = rows.Scan(&entrantID, &result.Phase, &result.Group, &result.Placement)
+		if err != nil {
+			return nil, err
+		}
+
+		results[entrantID] = append(results[entrantID], result)
+	}
+
+	return results, rows.Err()
+}
+
+func getEntrant(ctx context.Context, q queryer, id int64) (entrant tournament.Entrant, err error) {
+	if id < 1 {
+		return entrant, tournament.Errorf(tournament.ENOTFOUND, "Entrant not found.")
+	}
+
+	query := `
+SELECT id, name, placement, tournament_id, participants, version
+FROM entrants
+WHERE id = ?1`
+
+	err = q.QueryRowContext(ctx, query, id).Scan(
+		&entrant.ID,
+		&entrant.Name,
+		&entrant.Placement,
+		&entrant.TournamentID,
+		jsonArray{&entrant.Participants},
+		&entrant.Version,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = tournament.Errorf(tournament.ENOTFOUND, "Entrant not found.")
+		}
+		return
+	}
+
+	players, err := getTournamentPlayers(ctx, q, entrant.TournamentID)
+	entrant.Players = players[entrant.ID]
+	return
+}
+
+// getTournamentPlayers returns the players of every Entrant in the given Tournament, mapped by Entrant ID.
+func getTournamentPlayers(ctx context.Context, q queryer, tournamentID int64) (map[int64][]tournament.Player, error) {
+	query := `
+SELECT entrant_players.entrant_id, players.id, players.name, players.version
+FROM entrant_players
+         INNER JOIN players ON entrant_players.player_id = players.id
+WHERE entrant_players.tournament_id = ?1
+  AND players.deleted_at IS NULL
+ORDER BY players.name`
+
+	rows, err := q.QueryContext(ctx, query, tournamentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	players := make(map[int64][]tournament.Player)
+	for rows.Next() {
+		var (
+			entrantID int64
+			player    tournament.Player
+		)
+
+		err = rows.Scan(&entrantID, &player.ID, &player.Name, &player.Version)
+		if err != nil {
+			return nil, err
+		}
+
+		players[entrantID] = append(players[entrantID], player)
+	}
+
+	return players, rows.Err()
+}
@@ -0,0 +1,66 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	tournament "github.com/ejacobg/tourney-tracker"
+)
+
+// FormulaService represents a service for managing the point formula.
+type FormulaService struct {
+	DB *sql.DB
+}
+
+func (fs FormulaService) GetFormula(ctx context.Context) (tournament.Formula, error) {
+	return getFormula(ctx, fs.DB)
+}
+
+func (fs FormulaService) UpdateFormula(ctx context.Context, formula tournament.Formula) error {
+	tx, err := fs.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := getFormula(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	query := `
+INSERT INTO formula (up_points, att_points, first_points, br_points)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (id) DO UPDATE SET up_points    = excluded.up_points,
+                               att_points   = excluded.att_points,
+                               first_points = excluded.first_points,
+                               br_points    = excluded.br_points`
+
+	_, err = tx.ExecContext(ctx, query, formula.UP, formula.ATT, formula.FIRST, formula.BR)
+	if err != nil {
+		return translateError(err)
+	}
+
+	// There is only one Formula, so its entries have no subject ID.
+	err = createEntry(ctx, tx, tournament.ActionUpdateFormula, 0, before, formula)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// getFormula returns the saved Formula, or the DefaultFormula if it has never been changed.
+func getFormula(ctx context.Context, q queryer) (formula tournament.Formula, err error) {
+	query := `
+SELECT up_points, att_points, first_points, br_points
+FROM formula`
+
+	err = q.QueryRowContext(ctx, query).Scan(&formula.UP, &formula.ATT, &formula.FIRST, &formula.BR)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return tournament.DefaultFormula, nil
+	}
+
+	return
+}
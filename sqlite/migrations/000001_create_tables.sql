@@ -0,0 +1,141 @@
+-- The SQLite schema matches the PostgreSQL schema after all of its migrations have been applied.
+-- Arrays are stored as JSON text, and times are stored as UTC text.
+CREATE TABLE tiers
+(
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    name       TEXT    NOT NULL,
+    multiplier INTEGER NOT NULL
+);
+
+-- These are the default tiers the program will start with.
+INSERT INTO tiers
+VALUES (1, 'C', 75),
+       (2, 'B', 150),
+       (3, 'A', 200),
+       (4, 'S', 300);
+
+CREATE TABLE games
+(
+    id   INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT UNIQUE NOT NULL
+);
+
+CREATE TABLE tournaments
+(
+    id            INTEGER PRIMARY KEY AUTOINCREMENT,
+    name          TEXT     NOT NULL,
+    url           TEXT     NOT NULL,
+    bracket_reset BOOLEAN  NOT NULL,
+    placements    TEXT     NOT NULL,
+    tier_id       INTEGER  NOT NULL REFERENCES tiers (id),
+    bracket_type  TEXT     NOT NULL DEFAULT 'double elimination',
+    teams         BOOLEAN  NOT NULL DEFAULT false,
+    team_scoring  TEXT     NOT NULL DEFAULT 'separate',
+    game_id       INTEGER REFERENCES games (id),
+    deleted_at    DATETIME
+);
+
+CREATE TABLE players
+(
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    name       TEXT UNIQUE NOT NULL,
+    deleted_at DATETIME,
+    version    INTEGER     NOT NULL DEFAULT 1
+);
+
+CREATE TABLE entrants
+(
+    id            INTEGER PRIMARY KEY AUTOINCREMENT,
+    name          TEXT    NOT NULL,
+    placement     INTEGER NOT NULL,
+    tournament_id INTEGER NOT NULL REFERENCES tournaments (id) ON DELETE CASCADE,
+    participants  TEXT    NOT NULL DEFAULT '[]',
+    version       INTEGER NOT NULL DEFAULT 1
+);
+
+CREATE TABLE phases
+(
+    id            INTEGER PRIMARY KEY AUTOINCREMENT,
+    name          TEXT    NOT NULL,
+    bracket_type  TEXT    NOT NULL,
+    phase_order   INTEGER NOT NULL,
+    tournament_id INTEGER NOT NULL REFERENCES tournaments (id) ON DELETE CASCADE,
+    UNIQUE (tournament_id, phase_order)
+);
+
+CREATE TABLE results
+(
+    entrant_id INTEGER NOT NULL REFERENCES entrants (id) ON DELETE CASCADE,
+    phase_id   INTEGER NOT NULL REFERENCES phases (id) ON DELETE CASCADE,
+    pool       TEXT    NOT NULL,
+    placement  INTEGER NOT NULL,
+    PRIMARY KEY (entrant_id, phase_id)
+);
+
+-- A player may only be assigned to one entrant per tournament.
+CREATE TABLE entrant_players
+(
+    entrant_id    INTEGER NOT NULL REFERENCES entrants (id) ON DELETE CASCADE,
+    player_id     INTEGER NOT NULL REFERENCES players (id) ON DELETE CASCADE,
+    tournament_id INTEGER NOT NULL REFERENCES tournaments (id) ON DELETE CASCADE,
+    PRIMARY KEY (entrant_id, player_id),
+    UNIQUE (tournament_id, player_id)
+);
+
+CREATE TABLE users
+(
+    id            INTEGER PRIMARY KEY AUTOINCREMENT,
+    name          TEXT UNIQUE NOT NULL,
+    password_hash BLOB        NOT NULL,
+    created_at    DATETIME    NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    role          TEXT        NOT NULL DEFAULT 'viewer' CHECK (role IN ('viewer', 'editor', 'organizer', 'admin'))
+);
+
+-- Only the SHA-256 hash of each session token is stored.
+CREATE TABLE sessions
+(
+    token_hash BLOB PRIMARY KEY,
+    user_id    INTEGER  NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    expiry     DATETIME NOT NULL
+);
+
+CREATE INDEX sessions_expiry_idx ON sessions (expiry);
+
+-- Only the SHA-256 hash of each token is stored. Tokens are revoked by deleting them.
+CREATE TABLE tokens
+(
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    token_hash   BLOB UNIQUE NOT NULL,
+    label        TEXT        NOT NULL,
+    role         TEXT        NOT NULL CHECK (role IN ('viewer', 'editor', 'organizer', 'admin')),
+    user_id      INTEGER     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at   DATETIME    NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    last_used_at DATETIME
+);
+
+-- Entries keep the name of the user who made each change, so that the log is still readable after users are deleted.
+CREATE TABLE audit_log
+(
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id    INTEGER REFERENCES users (id) ON DELETE SET NULL,
+    actor      TEXT     NOT NULL,
+    action     TEXT     NOT NULL,
+    subject    TEXT     NOT NULL,
+    subject_id INTEGER  NOT NULL,
+    before     TEXT,
+    after      TEXT,
+    created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+
+CREATE INDEX audit_log_subject_idx ON audit_log (subject, subject_id);
+
+-- The data of each snapshot is stored as a single JSON object, holding an array of rows for each table. See sqlite.SnapshotService.
+CREATE TABLE snapshots
+(
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    name       TEXT     NOT NULL,
+    automatic  BOOLEAN  NOT NULL DEFAULT false,
+    created_by TEXT     NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    data       TEXT     NOT NULL
+);
@@ -0,0 +1,9 @@
+-- The formula table holds at most one row. Until an admin changes the formula, it is empty and tournament.DefaultFormula is used.
+CREATE TABLE formula
+(
+    id           BOOLEAN PRIMARY KEY DEFAULT true CHECK (id),
+    up_points    INTEGER NOT NULL CHECK (up_points >= 0),
+    att_points   INTEGER NOT NULL CHECK (att_points >= 0),
+    first_points INTEGER NOT NULL CHECK (first_points >= 0),
+    br_points    INTEGER NOT NULL CHECK (br_points >= 0)
+);
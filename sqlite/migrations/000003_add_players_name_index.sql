@@ -0,0 +1,21 @@
+-- Players in the trash no longer keep their names, so that a deleted player's name can be given to a new player.
+-- Restoring a player whose name has since been taken fails instead.
+-- SQLite cannot drop a column's UNIQUE constraint, so the table is rebuilt without it. See https://www.sqlite.org/lang_altertable.html.
+CREATE TABLE players_new
+(
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    name       TEXT    NOT NULL,
+    deleted_at DATETIME,
+    version    INTEGER NOT NULL DEFAULT 1
+);
+
+INSERT INTO players_new (id, name, deleted_at, version)
+SELECT id, name, deleted_at, version
+FROM players;
+
+DROP TABLE players;
+
+ALTER TABLE players_new
+    RENAME TO players;
+
+CREATE UNIQUE INDEX players_name_idx ON players (name) WHERE deleted_at IS NULL;
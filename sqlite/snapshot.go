@@ -0,0 +1,258 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	tournament "github.com/ejacobg/tourney-tracker"
+	"strings"
+)
+
+// snapshotTables holds every table saved in a Snapshot and its columns, ordered so that each table comes after the tables it references.
+var snapshotTables = []struct {
+	name    string
+	columns []string
+}{
+	{"tiers", []string{"id", "name", "multiplier"}},
+	{"games", []string{"id", "name"}},
+	{"players", []string{"id", "name", "deleted_at", "version"}},
+	{"tournaments", []string{"id", "name", "url", "bracket_reset", "placements", "tier_id", "bracket_type", "teams", "team_scoring", "game_id", "deleted_at"}},
+	{"phases", []string{"id", "name", "bracket_type", "phase_order", "tournament_id"}},
+	{"entrants", []string{"id", "name", "placement", "tournament_id", "participants", "version"}},
+	{"results", []string{"entrant_id", "phase_id", "pool", "placement"}},
+	{"entrant_players", []string{"entrant_id", "player_id", "tournament_id"}},
+	{"formula", []string{"id", "up_points", "att_points", "first_points", "br_points"}},
+}
+
+// snapshotDefaults holds the values of the NOT NULL columns that may be missing from older snapshots, keyed by table and column.
+// Their rows are restored using these values instead.
+var snapshotDefaults = map[string]string{
+	"players.version":  "1",
+	"entrants.version": "1",
+}
+
+// SnapshotService represents a service for saving and restoring copies of the tracker's data.
+// Each Snapshot stores the rows of every table in snapshotTables as JSON, which are converted back into rows when restored.
+// Tables and columns missing from a Snapshot are restored empty, or using their snapshotDefaults.
+type SnapshotService struct {
+	DB *sql.DB
+}
+
+func (ss SnapshotService) GetSnapshots(ctx context.Context) (snapshots []tournament.Snapshot, err error) {
+	query := `
+SELECT id, name, automatic, created_by, created_at, json_array_length(data, '$.tournaments'), json_array_length(data, '$.players')
+FROM snapshots
+ORDER BY created_at DESC`
+
+	rows, err := ss.DB.QueryContext(ctx, query)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var snapshot tournament.Snapshot
+
+		err = rows.Scan(&snapshot.ID, &snapshot.Name, &snapshot.Automatic, &snapshot.CreatedBy, &snapshot.CreatedAt, &snapshot.Tournaments, &snapshot.Players)
+		if err != nil {
+			return
+		}
+
+		snapshots = append(snapshots, snapshot)
+	}
+
+	return snapshots, rows.Err()
+}
+
+func (ss SnapshotService) GetSnapshot(ctx context.Context, id int64) (tournament.Snapshot, error) {
+	return getSnapshot(ctx, ss.DB, id)
+}
+
+func (ss SnapshotService) CreateSnapshot(ctx context.Context, snapshot *tournament.Snapshot) error {
+	// SQLite cannot convert a whole row to JSON, so each column is named. Columns holding JSON text are kept as text.
+	tables := make([]string, len(snapshotTables))
+	for i, table := range snapshotTables {
+		pairs := make([]string, len(table.columns))
+		for j, column := range table.columns {
+			pairs[j] = fmt.Sprintf("'%[1]s', %[1]s", column)
+		}
+		tables[i] = fmt.Sprintf("'%s', (SELECT json_group_array(json_object(%s)) FROM %[1]s)", table.name, strings.Join(pairs, ", "))
+	}
+
+	// The data is read in a single statement, so every table is copied from the same point in time.
+	query := fmt.Sprintf(`
+INSERT INTO snapshots (name, automatic, created_by, data)
+VALUES (?1, ?2, ?3, json_object(%s))
+RETURNING id, created_at, json_array_length(data, '$.tournaments'), json_array_length(data, '$.players')`, strings.Join(tables, ",\n"))
+
+	tx, err := ss.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, query, snapshot.Name, snapshot.Automatic, snapshot.CreatedBy).
+		Scan(&snapshot.ID, &snapshot.CreatedAt, &snapshot.Tournaments, &snapshot.Players)
+	if err != nil {
+		return err
+	}
+
+	err = createEntry(ctx, tx, tournament.ActionCreateSnapshot, snapshot.ID, nil, snapshot)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (ss SnapshotService) RestoreSnapshot(ctx context.Context, id int64) error {
+	tx, err := ss.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// The data is read first, so that a missing Snapshot cannot clear every table.
+	var data string
+	err = tx.QueryRowContext(ctx, `SELECT data FROM snapshots WHERE id = ?1`, id).Scan(&data)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = tournament.Errorf(tournament.ENOTFOUND, "Snapshot not found.")
+		}
+		return err
+	}
+
+	// The restored Snapshot is recorded as the value after the change.
+	after, err := getSnapshot(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	// Tables are cleared in reverse order, so that no row is deleted while another row still references it.
+	for i := len(snapshotTables) - 1; i >= 0; i-- {
+		_, err = tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s`, snapshotTables[i].name))
+		if err != nil {
+			return err
+		}
+	}
+
+	// Each value is extracted by its column name, so that it is put back into the right column.
+	// New rows will not reuse the restored IDs, since AUTOINCREMENT always moves past the largest ID in the table.
+	for _, table := range snapshotTables {
+		values := make([]string, len(table.columns))
+		for i, column := range table.columns {
+			values[i] = fmt.Sprintf("value ->> '%s'", column)
+			if value, ok := snapshotDefaults[table.name+"."+column]; ok {
+				values[i] = fmt.Sprintf("COALESCE(%s, %s)", values[i], value)
+			}
+		}
+
+		query := fmt.Sprintf(`
+INSERT INTO %s (%s)
+SELECT %s
+FROM json_each(?1, '$.%[1]s')`, table.name, strings.Join(table.columns, ", "), strings.Join(values, ", "))
+
+		_, err = tx.ExecContext(ctx, query, data)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = createEntry(ctx, tx, tournament.ActionRestoreSnapshot, id, nil, after)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (ss SnapshotService) DeleteSnapshot(ctx context.Context, id int64) error {
+	tx, err := ss.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := getSnapshot(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM snapshots WHERE id = ?1`, id)
+	if err != nil {
+		return err
+	}
+
+	err = createEntry(ctx, tx, tournament.ActionDeleteSnapshot, id, before, nil)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (ss SnapshotService) PurgeSnapshots(ctx context.Context, keep int) error {
+	tx, err := ss.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// IDs are assigned in order, so the newest snapshots have the highest IDs, even if they were taken at the same time.
+	query := `
+DELETE
+FROM snapshots
+WHERE automatic
+  AND id NOT IN (SELECT id
+                 FROM snapshots
+                 WHERE automatic
+                 ORDER BY id DESC
+                 LIMIT ?1)
+RETURNING id, name, automatic, created_by, created_at, json_array_length(data, '$.tournaments'), json_array_length(data, '$.players')`
+
+	rows, err := tx.QueryContext(ctx, query, keep)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var purged []tournament.Snapshot
+	for rows.Next() {
+		var snapshot tournament.Snapshot
+
+		err = rows.Scan(&snapshot.ID, &snapshot.Name, &snapshot.Automatic, &snapshot.CreatedBy, &snapshot.CreatedAt, &snapshot.Tournaments, &snapshot.Players)
+		if err != nil {
+			return err
+		}
+
+		purged = append(purged, snapshot)
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for _, snapshot := range purged {
+		err = createEntry(ctx, tx, tournament.ActionPurgeSnapshot, snapshot.ID, snapshot, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func getSnapshot(ctx context.Context, q queryer, id int64) (snapshot tournament.Snapshot, err error) {
+	query := `
+SELECT id, name, automatic, created_by, created_at, json_array_length(data, '$.tournaments'), json_array_length(data, '$.players')
+FROM snapshots
+WHERE id = ?1`
+
+	err = q.QueryRowContext(ctx, query, id).Scan(&snapshot.ID, &snapshot.Name, &snapshot.Automatic, &snapshot.CreatedBy, &snapshot.CreatedAt, &snapshot.Tournaments, &snapshot.Players)
+
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		err = tournament.Errorf(tournament.ENOTFOUND, "Snapshot not found.")
+	}
+
+	return
+}
@@ -0,0 +1,310 @@
+// Package sqlite implements the tracker's services using an SQLite database, for communities that do not want to run a PostgreSQL server.
+// The schema mirrors the one used by the postgres package. Arrays are stored as JSON text, and times are stored as UTC text.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	tournament "github.com/ejacobg/tourney-tracker"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// queryer is implemented by both *sql.DB and *sql.Tx, allowing helper functions to be used inside and outside of transactions.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// NewServices returns an implementation of every service, all using the given database.
+func NewServices(db *sql.DB) tournament.Services {
+	return tournament.Services{
+		AuditService:      AuditService{DB: db},
+		EntrantService:    EntrantService{DB: db},
+		FormulaService:    FormulaService{DB: db},
+		GameService:       GameService{DB: db},
+		PlayerService:     PlayerService{DB: db},
+		SessionService:    SessionService{DB: db},
+		SnapshotService:   SnapshotService{DB: db},
+		TierService:       TierService{DB: db},
+		TokenService:      TokenService{DB: db},
+		TournamentService: TournamentService{DB: db},
+		UserService:       UserService{DB: db},
+	}
+}
+
+// Open opens the SQLite database file at the given path, creating it if it does not exist.
+// Foreign keys are enforced, and times are written in a format that SQLite's date functions understand.
+func Open(path string) (*sql.DB, error) {
+	dsn := "file:" + path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_time_format=sqlite"
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite only allows one writer at a time. Sharing a single connection queues transactions up, rather than failing them with SQLITE_BUSY.
+	// This also keeps in-memory databases alive, since each connection to ":memory:" would otherwise get its own database.
+	db.SetMaxOpenConns(1)
+
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// Version returns the version of the last migration applied to the database, and the version of the last migration in the migrations directory.
+func Version(db *sql.DB) (current, latest int, err error) {
+	versions, err := migrationVersions()
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(versions) > 0 {
+		latest = versions[len(versions)-1].version
+	}
+
+	err = db.QueryRow(`PRAGMA user_version`).Scan(&current)
+	return current, latest, err
+}
+
+// Migrate applies any migrations in the migrations directory that have not been applied yet.
+// Each migration is named after its version number, and the version of the last migration applied is kept in the user_version pragma.
+func Migrate(db *sql.DB) error {
+	var current int
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&current); err != nil {
+		return err
+	}
+
+	versions, err := migrationVersions()
+	if err != nil {
+		return err
+	}
+
+	for _, v := range versions {
+		if v.version <= current {
+			continue
+		}
+
+		if err = migrate(db, v.name, v.version); err != nil {
+			return fmt.Errorf("migration %s: %w", v.name, err)
+		}
+	}
+
+	return nil
+}
+
+// migration names the script of a single version.
+type migration struct {
+	name    string
+	version int
+}
+
+// migrationVersions returns the migrations in the migrations directory, in order.
+func migrationVersions() ([]migration, error) {
+	names, err := fs.Glob(migrations, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	versions := make([]migration, len(names))
+	for i, name := range names {
+		version, err := strconv.Atoi(strings.SplitN(path.Base(name), "_", 2)[0])
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: invalid version: %w", name, err)
+		}
+		versions[i] = migration{name, version}
+	}
+
+	return versions, nil
+}
+
+// migrate applies a single migration, then records its version. Nothing is changed if the migration fails.
+// Foreign keys are turned off while the migration runs, so that tables can be rebuilt without their rows being deleted by cascades.
+// They are checked again before the migration is committed.
+func migrate(db *sql.DB, name string, version int) error {
+	script, err := migrations.ReadFile(name)
+	if err != nil {
+		return err
+	}
+
+	// Pragmas apply to a single connection, and foreign keys cannot be turned off inside a transaction.
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err = conn.ExecContext(ctx, `PRAGMA foreign_keys = OFF`); err != nil {
+		return err
+	}
+	defer conn.ExecContext(ctx, `PRAGMA foreign_keys = ON`)
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Exec(string(script)); err != nil {
+		return err
+	}
+
+	// Each row returned names a table, the row violating its foreign key, the table it refers to, and the index of the foreign key.
+	var (
+		table, parent string
+		rowID         sql.NullInt64
+		fkID          int
+	)
+	err = tx.QueryRow(`PRAGMA foreign_key_check`).Scan(&table, &rowID, &parent, &fkID)
+	if err == nil {
+		return fmt.Errorf("row %d of %s refers to a missing row of %s", rowID.Int64, table, parent)
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	// Pragmas cannot take parameters.
+	if _, err = tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, version)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// trashTables holds the tables whose rows can be moved to the trash, using their deleted_at column.
+var trashTables = map[string]bool{"players": true, "tournaments": true}
+
+// versionError returns the error for an update of the given row that changed nothing because of its version.
+// The update may also have changed nothing because the row does not exist, so an ENOTFOUND error is returned in that case instead of an ECONFLICT.
+// The name of the row's type is used in the error message, eg. "Player". Rows in the trash are treated as if they do not exist.
+func versionError(ctx context.Context, q queryer, table, name string, id int64) error {
+	query := `SELECT EXISTS(SELECT 1 FROM %s WHERE id = ?1)`
+	if trashTables[table] {
+		query = `SELECT EXISTS(SELECT 1 FROM %s WHERE id = ?1 AND deleted_at IS NULL)`
+	}
+
+	var exists bool
+	err := q.QueryRowContext(ctx, fmt.Sprintf(query, table), id).Scan(&exists)
+
+	switch {
+	case err != nil:
+		return err
+	case !exists:
+		return tournament.Errorf(tournament.ENOTFOUND, "%s not found.", name)
+	default:
+		return tournament.Errorf(tournament.ECONFLICT, "The %s was changed by someone else.", strings.ToLower(name))
+	}
+}
+
+// constraintMessages holds the message shown to users for each constraint that their changes may violate.
+// SQLite does not name its constraints, so they are identified by the columns given in the error message.
+var constraintMessages = map[string]string{
+	"entrant_players.entrant_id, entrant_players.player_id":    "That player is already linked to this entrant.",
+	"entrant_players.tournament_id, entrant_players.player_id": "That player is already linked to another entrant in this tournament.",
+	"games.name":   "Another game already has that name.",
+	"players.name": "Another player already has that name.",
+	"users.name":   "Another user already has that name.",
+}
+
+// translateError converts errors caused by invalid data into *tournament.Error values, so that their messages can be shown to users.
+// Unique violations become ECONFLICT errors, and other constraint violations become EINVALID errors. Other errors are returned as-is.
+func translateError(err error) error {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return err
+	}
+
+	// Messages end with the columns involved, eg. "constraint failed: UNIQUE constraint failed: players.name (2067)".
+	var message string
+	if i := strings.LastIndex(sqliteErr.Error(), "constraint failed: "); i >= 0 {
+		columns, _, _ := strings.Cut(sqliteErr.Error()[i+len("constraint failed: "):], " (")
+		message = constraintMessages[columns]
+	}
+
+	switch sqliteErr.Code() {
+	case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+		if message == "" {
+			message = "This conflicts with existing data."
+		}
+		return &tournament.Error{Code: tournament.ECONFLICT, Message: message}
+	case sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY:
+		// SQLite does not report which foreign key failed. See referenceError() for deletions of rows that are still referenced.
+		return tournament.Errorf(tournament.EINVALID, "This refers to something that does not exist.")
+	case sqlite3.SQLITE_CONSTRAINT_NOTNULL, sqlite3.SQLITE_CONSTRAINT_CHECK:
+		return tournament.Errorf(tournament.EINVALID, "Invalid value.")
+	}
+
+	return err
+}
+
+// referenceError translates errors from deleting a row. Foreign key violations mean that the row is still referenced, which is a conflict rather than a bad reference.
+func referenceError(err error) error {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY {
+		return tournament.Errorf(tournament.ECONFLICT, "This is still in use.")
+	}
+	return translateError(err)
+}
+
+// jsonArray stores a slice as JSON text, in place of the arrays used by PostgreSQL. The value should be a pointer to a slice when scanning.
+type jsonArray struct {
+	value any
+}
+
+// Value implements the driver.Valuer interface.
+func (a jsonArray) Value() (driver.Value, error) {
+	data, err := json.Marshal(a.value)
+	if err != nil {
+		return nil, err
+	}
+
+	// Nil slices are stored as empty arrays, matching the column defaults.
+	if string(data) == "null" {
+		return "[]", nil
+	}
+	return string(data), nil
+}
+
+// Scan implements the sql.Scanner interface.
+func (a jsonArray) Scan(src any) error {
+	switch src := src.(type) {
+	case string:
+		return json.Unmarshal([]byte(src), a.value)
+	case []byte:
+		return json.Unmarshal(src, a.value)
+	case nil:
+		return nil
+	}
+	return fmt.Errorf("cannot scan %T into a JSON array", src)
+}
+
+// jsonText converts the given JSON into a query argument, so that it is stored as text rather than as a blob.
+func jsonText(raw json.RawMessage) any {
+	if raw == nil {
+		return nil
+	}
+	return string(raw)
+}
+
+// utc converts the given time to UTC, so that stored times can be compared as text.
+func utc(t time.Time) time.Time {
+	return t.UTC()
+}
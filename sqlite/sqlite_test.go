@@ -0,0 +1,325 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	tournament "github.com/ejacobg/tourney-tracker"
+	"github.com/ejacobg/tourney-tracker/servicetest"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// openTestDB opens a migrated database in a temporary directory, which is removed when the test finishes.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err = Migrate(db); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	return db
+}
+
+func TestMigrate(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	// Applied migrations are skipped, rather than failing on tables that already exist.
+	if err := Migrate(db); err != nil {
+		t.Fatalf("second Migrate() error = %v", err)
+	}
+
+	tiers, err := TierService{DB: db}.GetTiers(ctx)
+	if err != nil {
+		t.Fatalf("GetTiers() error = %v", err)
+	}
+	if len(tiers) != 4 {
+		t.Errorf("GetTiers() returned %d tiers, want the 4 default tiers", len(tiers))
+	}
+}
+
+// TestMigrate_rebuildPlayers checks that rebuilding the players table keeps the links of existing players.
+func TestMigrate_rebuildPlayers(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	// Apply the migrations from before the rebuild.
+	for version, name := range []string{"migrations/000001_create_tables.sql", "migrations/000002_create_formula_table.sql"} {
+		if err = migrate(db, name, version+1); err != nil {
+			t.Fatalf("migrate(%s) error = %v", name, err)
+		}
+	}
+
+	tourney := tournament.Tournament{Name: "Weekly", BracketType: tournament.DoubleElimination, Placements: []int64{2, 1}}
+	entrants := []tournament.Entrant{{Name: "A", Placement: 1}, {Name: "B", Placement: 2}}
+	if err = (TournamentService{DB: db}).CreateTournament(ctx, &tourney, entrants); err != nil {
+		t.Fatalf("CreateTournament() error = %v", err)
+	}
+	player := tournament.Player{Name: "Mango"}
+	if err = (PlayerService{DB: db}).CreatePlayer(ctx, &player); err != nil {
+		t.Fatalf("CreatePlayer() error = %v", err)
+	}
+	es := EntrantService{DB: db}
+	if err = es.SetPlayers(ctx, entrants[0].ID, 0, []int64{player.ID}); err != nil {
+		t.Fatalf("SetPlayers() error = %v", err)
+	}
+
+	if err = Migrate(db); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+
+	got, _, err := es.GetEntrantWithPoints(ctx, entrants[0].ID)
+	if err != nil {
+		t.Fatalf("GetEntrantWithPoints() error = %v", err)
+	}
+	if len(got.Players) != 1 || got.Players[0].ID != player.ID {
+		t.Errorf("GetEntrantWithPoints() players = %+v, want Mango", got.Players)
+	}
+}
+
+func TestTournamentService_CreateTournament(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	ts := TournamentService{DB: db}
+
+	tourney := tournament.Tournament{
+		Name:        "Weekly #1",
+		URL:         "https://challonge.com/weekly1",
+		BracketType: tournament.DoubleElimination,
+		Teams:       true,
+		Placements:  []int64{3, 2, 1},
+		Game:        tournament.Game{Name: "Melee"},
+	}
+	entrants := []tournament.Entrant{
+		{Name: "A", Placement: 1, Participants: []string{"a1", "a2"}},
+		{Name: "B", Placement: 2},
+		{Name: "C", Placement: 3},
+	}
+
+	if err := ts.CreateTournament(ctx, &tourney, entrants); err != nil {
+		t.Fatalf("CreateTournament() error = %v", err)
+	}
+	if tourney.Tier.Name != "C" || tourney.Tier.Multiplier != 75 {
+		t.Errorf("CreateTournament() tier = %+v, want the default C tier", tourney.Tier)
+	}
+
+	got, err := ts.GetTournament(ctx, tourney.ID)
+	if err != nil {
+		t.Fatalf("GetTournament() error = %v", err)
+	}
+	if !reflect.DeepEqual(got.Placements, tourney.Placements) || got.Game.Name != "Melee" || got.TeamScoring != tournament.SeparateLeaderboard {
+		t.Errorf("GetTournament() = %+v, want %+v", got, tourney)
+	}
+
+	stored, err := EntrantService{DB: db}.GetEntrants(ctx, tourney.ID)
+	if err != nil {
+		t.Fatalf("GetEntrants() error = %v", err)
+	}
+	if len(stored) != 3 || !reflect.DeepEqual(stored[0].Participants, []string{"a1", "a2"}) || len(stored[1].Participants) != 0 {
+		t.Errorf("GetEntrants() = %+v, want the created entrants", stored)
+	}
+}
+
+func TestTranslateError(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	ps := PlayerService{DB: db}
+
+	if err := ps.CreatePlayer(ctx, &tournament.Player{Name: "Mango"}); err != nil {
+		t.Fatalf("CreatePlayer() error = %v", err)
+	}
+
+	err := ps.CreatePlayer(ctx, &tournament.Player{Name: "Mango"})
+	if tournament.ErrorCode(err) != tournament.ECONFLICT || tournament.ErrorMessage(err) != "Another player already has that name." {
+		t.Errorf("CreatePlayer() error = %v, want a conflict on the name", err)
+	}
+
+	ts := TournamentService{DB: db}
+	tourney := tournament.Tournament{Name: "Weekly", BracketType: tournament.DoubleElimination, Placements: []int64{1}}
+	if err = ts.CreateTournament(ctx, &tourney, nil); err != nil {
+		t.Fatalf("CreateTournament() error = %v", err)
+	}
+
+	err = ts.SetTier(ctx, tourney.ID, 99)
+	if tournament.ErrorCode(err) != tournament.EINVALID {
+		t.Errorf("SetTier() error = %v, want %s", err, tournament.EINVALID)
+	}
+
+	err = TierService{DB: db}.DeleteTier(ctx, tourney.Tier.ID)
+	if tournament.ErrorCode(err) != tournament.ECONFLICT {
+		t.Errorf("DeleteTier() error = %v, want %s", err, tournament.ECONFLICT)
+	}
+}
+
+func TestPlayerService_PurgePlayers(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	ps := PlayerService{DB: db}
+
+	player := tournament.Player{Name: "Mango"}
+	if err := ps.CreatePlayer(ctx, &player); err != nil {
+		t.Fatalf("CreatePlayer() error = %v", err)
+	}
+	if err := ps.DeletePlayer(ctx, player.ID); err != nil {
+		t.Fatalf("DeletePlayer() error = %v", err)
+	}
+
+	// Times are compared as text, so players trashed just now must not be purged by an earlier cutoff in another time zone.
+	if err := ps.PurgePlayers(ctx, time.Now().In(time.FixedZone("UTC+8", 8*60*60)).Add(-time.Minute)); err != nil {
+		t.Fatalf("PurgePlayers() error = %v", err)
+	}
+
+	trashed, err := ps.GetDeletedPlayers(ctx)
+	if err != nil {
+		t.Fatalf("GetDeletedPlayers() error = %v", err)
+	}
+	if len(trashed) != 1 || time.Since(trashed[0].DeletedAt) > time.Minute {
+		t.Fatalf("GetDeletedPlayers() = %+v, want the trashed player", trashed)
+	}
+
+	if err = ps.PurgePlayers(ctx, time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("PurgePlayers() error = %v", err)
+	}
+	if _, err = ps.GetPlayer(ctx, player.ID); tournament.ErrorCode(err) != tournament.ENOTFOUND {
+		t.Errorf("GetPlayer() error = %v, want %s", err, tournament.ENOTFOUND)
+	}
+}
+
+func TestSnapshotService_RestoreSnapshot(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	ss := SnapshotService{DB: db}
+	ts := TournamentService{DB: db}
+
+	tourney := tournament.Tournament{Name: "Weekly", BracketType: tournament.DoubleElimination, Placements: []int64{2, 1}}
+	entrants := []tournament.Entrant{{Name: "A", Placement: 1}, {Name: "B", Placement: 2}}
+	if err := ts.CreateTournament(ctx, &tourney, entrants); err != nil {
+		t.Fatalf("CreateTournament() error = %v", err)
+	}
+
+	snapshot := tournament.Snapshot{Name: "Before"}
+	if err := ss.CreateSnapshot(ctx, &snapshot); err != nil {
+		t.Fatalf("CreateSnapshot() error = %v", err)
+	}
+	if snapshot.Tournaments != 1 || snapshot.Players != 0 {
+		t.Errorf("CreateSnapshot() counted %d tournaments and %d players, want 1 and 0", snapshot.Tournaments, snapshot.Players)
+	}
+
+	if err := ts.DeleteTournament(ctx, tourney.ID); err != nil {
+		t.Fatalf("DeleteTournament() error = %v", err)
+	}
+	if err := ts.PurgeTournaments(ctx, time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("PurgeTournaments() error = %v", err)
+	}
+
+	if err := ss.RestoreSnapshot(ctx, snapshot.ID); err != nil {
+		t.Fatalf("RestoreSnapshot() error = %v", err)
+	}
+
+	got, err := ts.GetTournament(ctx, tourney.ID)
+	if err != nil {
+		t.Fatalf("GetTournament() error = %v", err)
+	}
+	if !reflect.DeepEqual(got.Placements, tourney.Placements) {
+		t.Errorf("GetTournament() placements = %v, want %v", got.Placements, tourney.Placements)
+	}
+
+	restored, err := EntrantService{DB: db}.GetEntrants(ctx, tourney.ID)
+	if err != nil {
+		t.Fatalf("GetEntrants() error = %v", err)
+	}
+	if len(restored) != 2 {
+		t.Errorf("GetEntrants() returned %d entrants, want 2", len(restored))
+	}
+
+	if err = ss.RestoreSnapshot(ctx, snapshot.ID+1); tournament.ErrorCode(err) != tournament.ENOTFOUND {
+		t.Errorf("RestoreSnapshot() of a missing snapshot error = %v, want %s", err, tournament.ENOTFOUND)
+	}
+}
+
+// TestSnapshotService_RestoreSnapshot_older checks that snapshots taken before a column or table was added can still be restored.
+func TestSnapshotService_RestoreSnapshot_older(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	// This snapshot has no player versions, and no formula.
+	data := `{"tiers": [{"id": 1, "name": "C", "multiplier": 75}], "games": [], "players": [{"id": 1, "name": "Mango", "deleted_at": null}],
+"tournaments": [], "phases": [], "entrants": [], "results": [], "entrant_players": []}`
+	var id int64
+	err := db.QueryRow(`INSERT INTO snapshots (name, data) VALUES ('older', ?1) RETURNING id`, data).Scan(&id)
+	if err != nil {
+		t.Fatalf("inserting the snapshot: %v", err)
+	}
+
+	if err = (FormulaService{DB: db}).UpdateFormula(ctx, tournament.Formula{UP: 1, ATT: 2, FIRST: 3, BR: 4}); err != nil {
+		t.Fatalf("UpdateFormula() error = %v", err)
+	}
+
+	if err = (SnapshotService{DB: db}).RestoreSnapshot(ctx, id); err != nil {
+		t.Fatalf("RestoreSnapshot() error = %v", err)
+	}
+
+	player, err := PlayerService{DB: db}.GetPlayer(ctx, 1)
+	if err != nil || player.Name != "Mango" || player.Version != 1 {
+		t.Errorf("GetPlayer() = %+v, %v, want Mango at version 1", player, err)
+	}
+	if formula, err := (FormulaService{DB: db}).GetFormula(ctx); err != nil || formula != tournament.DefaultFormula {
+		t.Errorf("GetFormula() = %+v, %v, want the default formula", formula, err)
+	}
+}
+
+func TestTokenService_AuthenticateToken(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	us := UserService{DB: db}
+	user := tournament.User{Name: "organizer", Role: tournament.RoleOrganizer, PasswordHash: []byte("hash")}
+	if err := us.CreateUser(ctx, &user); err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+
+	ts := TokenService{DB: db}
+	token := tournament.Token{Plaintext: "secret", Label: "bot", Role: tournament.RoleOrganizer, UserID: user.ID}
+	if err := ts.CreateToken(ctx, &token); err != nil {
+		t.Fatalf("CreateToken() error = %v", err)
+	}
+
+	got, err := ts.AuthenticateToken(ctx, "secret")
+	if err != nil {
+		t.Fatalf("AuthenticateToken() error = %v", err)
+	}
+	if got.ID != token.ID || got.UserName != "organizer" || got.Role != tournament.RoleOrganizer || got.LastUsedAt == nil {
+		t.Errorf("AuthenticateToken() = %+v, want organizer token %d of organizer with a last use", got, token.ID)
+	}
+
+	// A demoted User's tokens lose the permissions that the User lost.
+	user.Role = tournament.RoleEditor
+	if err = us.UpdateUser(ctx, &user); err != nil {
+		t.Fatalf("UpdateUser() error = %v", err)
+	}
+	if got, err = ts.AuthenticateToken(ctx, "secret"); err != nil || got.Role != tournament.RoleEditor {
+		t.Errorf("AuthenticateToken() after demotion = %+v, %v, want the editor role", got, err)
+	}
+
+	if _, err = ts.AuthenticateToken(ctx, "wrong"); tournament.ErrorCode(err) != tournament.ENOTFOUND {
+		t.Errorf("AuthenticateToken() with a wrong token error = %v, want %s", err, tournament.ENOTFOUND)
+	}
+}
+
+func TestServices(t *testing.T) {
+	servicetest.Run(t, func(t *testing.T) tournament.Services {
+		return NewServices(openTestDB(t))
+	})
+}
@@ -0,0 +1,100 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	tournament "github.com/ejacobg/tourney-tracker"
+)
+
+// TierService represents a service for managing tiers.
+type TierService struct {
+	DB *sql.DB
+}
+
+func (ts TierService) GetTiers(ctx context.Context) (tiers []tournament.Tier, err error) {
+	query := `
+SELECT id, name, multiplier
+FROM tiers`
+
+	rows, err := ts.DB.QueryContext(ctx, query)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tier tournament.Tier
+
+		err = rows.Scan(&tier.ID, &tier.Name, &tier.Multiplier)
+		if err != nil {
+			return
+		}
+
+		tiers = append(tiers, tier)
+	}
+
+	return tiers, rows.Err()
+}
+
+func (ts TierService) GetTier(ctx context.Context, id int64) (tier tournament.Tier, err error) {
+	query := `
+SELECT id, name, multiplier
+FROM tiers
+WHERE id = ?1`
+
+	err = ts.DB.QueryRowContext(ctx, query, id).Scan(&tier.ID, &tier.Name, &tier.Multiplier)
+
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		err = tournament.Errorf(tournament.ENOTFOUND, "Tier not found.")
+	}
+
+	return
+}
+
+func (ts TierService) GetTournamentTier(ctx context.Context, tournamentID int64) (tier tournament.Tier, err error) {
+	query := `
+SELECT tiers.id, tiers.name, multiplier
+FROM tournaments
+INNER JOIN tiers on tournaments.tier_id = tiers.id
+WHERE tournaments.id = ?1`
+
+	err = ts.DB.QueryRowContext(ctx, query, tournamentID).Scan(&tier.ID, &tier.Name, &tier.Multiplier)
+
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		err = tournament.Errorf(tournament.ENOTFOUND, "Tournament not found.")
+	}
+
+	return
+}
+
+func (ts TierService) CreateTier(ctx context.Context, tier *tournament.Tier) error {
+	query := `
+INSERT INTO tiers (name, multiplier)
+VALUES (?1, ?2)
+RETURNING id`
+
+	err := ts.DB.QueryRowContext(ctx, query, tier.Name, tier.Multiplier).Scan(&tier.ID)
+
+	return translateError(err)
+}
+
+func (ts TierService) UpdateTier(ctx context.Context, tier *tournament.Tier) error {
+	query := `UPDATE tiers
+SET name = ?2, multiplier = ?3
+WHERE id = ?1`
+
+	_, err := ts.DB.ExecContext(ctx, query, tier.ID, tier.Name, tier.Multiplier)
+
+	return translateError(err)
+}
+
+func (ts TierService) DeleteTier(ctx context.Context, id int64) error {
+	query := `
+DELETE FROM tiers
+WHERE id = ?1`
+
+	_, err := ts.DB.ExecContext(ctx, query, id)
+
+	return referenceError(err)
+}
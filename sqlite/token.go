@@ -0,0 +1,83 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	tournament "github.com/ejacobg/tourney-tracker"
+)
+
+// TokenService represents a service for managing API tokens.
+type TokenService struct {
+	DB *sql.DB
+}
+
+func (ts TokenService) GetTokens(ctx context.Context) (tokens []tournament.Token, err error) {
+	query := `
+SELECT tokens.id, label, tokens.role, user_id, users.name, tokens.created_at, last_used_at
+FROM tokens
+         INNER JOIN users ON users.id = tokens.user_id
+ORDER BY tokens.created_at DESC`
+
+	rows, err := ts.DB.QueryContext(ctx, query)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var token tournament.Token
+
+		err = rows.Scan(&token.ID, &token.Label, &token.Role, &token.UserID, &token.UserName, &token.CreatedAt, &token.LastUsedAt)
+		if err != nil {
+			return
+		}
+
+		tokens = append(tokens, token)
+	}
+
+	return tokens, rows.Err()
+}
+
+func (ts TokenService) CreateToken(ctx context.Context, token *tournament.Token) error {
+	query := `
+INSERT INTO tokens (token_hash, label, role, user_id)
+VALUES (?1, ?2, ?3, ?4)
+RETURNING id, created_at`
+
+	err := ts.DB.QueryRowContext(ctx, query, tournament.HashToken(token.Plaintext), token.Label, token.Role, token.UserID).Scan(&token.ID, &token.CreatedAt)
+	return translateError(err)
+}
+
+// AuthenticateToken finds the Token and updates its last use in a single statement.
+// SQLite does not allow joined tables in a RETURNING clause, so the name and Role of the User are selected separately.
+func (ts TokenService) AuthenticateToken(ctx context.Context, plaintext string) (token tournament.Token, err error) {
+	query := `
+UPDATE tokens
+SET last_used_at = strftime('%Y-%m-%d %H:%M:%f', 'now')
+WHERE token_hash = ?1
+RETURNING id, label, role, user_id,
+          (SELECT name FROM users WHERE users.id = tokens.user_id),
+          (SELECT role FROM users WHERE users.id = tokens.user_id),
+          created_at, last_used_at`
+
+	var userRole tournament.Role
+	err = ts.DB.QueryRowContext(ctx, query, tournament.HashToken(plaintext)).Scan(&token.ID, &token.Label, &token.Role, &token.UserID, &token.UserName, &userRole, &token.CreatedAt, &token.LastUsedAt)
+	token.Role = token.Role.Limit(userRole)
+
+	if err != nil && errors.Is(err, sql.ErrNoRows) {
+		err = tournament.Errorf(tournament.ENOTFOUND, "Token not found.")
+	}
+
+	return
+}
+
+func (ts TokenService) RevokeToken(ctx context.Context, id int64) error {
+	query := `
+DELETE
+FROM tokens
+WHERE id = ?1`
+
+	_, err := ts.DB.ExecContext(ctx, query, id)
+	return err
+}
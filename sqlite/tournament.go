@@ -0,0 +1,361 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	tournament "github.com/ejacobg/tourney-tracker"
+	"time"
+)
+
+// TournamentService represents a service for managing tournaments.
+type TournamentService struct {
+	DB *sql.DB
+}
+
+func (ts TournamentService) GetPreviews(ctx context.Context, gameID int64) (previews []tournament.Preview, err error) {
+	query := `
+SELECT tournaments.id, tournaments.name, tiers.name, COALESCE(games.name, '')
+FROM tournaments
+INNER JOIN tiers on tiers.id = tournaments.tier_id
+LEFT OUTER JOIN games on games.id = tournaments.game_id
+WHERE tournaments.deleted_at IS NULL
+  AND (?1 = 0 OR tournaments.game_id = ?1)`
+
+	rows, err := ts.DB.QueryContext(ctx, query, gameID)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var preview tournament.Preview
+
+		err = rows.Scan(&preview.ID, &preview.Name, &preview.Tier, &preview.Game)
+		if err != nil {
+			return
+		}
+
+		previews = append(previews, preview)
+	}
+
+	return previews, rows.Err()
+}
+
+func (ts TournamentService) GetNamesByTier(ctx context.Context, tierID int64) (names []tournament.Name, err error) {
+	query := `
+SELECT id, name
+FROM tournaments
+WHERE tier_id = ?1
+  AND deleted_at IS NULL`
+
+	rows, err := ts.DB.QueryContext(ctx, query, tierID)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name tournament.Name
+
+		err = rows.Scan(&name.ID, &name.Name)
+		if err != nil {
+			return
+		}
+
+		names = append(names, name)
+	}
+
+	return names, rows.Err()
+}
+
+func (ts TournamentService) GetTournament(ctx context.Context, id int64) (tournament.Tournament, error) {
+	return getTournament(ctx, ts.DB, id)
+}
+
+func (ts TournamentService) CreateTournament(ctx context.Context, tourney *tournament.Tournament, entrants []tournament.Entrant) error {
+	tx, err := ts.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if tourney.Game.Name != "" {
+		err = createGame(ctx, tx, &tourney.Game)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = createTournament(ctx, tx, tourney)
+	if err != nil {
+		return translateError(err)
+	}
+
+	err = createPhases(ctx, tx, tourney.Phases, tourney.ID)
+	if err != nil {
+		return translateError(err)
+	}
+
+	err = createEntrants(ctx, tx, entrants, tourney.ID)
+	if err != nil {
+		return translateError(err)
+	}
+
+	err = createEntry(ctx, tx, tournament.ActionCreateTournament, tourney.ID, nil, tourney)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (ts TournamentService) SetTier(ctx context.Context, tournamentID, tierID int64) error {
+	query := `
+UPDATE tournaments
+SET tier_id = ?2
+WHERE id = ?1
+  AND deleted_at IS NULL`
+
+	// The Tier is recorded rather than the whole Tournament.
+	return updateTournament(ctx, ts.DB, tournament.ActionSetTier, tournamentID, query, tierID, func(t tournament.Tournament) any { return t.Tier })
+}
+
+func (ts TournamentService) SetGame(ctx context.Context, tournamentID, gameID int64) error {
+	query := `
+UPDATE tournaments
+SET game_id = ?2
+WHERE id = ?1
+  AND deleted_at IS NULL`
+
+	return updateTournament(ctx, ts.DB, tournament.ActionSetGame, tournamentID, query, gameID, func(t tournament.Tournament) any { return t.Game })
+}
+
+func (ts TournamentService) SetTeamScoring(ctx context.Context, tournamentID int64, scoring tournament.TeamScoring) error {
+	query := `
+UPDATE tournaments
+SET team_scoring = ?2
+WHERE id = ?1
+  AND deleted_at IS NULL`
+
+	return updateTournament(ctx, ts.DB, tournament.ActionSetTeamScoring, tournamentID, query, scoring, func(t tournament.Tournament) any { return t.TeamScoring })
+}
+
+func (ts TournamentService) DeleteTournament(ctx context.Context, id int64) error {
+	tx, err := ts.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Tournaments in the trash cannot be read, so a missing or trashed Tournament is reported here.
+	before, err := getTournament(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	query := `
+UPDATE tournaments
+SET deleted_at = strftime('%Y-%m-%d %H:%M:%f', 'now')
+WHERE id = ?1
+  AND deleted_at IS NULL`
+
+	_, err = tx.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	err = createEntry(ctx, tx, tournament.ActionDeleteTournament, id, before, nil)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (ts TournamentService) GetDeletedTournaments(ctx context.Context) ([]tournament.Trashed, error) {
+	query := `
+SELECT id, name, deleted_at
+FROM tournaments
+WHERE deleted_at IS NOT NULL
+ORDER BY deleted_at DESC`
+
+	return getTrashed(ctx, ts.DB, query)
+}
+
+func (ts TournamentService) RestoreTournament(ctx context.Context, id int64) error {
+	tx, err := ts.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+UPDATE tournaments
+SET deleted_at = NULL
+WHERE id = ?1
+  AND deleted_at IS NOT NULL`
+
+	result, err := tx.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	if err = trashError(result, "Tournament not found in the trash."); err != nil {
+		return err
+	}
+
+	// The Tournament can only be read once it is out of the trash.
+	after, err := getTournament(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	err = createEntry(ctx, tx, tournament.ActionRestoreTournament, id, nil, after)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (ts TournamentService) PurgeTournaments(ctx context.Context, before time.Time) error {
+	query := `
+DELETE FROM tournaments
+WHERE deleted_at < ?1
+RETURNING id, name, deleted_at`
+
+	// Due to the way the database is set up, deleting a Tournament will also delete its entrants.
+	return purge(ctx, ts.DB, tournament.ActionPurgeTournament, query, before)
+}
+
+func createTournament(ctx context.Context, tx *sql.Tx, tourney *tournament.Tournament) error {
+	// Team tournaments get their own leaderboard unless told otherwise.
+	if tourney.TeamScoring == "" {
+		tourney.TeamScoring = tournament.SeparateLeaderboard
+	}
+
+	// Tournaments without a Tier are given the C-tier, which is assumed to always exist.
+	// SQLite does not allow joined tables in a RETURNING clause, so the Tier is selected separately.
+	query := `
+INSERT INTO tournaments (name, url, bracket_type, bracket_reset, teams, team_scoring, placements, game_id, tier_id)
+VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, NULLIF(?8, 0), COALESCE(NULLIF(?9, 0), 1))
+RETURNING id, tier_id, (SELECT name FROM tiers WHERE tiers.id = tier_id), (SELECT multiplier FROM tiers WHERE tiers.id = tier_id)`
+
+	return tx.QueryRowContext(ctx, query, tourney.Name, tourney.URL, tourney.BracketType, tourney.BracketReset, tourney.Teams, tourney.TeamScoring, jsonArray{tourney.Placements}, tourney.Game.ID, tourney.Tier.ID).
+		Scan(&tourney.ID, &tourney.Tier.ID, &tourney.Tier.Name, &tourney.Tier.Multiplier)
+}
+
+func getTournament(ctx context.Context, q queryer, id int64) (tourney tournament.Tournament, err error) {
+	if id < 1 {
+		return tourney, tournament.Errorf(tournament.ENOTFOUND, "Tournament not found.")
+	}
+
+	query := `
+SELECT tournaments.id, tournaments.name, url, bracket_type, bracket_reset, teams, team_scoring, placements, tier_id, tiers.name, tiers.multiplier,
+       COALESCE(game_id, 0), COALESCE(games.name, '')
+FROM tournaments INNER JOIN tiers ON tier_id = tiers.id
+LEFT OUTER JOIN games ON game_id = games.id
+WHERE tournaments.id = ?1
+  AND tournaments.deleted_at IS NULL;`
+
+	err = q.QueryRowContext(ctx, query, id).Scan(
+		&tourney.ID,
+		&tourney.Name,
+		&tourney.URL,
+		&tourney.BracketType,
+		&tourney.BracketReset,
+		&tourney.Teams,
+		&tourney.TeamScoring,
+		jsonArray{&tourney.Placements},
+		&tourney.Tier.ID,
+		&tourney.Tier.Name,
+		&tourney.Tier.Multiplier,
+		&tourney.Game.ID,
+		&tourney.Game.Name,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = tournament.Errorf(tournament.ENOTFOUND, "Tournament not found.")
+		}
+		return
+	}
+
+	tourney.Phases, err = getPhases(ctx, q, id)
+	return
+}
+
+func createPhases(ctx context.Context, tx *sql.Tx, phases []tournament.Phase, tournamentID int64) error {
+	query := `
+INSERT INTO phases (name, bracket_type, phase_order, tournament_id)
+VALUES (?1, ?2, ?3, ?4)
+RETURNING id`
+
+	for i, phase := range phases {
+		err := tx.QueryRowContext(ctx, query, phase.Name, phase.BracketType, phase.Order, tournamentID).Scan(&phases[i].ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func getPhases(ctx context.Context, q queryer, tournamentID int64) (phases []tournament.Phase, err error) {
+	query := `
+SELECT id, name, bracket_type, phase_order
+FROM phases
+WHERE tournament_id = ?1
+ORDER BY phase_order`
+
+	rows, err := q.QueryContext(ctx, query, tournamentID)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var phase tournament.Phase
+
+		err = rows.Scan(&phase.ID, &phase.Name, &phase.BracketType, &phase.Order)
+		if err != nil {
+			return
+		}
+
+		phases = append(phases, phase)
+	}
+
+	return phases, rows.Err()
+}
+
+// updateTournament runs the given query, which should change a single field of the Tournament to the given value, and records the change.
+// The field function picks out the changed field, which is recorded before and after the change.
+func updateTournament(ctx context.Context, db *sql.DB, action tournament.Action, id int64, query string, value any, field func(tournament.Tournament) any) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := getTournament(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, query, id, value)
+	if err != nil {
+		return translateError(err)
+	}
+
+	after, err := getTournament(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	err = createEntry(ctx, tx, action, id, field(before), field(after))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
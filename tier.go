@@ -1,5 +1,12 @@
 package tourney_tracker
 
+import (
+	"context"
+	"fmt"
+	"github.com/ejacobg/tourney-tracker/validator"
+	"strings"
+)
+
 // Tier represents the relative importance of a Tournament.
 type Tier struct {
 	ID         int64  `json:"id"`
@@ -7,25 +14,44 @@ type Tier struct {
 	Multiplier int    `json:"multiplier"`
 }
 
+// MaxMultiplier is the largest Multiplier that a Tier may have.
+const MaxMultiplier = 100
+
+// ValidateTier trims the Tier's name, then checks that it is not empty and that its Multiplier is between 1 and MaxMultiplier.
+func ValidateTier(v *validator.Validator, tier *Tier) {
+	tier.Name = strings.TrimSpace(tier.Name)
+	v.Check(validator.NotBlank(tier.Name), "name", "Name must not be empty.")
+	v.Check(validator.MaxChars(tier.Name, MaxNameLength), "name", fmt.Sprintf("Name must not be longer than %d characters.", MaxNameLength))
+	v.Check(tier.Multiplier > 0, "multiplier", "Multiplier must be greater than 0.")
+	v.Check(tier.Multiplier <= MaxMultiplier, "multiplier", fmt.Sprintf("Multiplier must not be greater than %d.", MaxMultiplier))
+}
+
+// ValidateTierID checks that the given Tier exists. Errors are added under the given key.
+// An error is only returned if the Tier could not be checked.
+func ValidateTierID(ctx context.Context, v *validator.Validator, ts TierService, key string, id int64) error {
+	_, err := ts.GetTier(ctx, id)
+	return checkFound(v, key, "That tier does not exist.", err)
+}
+
 // TierService represents a service for managing tiers.
 type TierService interface {
 	// GetTiers returns all tiers.
-	GetTiers() ([]Tier, error)
+	GetTiers(ctx context.Context) ([]Tier, error)
 
 	// GetTier returns a single Tier by ID.
-	GetTier(id int64) (Tier, error)
+	GetTier(ctx context.Context, id int64) (Tier, error)
 
-	// GetTournamentTier returns the Tier for the given Tournament.
-	GetTournamentTier(tournamentID int64) (Tier, error)
+	// GetTournamentTier returns the Tier for the given Tournament. An ENOTFOUND error is returned if the Tournament does not exist.
+	GetTournamentTier(ctx context.Context, tournamentID int64) (Tier, error)
 
 	// CreateTier adds the given Tier to the database.
-	CreateTier(tier *Tier) error
+	CreateTier(ctx context.Context, tier *Tier) error
 
 	// UpdateTier updates the given Tier.
-	UpdateTier(tier *Tier) error
+	UpdateTier(ctx context.Context, tier *Tier) error
 
 	// DeleteTier deletes the given Tier.
-	// Note that deleting a tier that still has tournaments attached to it should fail.
+	// Deleting a tier that still has tournaments attached to it, including trashed ones, fails with an ECONFLICT error.
 	// It is up to the user to ensure that all tournaments update their Tier before attempting to delete.
-	DeleteTier(id int64) error
+	DeleteTier(ctx context.Context, id int64) error
 }
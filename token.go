@@ -0,0 +1,58 @@
+package tourney_tracker
+
+import (
+	"context"
+	"fmt"
+	"github.com/ejacobg/tourney-tracker/validator"
+	"strings"
+	"time"
+)
+
+// Token is an API token, which allows programs such as bots to make changes without logging in.
+// A Token acts on behalf of the User who created it, but is limited to its own Role.
+// Tokens are sent in the Authorization header of a request, eg. "Authorization: Bearer <token>".
+type Token struct {
+	ID    int64  `json:"id"`
+	Label string `json:"label"`
+
+	// Role is the scope of the Token. It may not be higher than the Role of the User who created it.
+	// Users may be demoted after creating a Token, so AuthenticateToken limits the Role to the User's current Role.
+	Role Role `json:"role"`
+
+	UserID   int64  `json:"userID"`
+	UserName string `json:"userName"`
+
+	CreatedAt time.Time `json:"createdAt"`
+
+	// LastUsedAt is the last time the Token was used, or nil if it has never been used.
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+
+	// Plaintext is the value of the Token. It is only known when the Token is created, as only its hash is stored.
+	Plaintext string `json:"-"`
+}
+
+// ValidateToken trims the Token's label, then checks that it is not empty or too long.
+// The Token must have a valid Role, which may not be higher than the Role of the User creating it.
+func ValidateToken(v *validator.Validator, token *Token, creator Role) {
+	token.Label = strings.TrimSpace(token.Label)
+	v.Check(validator.NotBlank(token.Label), "label", "Label must not be empty.")
+	v.Check(validator.MaxChars(token.Label, MaxNameLength), "label", fmt.Sprintf("Label must not be longer than %d characters.", MaxNameLength))
+	v.Check(token.Role.Valid(), "role", "Invalid role.")
+	v.Check(creator.Includes(token.Role), "role", "A token cannot have a higher role than your own.")
+}
+
+// TokenService represents a service for managing API tokens.
+type TokenService interface {
+	// GetTokens returns all tokens, newest first.
+	GetTokens(ctx context.Context) ([]Token, error)
+
+	// CreateToken stores the given Token, using the hash of its Plaintext.
+	CreateToken(ctx context.Context, token *Token) error
+
+	// AuthenticateToken returns the Token with the given plaintext value, and records that it was used.
+	// The Role of the returned Token is limited to the current Role of its User.
+	AuthenticateToken(ctx context.Context, plaintext string) (Token, error)
+
+	// RevokeToken deletes the given Token, so that it can no longer be used.
+	RevokeToken(ctx context.Context, id int64) error
+}
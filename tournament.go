@@ -1,55 +1,173 @@
 // Package tourney_tracker contains core types that deal with handling Tournament objects.
 package tourney_tracker
 
+import (
+	"context"
+	"fmt"
+	"github.com/ejacobg/tourney-tracker/validator"
+	"golang.org/x/exp/slices"
+	"strings"
+	"time"
+)
+
 // Tournament holds fields relevant to the point calculation. A tournament is generally considered immutable after creation, except for its Tier.
 // It is assumed that the original tournament has already been completed. In-progress tournaments may not be parsed correctly.
-// It is assumed that the tournament is double-elimination, however the point formula may still work for other bracket types.
+// The BracketType of the tournament decides which parts of the point formula apply. See Formula.Points() for more.
 type Tournament struct {
 	ID   int64  `json:"id"`
 	Name string `json:"name"`
 	URL  string `json:"url"`
 
+	// BracketType is the format that the tournament was run in.
+	BracketType BracketType `json:"bracketType"`
+
 	// BracketReset is true if any bracket reset points should be applied to the second-place entrant.
+	// This is only ever true for double-elimination tournaments.
 	BracketReset bool `json:"bracketReset"`
 
+	// Teams is true if the entrants of this tournament are teams rather than individual players.
+	Teams bool `json:"teams"`
+
+	// TeamScoring decides which leaderboard the points of a team tournament count towards. It is ignored if Teams is false.
+	TeamScoring TeamScoring `json:"teamScoring"`
+
 	// Placements contains the unique placements of a tournament, in reverse-sorted order.
 	// For example, if the final standings for an 8-man tournament are [7, 7, 5, 5, 4, 3, 2, 1], then the unique placements are [7, 5, 4, 3, 2, 1].
 	Placements []int64 `json:"placements"` // Can't scan into the normal int type, use int64 or sql.NullInt64. (https://stackoverflow.com/questions/47962615/query-for-an-integer-array-from-postresql-always-returns-uint8)
 
-	Tier Tier
+	Tier Tier `json:"tier"`
+
+	// Game is the game that the tournament was played in. Tournaments imported before games were tracked will have an empty Game.
+	Game Game `json:"game"`
+
+	// Phases holds the stages of a multi-stage tournament, ordered by Phase.Order. Single-stage tournaments have no phases.
+	Phases []Phase `json:"phases"`
+}
+
+// BracketType represents the format of a Tournament. The values are the same ones used by Challonge.
+type BracketType string
+
+const (
+	DoubleElimination BracketType = "double elimination"
+	SingleElimination BracketType = "single elimination"
+	RoundRobin        BracketType = "round robin"
+	Swiss             BracketType = "swiss"
+)
+
+// Elimination returns true if entrants are knocked out of the tournament, rather than ranked by their record.
+func (b BracketType) Elimination() bool {
+	return b == DoubleElimination || b == SingleElimination
+}
+
+// TeamScoring represents how the points of a team Tournament are credited.
+type TeamScoring string
+
+const (
+	// CreditTeammates adds the points earned by a team to the singles ranking of every teammate.
+	CreditTeammates TeamScoring = "credit"
+
+	// SeparateLeaderboard adds the points earned by a team to the doubles leaderboard of every teammate.
+	SeparateLeaderboard TeamScoring = "separate"
+)
+
+// Doubles returns true if the points earned in this Tournament count towards the doubles leaderboard rather than the singles rankings.
+func (t Tournament) Doubles() bool {
+	return t.Teams && t.TeamScoring == SeparateLeaderboard
+}
+
+// ValidateTournament checks a Tournament and its entrants before they are saved.
+// The placements must be consistent with the number of entrants: each placement must be one more than the number of entrants placed above it
+// (eg. two entrants tied for 5th are followed by 7th), and the Placements of the Tournament must be the unique placements of its entrants.
+func ValidateTournament(v *validator.Validator, tourney *Tournament, entrants []Entrant) {
+	tourney.Name = strings.TrimSpace(tourney.Name)
+	v.Check(validator.NotBlank(tourney.Name), "name", "The tournament must have a name.")
+	v.Check(validator.PermittedValue(tourney.BracketType, DoubleElimination, SingleElimination, RoundRobin, Swiss), "bracketType", fmt.Sprintf("Unsupported bracket type %q.", tourney.BracketType))
+	v.Check(tourney.TeamScoring == "" || ValidTeamScoring(tourney.TeamScoring), "teamScoring", "Invalid team scoring.")
+	v.Check(len(entrants) > 0, "entrants", "The tournament must have at least one entrant.")
+
+	counts := make(map[int64]int)
+	for _, entrant := range entrants {
+		counts[entrant.Placement]++
+	}
+
+	// Placements are visited from first to last, counting the entrants placed above each one.
+	placements := make([]int64, 0, len(counts))
+	for placement := range counts {
+		placements = append(placements, placement)
+	}
+	slices.Sort(placements)
+
+	above := 0
+	for _, placement := range placements {
+		v.Check(placement == int64(above+1), "placements", fmt.Sprintf("Placement %d does not follow the %d entrants placed above it.", placement, above))
+		above += counts[placement]
+	}
+
+	// The Placements of the Tournament are in reverse-sorted order.
+	unique := len(placements) == len(tourney.Placements)
+	for i := 0; unique && i < len(placements); i++ {
+		unique = placements[i] == tourney.Placements[len(placements)-1-i]
+	}
+	v.Check(unique, "placements", "The placements of the tournament do not match the placements of its entrants.")
+}
+
+// ValidTeamScoring returns true if the TeamScoring is one of the known values.
+func ValidTeamScoring(scoring TeamScoring) bool {
+	return validator.PermittedValue(scoring, CreditTeammates, SeparateLeaderboard)
 }
 
 // TournamentService represents a service for managing tournaments.
 type TournamentService interface {
-	// GetPreviews returns previews for all tournaments.
-	GetPreviews() ([]Preview, error)
+	// GetPreviews returns previews for all tournaments of the given Game. A gameID of 0 returns previews for every tournament.
+	GetPreviews(ctx context.Context, gameID int64) ([]Preview, error)
 
 	// GetNamesByTier returns the names of all tournaments with the given tier.
-	GetNamesByTier(tierID int64) ([]Name, error)
+	GetNamesByTier(ctx context.Context, tierID int64) ([]Name, error)
 
-	// GetTournament returns a single Tournament by ID.
-	GetTournament(id int64) (Tournament, error)
+	// GetTournament returns a single Tournament by ID, including its phases. Tournaments in the trash are not found.
+	GetTournament(ctx context.Context, id int64) (Tournament, error)
 
-	// CreateTournament adds the given Tournament and its entrants to the database.
+	// CreateTournament adds the given Tournament, its phases, and its entrants (and their results) to the database.
 	// The Tournament and entrants should be created in the same transaction.
-	CreateTournament(tourney *Tournament, entrants []Entrant) error
+	// If the Game of the Tournament has a name, it will be created if it does not already exist.
+	// The Tournament is given the Tier with its Tier.ID, or the C-tier (ID 1) if that is 0.
+	CreateTournament(ctx context.Context, tourney *Tournament, entrants []Entrant) error
+
+	// SetTier updates the Tier of the given Tournament. Returns ENOTFOUND if the Tournament does not exist, or is in the trash.
+	SetTier(ctx context.Context, tournamentID, tierID int64) error
 
-	// SetTier updates the Tier of the given Tournament.
-	SetTier(tournamentID, tierID int64) error
+	// SetGame updates the Game of the given Tournament. Returns ENOTFOUND if the Tournament does not exist, or is in the trash.
+	SetGame(ctx context.Context, tournamentID, gameID int64) error
 
-	// DeleteTournament deletes a Tournament.
-	DeleteTournament(id int64) error
+	// SetTeamScoring updates how the points of the given team Tournament are credited.
+	// Returns ENOTFOUND if the Tournament does not exist, or is in the trash.
+	SetTeamScoring(ctx context.Context, tournamentID int64, scoring TeamScoring) error
+
+	// DeleteTournament moves a Tournament to the trash. Trashed tournaments are excluded from previews, names, rankings, and attendance.
+	// Its entrants are kept until the Tournament is purged, so that it can be restored.
+	// Returns ENOTFOUND if the Tournament does not exist, or is already in the trash.
+	DeleteTournament(ctx context.Context, id int64) error
+
+	// GetDeletedTournaments returns all tournaments in the trash, most recently deleted first.
+	GetDeletedTournaments(ctx context.Context) ([]Trashed, error)
+
+	// RestoreTournament moves a Tournament out of the trash. Returns ENOTFOUND if the Tournament is not in the trash.
+	RestoreTournament(ctx context.Context, id int64) error
+
+	// PurgeTournaments permanently deletes every Tournament that was moved to the trash before the given time, along with its entrants.
+	PurgeTournaments(ctx context.Context, before time.Time) error
 }
 
-// Preview represents a subset of a Tournament object, namely its ID, name, and Tier.
+// Preview represents a subset of a Tournament object, namely its ID, name, Tier, and Game.
 type Preview struct {
-	ID   int64
-	Name string
-	Tier string
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	Tier string `json:"tier"`
+	Game string `json:"game"`
 }
 
 // Name represents a unique Tournament name.
 type Name struct {
-	ID   int64
-	Name string
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
 }
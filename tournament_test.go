@@ -0,0 +1,47 @@
+package tourney_tracker
+
+import (
+	"github.com/ejacobg/tourney-tracker/validator"
+	"testing"
+)
+
+func TestValidateTournament(t *testing.T) {
+	// entrants returns an Entrant for each of the given placements.
+	entrants := func(placements ...int64) []Entrant {
+		entrants := make([]Entrant, len(placements))
+		for i, placement := range placements {
+			entrants[i].Placement = placement
+		}
+		return entrants
+	}
+
+	tests := []struct {
+		name       string
+		tourney    Tournament
+		entrants   []Entrant
+		wantErrors []string
+	}{
+		{"valid", Tournament{Name: " Gator Grind ", BracketType: DoubleElimination, Placements: []int64{7, 5, 4, 3, 2, 1}}, entrants(1, 2, 3, 4, 5, 5, 7, 7), nil},
+		{"blank name", Tournament{Name: " ", BracketType: Swiss, Placements: []int64{2, 1}}, entrants(1, 2), []string{"name"}},
+		{"unknown bracket", Tournament{Name: "T", BracketType: "free for all", Placements: []int64{2, 1}}, entrants(1, 2), []string{"bracketType"}},
+		{"no entrants", Tournament{Name: "T", BracketType: DoubleElimination}, nil, []string{"entrants"}},
+		{"gap in placements", Tournament{Name: "T", BracketType: DoubleElimination, Placements: []int64{5, 3, 2, 1}}, entrants(1, 2, 3, 5), []string{"placements"}},
+		{"too many tied", Tournament{Name: "T", BracketType: DoubleElimination, Placements: []int64{3, 2, 1}}, entrants(1, 2, 2, 3), []string{"placements"}},
+		{"mismatched placements", Tournament{Name: "T", BracketType: SingleElimination, Placements: []int64{3, 2, 1}}, entrants(1, 2), []string{"placements"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := validator.New()
+			ValidateTournament(v, &tt.tourney, tt.entrants)
+
+			if len(v.Errors) != len(tt.wantErrors) {
+				t.Errorf("ValidateTournament() errors = %v, want errors for %v", v.Errors, tt.wantErrors)
+			}
+			for _, key := range tt.wantErrors {
+				if _, ok := v.Errors[key]; !ok {
+					t.Errorf("ValidateTournament() errors = %v, want an error for %q", v.Errors, key)
+				}
+			}
+		})
+	}
+}
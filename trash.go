@@ -0,0 +1,18 @@
+package tourney_tracker
+
+import "time"
+
+// DefaultTrashRetention is how long deleted tournaments and players are kept in the trash, unless configured otherwise.
+const DefaultTrashRetention = 30 * 24 * time.Hour
+
+// Trashed is a deleted Tournament or Player. Trashed objects are hidden from the rest of the tracker, but can be restored until they are purged.
+type Trashed struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	DeletedAt time.Time `json:"deletedAt"`
+}
+
+// PurgeAt returns the time that the object will be permanently deleted, if objects are kept in the trash for the given duration.
+func (t Trashed) PurgeAt(retention time.Duration) time.Time {
+	return t.DeletedAt.Add(retention)
+}
@@ -0,0 +1,109 @@
+// Renders an OpenAPI document into the #openapi element.
+// Only the parts of the specification used by our own document are supported.
+(function () {
+    const root = document.getElementById('openapi');
+
+    // Creates an element with the given tag, attributes, and children. Strings are added as text.
+    function el(tag, attrs, ...children) {
+        const node = document.createElement(tag);
+        for (const [key, value] of Object.entries(attrs || {})) {
+            node.setAttribute(key, value);
+        }
+        for (const child of children) {
+            node.append(child);
+        }
+        return node;
+    }
+
+    // Describes a schema as a short, readable type, eg. "array of Player". Referenced schemas link to their definition.
+    function typeOf(schema) {
+        if (schema.$ref) {
+            const name = schema.$ref.split('/').pop();
+            return el('a', {href: '#schema-' + name}, name);
+        }
+        switch (schema.type) {
+            case 'array':
+                return el('span', {}, 'array of ', typeOf(schema.items));
+            case 'object':
+                if (schema.additionalProperties) {
+                    return el('span', {}, 'map of ', typeOf(schema.additionalProperties));
+                }
+                if (schema.properties) {
+                    return properties(schema);
+                }
+                return 'object';
+            case undefined:
+                return 'any';
+            default:
+                return schema.format ? `${schema.type} (${schema.format})` : schema.type;
+        }
+    }
+
+    // Lists the properties of an object schema.
+    function properties(schema) {
+        const list = el('ul');
+        for (const [name, property] of Object.entries(schema.properties || {})) {
+            list.append(el('li', {}, el('code', {}, name), ': ', typeOf(property)));
+        }
+        return list;
+    }
+
+    function operation(path, method, op) {
+        const section = el('section', {id: op.operationId},
+            el('h4', {}, el('code', {}, `${method.toUpperCase()} ${path}`)),
+            el('p', {}, op.summary));
+
+        if (op.parameters) {
+            const rows = op.parameters.map(param => el('tr', {},
+                el('td', {}, el('code', {}, param.name)),
+                el('td', {}, param.in),
+                el('td', {}, typeOf(param.schema)),
+                el('td', {}, param.description || '')));
+            section.append(el('table', {},
+                el('thead', {}, el('tr', {}, el('th', {}, 'Parameter'), el('th', {}, 'In'), el('th', {}, 'Type'), el('th', {}, 'Description'))),
+                el('tbody', {}, ...rows)));
+        }
+
+        if (op.requestBody) {
+            section.append(el('p', {}, 'Request body: ', typeOf(op.requestBody.content['application/json'].schema)));
+        }
+
+        for (const [status, response] of Object.entries(op.responses)) {
+            const content = response.content && response.content['application/json'];
+            section.append(el('p', {}, `${status} (${response.description})`, content ? ': ' : '', content ? typeOf(content.schema) : ''));
+        }
+
+        return section;
+    }
+
+    function render(doc) {
+        root.replaceChildren(el('p', {}, `${doc.info.title} ${doc.info.version}. ${doc.info.description || ''}`));
+
+        // Group the operations by their first tag.
+        const tags = new Map();
+        for (const [path, methods] of Object.entries(doc.paths)) {
+            for (const [method, op] of Object.entries(methods)) {
+                const tag = (op.tags && op.tags[0]) || 'Other';
+                if (!tags.has(tag)) {
+                    tags.set(tag, []);
+                }
+                tags.get(tag).push(operation(path, method, op));
+            }
+        }
+        for (const [tag, sections] of tags) {
+            root.append(el('h3', {}, tag), ...sections);
+        }
+
+        root.append(el('h3', {}, 'Schemas'));
+        for (const [name, schema] of Object.entries(doc.components.schemas)) {
+            root.append(el('section', {id: 'schema-' + name}, el('h4', {}, name), typeOf(schema)));
+        }
+    }
+
+    fetch(root.dataset.src)
+        .then(response => response.json())
+        .then(render)
+        .catch(err => {
+            root.textContent = 'Failed to load the API document: ' + err;
+        });
+})();
@@ -0,0 +1,9 @@
+// Package ui holds the HTML templates and static files of the web interface, so that they are built into the binary.
+package ui
+
+import "embed"
+
+// Files holds the html and static directories.
+//
+//go:embed html static
+var Files embed.FS
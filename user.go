@@ -0,0 +1,140 @@
+package tourney_tracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ejacobg/tourney-tracker/validator"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/exp/slices"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCredentials is returned when a User cannot be authenticated with the given name and password.
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// User represents someone who may log in to the tracker. What they are allowed to change depends on their Role.
+// Visitors who are not logged in can only view the rankings and tournament history.
+type User struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Role      Role      `json:"role"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	// PasswordHash is the bcrypt hash of the User's password. The password itself is never stored.
+	PasswordHash []byte `json:"-"`
+}
+
+// Role decides what a User is allowed to change. Each Role includes the permissions of the roles before it.
+type Role string
+
+const (
+	// RoleViewer can only view the tracker, the same as a visitor who is not logged in.
+	RoleViewer Role = "viewer"
+
+	// RoleEditor can add and rename players, and link entrants to players.
+	RoleEditor Role = "editor"
+
+	// RoleOrganizer can import and delete tournaments, change their game and team scoring, and delete players.
+	RoleOrganizer Role = "organizer"
+
+	// RoleAdmin can change tiers and manage users.
+	RoleAdmin Role = "admin"
+)
+
+// Roles holds every Role, from least to most permissive.
+var Roles = []Role{RoleViewer, RoleEditor, RoleOrganizer, RoleAdmin}
+
+// Valid returns true if the Role is one of the known roles.
+func (r Role) Valid() bool {
+	return slices.Contains(Roles, r)
+}
+
+// Includes returns true if the Role has all the permissions of the other Role.
+func (r Role) Includes(other Role) bool {
+	return r.Valid() && other.Valid() && slices.Index(Roles, r) >= slices.Index(Roles, other)
+}
+
+// Limit returns the Role, or max if the Role has more permissions than it.
+func (r Role) Limit(max Role) Role {
+	if max.Includes(r) {
+		return r
+	}
+	return max
+}
+
+// MinPasswordLength is the shortest password that a User may have.
+const MinPasswordLength = 8
+
+// ValidateUser trims the User's name, then checks that it is not empty or too long, and that the User has a valid Role.
+func ValidateUser(v *validator.Validator, user *User) {
+	user.Name = strings.TrimSpace(user.Name)
+	v.Check(validator.NotBlank(user.Name), "name", "Name must not be empty.")
+	v.Check(validator.MaxChars(user.Name, MaxNameLength), "name", fmt.Sprintf("Name must not be longer than %d characters.", MaxNameLength))
+	v.Check(user.Role.Valid(), "role", "Invalid role.")
+}
+
+// ValidatePassword checks that a new password is long enough.
+func ValidatePassword(v *validator.Validator, password string) {
+	v.Check(len(password) >= MinPasswordLength, "password", fmt.Sprintf("Password must be at least %d characters long.", MinPasswordLength))
+}
+
+// SetPassword replaces the PasswordHash of the User with the hash of the given password.
+func (u *User) SetPassword(password string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), 12)
+	if err != nil {
+		return err
+	}
+
+	u.PasswordHash = hash
+	return nil
+}
+
+// PasswordMatches returns true if the given password matches the PasswordHash of the User.
+func (u User) PasswordMatches(password string) bool {
+	return bcrypt.CompareHashAndPassword(u.PasswordHash, []byte(password)) == nil
+}
+
+// dummyHash is checked in place of the PasswordHash of a User who does not exist, so that checking their password takes just as long.
+var dummyHash = []byte("$2a$12$kgylzrcNsCZVIDl.0s3GyeaRv737dcd7UlSx/N0Cp.rFMorMROwdy")
+
+// Authenticate returns the User with the given name if the password matches.
+// Unknown names and wrong passwords both return ErrInvalidCredentials, and take as long as each other, so that user names cannot be discovered.
+// Any other error is returned as-is.
+func Authenticate(ctx context.Context, us UserService, name, password string) (User, error) {
+	user, err := us.GetUserByName(ctx, name)
+	switch {
+	case ErrorCode(err) == ENOTFOUND:
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return User{}, ErrInvalidCredentials
+	case err != nil:
+		return User{}, err
+	case !user.PasswordMatches(password):
+		return User{}, ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+// UserService represents a service for managing users.
+type UserService interface {
+	// GetUsers returns all users, ordered by name.
+	GetUsers(ctx context.Context) ([]User, error)
+
+	// GetUser returns a single User by ID.
+	GetUser(ctx context.Context, id int64) (User, error)
+
+	// GetUserByName returns a single User by name.
+	GetUserByName(ctx context.Context, name string) (User, error)
+
+	// CreateUser adds the given User to the database. User names must be unique.
+	CreateUser(ctx context.Context, user *User) error
+
+	// UpdateUser updates the name, Role, and PasswordHash of the given User.
+	// Returns ECONFLICT if the User is the last admin and would no longer be one, and ENOTFOUND if they do not exist.
+	UpdateUser(ctx context.Context, user *User) error
+
+	// DeleteUser deletes the given User, logging them out of all their sessions.
+	// Returns ECONFLICT if the User is the last admin, and ENOTFOUND if they do not exist.
+	DeleteUser(ctx context.Context, id int64) error
+}
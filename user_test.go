@@ -0,0 +1,100 @@
+package tourney_tracker
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRole_Includes(t *testing.T) {
+	tests := []struct {
+		role  Role
+		other Role
+		want  bool
+	}{
+		{RoleAdmin, RoleViewer, true},
+		{RoleAdmin, RoleAdmin, true},
+		{RoleOrganizer, RoleEditor, true},
+		{RoleEditor, RoleOrganizer, false},
+		{RoleViewer, RoleEditor, false},
+		{Role("owner"), RoleViewer, false},
+		{Role(""), RoleViewer, false},
+		{RoleAdmin, Role("owner"), false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.role)+"/"+string(tt.other), func(t *testing.T) {
+			if got := tt.role.Includes(tt.other); got != tt.want {
+				t.Errorf("Includes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRole_Limit(t *testing.T) {
+	tests := []struct {
+		role Role
+		max  Role
+		want Role
+	}{
+		{RoleEditor, RoleAdmin, RoleEditor},
+		{RoleAdmin, RoleAdmin, RoleAdmin},
+		{RoleAdmin, RoleEditor, RoleEditor},
+		{RoleOrganizer, RoleViewer, RoleViewer},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.role)+"/"+string(tt.max), func(t *testing.T) {
+			if got := tt.role.Limit(tt.max); got != tt.want {
+				t.Errorf("Limit() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// userService is a UserService holding a single User. All other names are not found.
+type userService struct {
+	UserService
+	user User
+	err  error
+}
+
+func (us userService) GetUserByName(_ context.Context, name string) (User, error) {
+	if us.err != nil {
+		return User{}, us.err
+	}
+	if name != us.user.Name {
+		return User{}, Errorf(ENOTFOUND, "User not found.")
+	}
+	return us.user, nil
+}
+
+func TestAuthenticate(t *testing.T) {
+	user := User{Name: "admin"}
+	if err := user.SetPassword("correct horse"); err != nil {
+		t.Fatal("SetPassword() error:", err)
+	}
+	failure := errors.New("connection refused")
+
+	tests := []struct {
+		name     string
+		us       userService
+		userName string
+		password string
+		wantErr  error
+	}{
+		{"correct", userService{user: user}, "admin", "correct horse", nil},
+		{"wrong password", userService{user: user}, "admin", "battery staple", ErrInvalidCredentials},
+		{"unknown name", userService{user: user}, "nobody", "correct horse", ErrInvalidCredentials},
+		{"failed lookup", userService{user: user, err: failure}, "admin", "correct horse", failure},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Authenticate(context.Background(), tt.us, tt.userName, tt.password)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Authenticate() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && got.Name != tt.userName {
+				t.Errorf("Authenticate() = %+v, want %s", got, tt.userName)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+// Package validator collects the problems found with user input, so that they can be shown next to the fields that caused them.
+package validator
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Validator holds an error message for each invalid field, keyed by the field's name.
+type Validator struct {
+	Errors map[string]string
+}
+
+// New returns a Validator with no errors.
+func New() *Validator {
+	return &Validator{Errors: make(map[string]string)}
+}
+
+// Valid returns true if no errors have been added.
+func (v *Validator) Valid() bool {
+	return len(v.Errors) == 0
+}
+
+// AddError adds an error message for the given field. Only the first message for each field is kept.
+func (v *Validator) AddError(key, message string) {
+	if _, exists := v.Errors[key]; !exists {
+		v.Errors[key] = message
+	}
+}
+
+// Check adds an error message for the given field if ok is false.
+func (v *Validator) Check(ok bool, key, message string) {
+	if !ok {
+		v.AddError(key, message)
+	}
+}
+
+// NotBlank returns true if the value contains anything other than whitespace.
+func NotBlank(value string) bool {
+	return strings.TrimSpace(value) != ""
+}
+
+// MaxChars returns true if the value has at most n characters.
+func MaxChars(value string, n int) bool {
+	return utf8.RuneCountInString(value) <= n
+}
+
+// PermittedValue returns true if the value is one of the permitted values.
+func PermittedValue[T comparable](value T, permitted ...T) bool {
+	for _, p := range permitted {
+		if value == p {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package validator
+
+import "testing"
+
+func TestValidator_AddError(t *testing.T) {
+	v := New()
+	if !v.Valid() {
+		t.Fatal("Valid() = false for a new Validator")
+	}
+
+	v.Check(true, "name", "Ignored.")
+	v.Check(false, "name", "Name must not be empty.")
+	v.AddError("name", "Name is too long.")
+
+	if v.Valid() {
+		t.Error("Valid() = true after adding an error")
+	}
+	if got := v.Errors["name"]; got != "Name must not be empty." {
+		t.Errorf(`Errors["name"] = %q, want the first message`, got)
+	}
+}
+
+func TestNotBlank(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"", false},
+		{" \t\n", false},
+		{"Ean", true},
+		{"  Ean  ", true},
+	}
+	for _, tt := range tests {
+		if got := NotBlank(tt.value); got != tt.want {
+			t.Errorf("NotBlank(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestMaxChars(t *testing.T) {
+	if !MaxChars("ééé", 3) {
+		t.Error(`MaxChars("ééé", 3) = false, want characters to be counted rather than bytes`)
+	}
+	if MaxChars("abcd", 3) {
+		t.Error(`MaxChars("abcd", 3) = true, want false`)
+	}
+}